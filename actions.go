@@ -0,0 +1,449 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+
+	"gosearch/search"
+)
+
+// action is something that can be done with the highlighted package.
+type action struct {
+	name        string
+	description string
+	run         func(m *model, pkg Package) tea.Cmd
+	// noAudit leaves the action out of the audit log because it does not
+	// pick or install anything.
+	noAudit bool
+}
+
+// actions lists every action available for the enter_action setting.
+var actions = map[string]action{
+	"copy": {
+		name:        "copy",
+		description: "Copy the import path to the clipboard",
+		run: func(m *model, pkg Package) tea.Cmd {
+			return copyAndQuit(m, pkg.Path)
+		},
+	},
+	"copy-pinned": {
+		name:        "copy-pinned",
+		description: "Copy path@version to the clipboard",
+		run: func(m *model, pkg Package) tea.Cmd {
+			return copyAndQuit(m, pkg.Path+"@"+m.packageVersion(pkg))
+		},
+	},
+	"copy-get": {
+		name:        "copy-get",
+		description: "Copy a go get command to the clipboard",
+		run: func(m *model, pkg Package) tea.Cmd {
+			return copyAndQuit(m, "go get "+pkg.Path+"@"+m.packageVersion(pkg))
+		},
+	},
+	"copy-require": {
+		name:        "copy-require",
+		description: "Copy a require directive for go.mod to the clipboard",
+		run: func(m *model, pkg Package) tea.Cmd {
+			return copyAndQuit(m, "require "+pkg.Path+" "+m.packageVersion(pkg))
+		},
+	},
+	"copy-install": {
+		name:        "copy-install",
+		description: "Copy a go install command to the clipboard",
+		run: func(m *model, pkg Package) tea.Cmd {
+			return copyAndQuit(m, "go install "+pkg.Path+"@latest")
+		},
+	},
+	"print": {
+		name:        "print",
+		description: "Print the import path to stdout",
+		run: func(m *model, pkg Package) tea.Cmd {
+			m.output = pkg.Path
+			return m.quit("")
+		},
+	},
+	"get": {
+		name:        "get",
+		description: "Run go get in the current directory",
+		run: func(m *model, pkg Package) tea.Cmd {
+			target := pkg.Path + "@" + m.packageVersion(pkg)
+			gated := policyGateCmd(m.policy, pkg.Path, m.packageVersion(pkg), goGetCmd(target))
+			return tea.Sequence(gated, m.quit(fmt.Sprintf("Added '%s' to the current module.", target)))
+		},
+	},
+	"vendor": {
+		name:        "vendor",
+		description: "Run go get, then go mod vendor to update vendor/",
+		run: func(m *model, pkg Package) tea.Cmd {
+			target := pkg.Path + "@" + m.packageVersion(pkg)
+			gated := policyGateCmd(m.policy, pkg.Path, m.packageVersion(pkg), goGetCmd(target))
+			return tea.Sequence(gated, goModVendorCmd(), m.quit(fmt.Sprintf("Added '%s' to the current module and re-vendored it.", target)))
+		},
+	},
+	"upgrade": {
+		name:        "upgrade",
+		description: "Preview upgrading the dependency, then run go get -u",
+		run: func(m *model, pkg Package) tea.Cmd {
+			if !m.setState(stateUpgrading) {
+				return nil
+			}
+			m.upgrade = upgradeView{path: pkg.Path, loading: true}
+			return previewUpgradeCmd(pkg.Path)
+		},
+		noAudit: true,
+	},
+	"replace": {
+		name:        "replace",
+		description: "Point the module at a local directory or a fork with a replace directive",
+		run: func(m *model, pkg Package) tea.Cmd {
+			if m.setState(stateReplacing) {
+				m.replace = newReplacePrompt(pkg.Path)
+			}
+			return nil
+		},
+		noAudit: true,
+	},
+	"install": {
+		name:        "install",
+		description: "Run go install path@latest",
+		run: func(m *model, pkg Package) tea.Cmd {
+			target := pkg.Path + "@latest"
+			return tea.Sequence(goInstallCmd(target), m.quit(fmt.Sprintf("Installed '%s'.", target)))
+		},
+	},
+	"open": {
+		name:        "open",
+		description: "Open the package documentation in a browser",
+		run: func(m *model, pkg Package) tea.Cmd {
+			url := "https://pkg.go.dev/" + pkg.Path
+			return tea.Sequence(openBrowserCmd(url), m.quit(fmt.Sprintf("Opened %s", url)))
+		},
+	},
+	"clone": {
+		name:        "clone",
+		description: "Clone the source repository into the current directory",
+		run: func(m *model, pkg Package) tea.Cmd {
+			return tea.Sequence(cloneRepoCmd(pkg.Path), m.quit(fmt.Sprintf("Cloned the repository of '%s'.", pkg.Path)))
+		},
+	},
+	"versions": {
+		name:        "versions",
+		description: "Pick a specific version to copy",
+		run: func(m *model, pkg Package) tea.Cmd {
+			if !m.setState(statePicking) {
+				return nil
+			}
+			m.picker = versionPicker{path: pkg.Path, loading: true, keys: m.keys}
+			return fetchVersionsCmd(pkg.Path)
+		},
+		noAudit: true,
+	},
+	"details": {
+		name:        "details",
+		description: "Show the module's details",
+		run: func(m *model, pkg Package) tea.Cmd {
+			return m.openDetail(pkg.Path)
+		},
+		noAudit: true,
+	},
+	"files": {
+		name:        "files",
+		description: "Browse and read the module's files",
+		run: func(m *model, pkg Package) tea.Cmd {
+			matcher, err := search.Lookup(m.engine.Matcher())
+			if err != nil || !m.setState(stateFiles) {
+				return nil
+			}
+			m.files = newFileBrowser(pkg.Path, m.packageVersion(pkg), matcher, m.list.pageSize)
+			return listModuleFilesCmd(pkg.Path, m.packageVersion(pkg))
+		},
+		noAudit: true,
+	},
+	"grep": {
+		name:        "grep",
+		description: "Search the module's files for a pattern",
+		run: func(m *model, pkg Package) tea.Cmd {
+			if m.setState(stateGrepping) {
+				m.grep = newGrepView(pkg.Path, m.packageVersion(pkg), m.list.pageSize, m.width)
+			}
+			return nil
+		},
+		noAudit: true,
+	},
+	"licenses": {
+		name:        "licenses",
+		description: "Report the licenses of the module and its dependencies",
+		run: func(m *model, pkg Package) tea.Cmd {
+			if !m.setState(stateLicenses) {
+				return nil
+			}
+			version := m.packageVersion(pkg)
+			m.licenses = licenseView{path: pkg.Path, version: version, loading: true, height: m.list.pageSize}
+			return buildLicenseReportCmd(pkg.Path, version)
+		},
+		noAudit: true,
+	},
+	"note": {
+		name:        "note",
+		description: "Write a note about the package",
+		run: func(m *model, pkg Package) tea.Cmd {
+			return m.editAnnotation(pkg, "note")
+		},
+		noAudit: true,
+	},
+	"tag": {
+		name:        "tag",
+		description: "Edit the tags of the package",
+		run: func(m *model, pkg Package) tea.Cmd {
+			return m.editAnnotation(pkg, "tags")
+		},
+		noAudit: true,
+	},
+	"favorite": {
+		name:        "favorite",
+		description: "Star the module, or unstar it",
+		run: func(m *model, pkg Package) tea.Cmd {
+			return m.toggleFavorite(pkg)
+		},
+		noAudit: true,
+	},
+	"watch": {
+		name:        "watch",
+		description: "Watch the module for new versions, or stop watching it",
+		run: func(m *model, pkg Package) tea.Cmd {
+			return m.toggleWatch(pkg)
+		},
+		noAudit: true,
+	},
+	"hook": {
+		name:        "hook",
+		description: "Run the configured hook command",
+		run: func(m *model, pkg Package) tea.Cmd {
+			if !m.setState(stateQuitting) {
+				return nil
+			}
+			m.finalMessage = fmt.Sprintf("Hook finished for '%s'.", pkg.Path)
+			return runHookCmd(m.config.Hook, pkg.Path, m.packageVersion(pkg))
+		},
+	},
+}
+
+// menuActions is the order in which actions are listed in the actions menu.
+var menuActions = []string{"copy", "copy-pinned", "copy-get", "copy-require", "copy-install", "get", "vendor", "upgrade", "replace", "install", "open", "clone", "versions", "details", "files", "grep", "licenses", "note", "tag", "favorite", "watch", "hook"}
+
+// enterCycle is the order in which the cycle key steps through Enter
+// actions.
+var enterCycle = []string{"copy", "copy-pinned", "copy-get", "copy-require", "copy-install"}
+
+// cycleEnterAction makes Enter run the next action of enterCycle, starting
+// from the first if the configured one is not in it.
+func (m *model) cycleEnterAction() tea.Cmd {
+	next := enterCycle[0]
+	if i := slices.Index(enterCycle, m.config.EnterAction); i >= 0 {
+		next = enterCycle[(i+1)%len(enterCycle)]
+	}
+	m.config.EnterAction = next
+	return m.flashMessage("Enter: "+strings.ToLower(actions[next].description[:1])+actions[next].description[1:]+".", false)
+}
+
+// editAnnotation opens the editor for the note or tags of pkg.
+func (m *model) editAnnotation(pkg Package, field string) tea.Cmd {
+	if m.setState(stateAnnotating) {
+		m.editor = newAnnotationEditor(pkg.Path, field, m.annotations.get(pkg.Path))
+	}
+	return nil
+}
+
+// copyAndQuit copies text and quits, or in stay-open mode goes back to the
+// results and flashes the outcome instead.
+func copyAndQuit(m *model, text string) tea.Cmd {
+	if m.stayOpen {
+		m.setState(stateBrowsing)
+		return copyCmd(m.config.Clipboard, text, false)
+	}
+	// The copy quits once it is done, see finishCopy.
+	m.quit(fmt.Sprintf("Copying '%s' to clipboard...", text))
+	return copyCmd(m.config.Clipboard, text, true)
+}
+
+type copiedMsg struct {
+	text string
+	err  error
+	// quit is set when gosearch quits once the outcome has been shown.
+	quit bool
+}
+
+// copyCmd copies text with the named clipboard backend and reports how it
+// went, for gosearch to quit with if quit is set.
+func copyCmd(backend, text string, quit bool) tea.Cmd {
+	return func() tea.Msg {
+		return copiedMsg{text: text, err: copyToClipboard(backend, text), quit: quit}
+	}
+}
+
+// copyToastDuration is how long the outcome of a copy shows before
+// gosearch quits.
+const copyToastDuration = 600 * time.Millisecond
+
+// finishCopy shows how the copy went for a moment, then quits with it.
+func (m *model) finishCopy(msg copiedMsg) tea.Cmd {
+	pause := tea.Tick(copyToastDuration, func(time.Time) tea.Msg { return nil })
+	if msg.err != nil {
+		return tea.Sequence(pause, m.fail(msg.err))
+	}
+	m.finalMessage = fmt.Sprintf("'%s' copied to clipboard!", msg.text)
+	return tea.Sequence(pause, tea.Quit)
+}
+
+// goGetCmd runs go get with args, such as a target and flags before it.
+func goGetCmd(args ...string) tea.Cmd {
+	return func() tea.Msg {
+		if err := backupModFiles("", "go get "+strings.Join(args, " ")); err != nil {
+			return errMsg(err)
+		}
+		out, err := runMutating("", "go", append([]string{"get"}, args...)...)
+		if err != nil {
+			return errMsg(&ActionError{Action: "get", Command: "go get " + strings.Join(args, " "), Output: strings.TrimSpace(string(out)), Err: err})
+		}
+		return nil
+	}
+}
+
+type goGetDoneMsg struct {
+	target string
+	output string
+	err    error
+}
+
+// goGetReportCmd runs go get target in the working directory and reports
+// how it went, output included, for the get key.
+func goGetReportCmd(target string) tea.Cmd {
+	return func() tea.Msg {
+		if err := backupModFiles("", "go get "+target); err != nil {
+			return goGetDoneMsg{target: target, err: err}
+		}
+		out, err := runMutating("", "go", "get", target)
+		return goGetDoneMsg{target: target, output: strings.TrimSpace(string(out)), err: err}
+	}
+}
+
+// getIntoProject runs go get on pkg in the module of the working directory,
+// quitting with the outcome once it is done, or flashing it in stay-open
+// mode.
+func (m *model) getIntoProject(pkg Package) tea.Cmd {
+	if isStdPath(pkg.Path) {
+		return m.flashMessage(fmt.Sprintf("'%s' is in the standard library; import it without go get.", pkg.Path), true)
+	}
+	cwd, _ := os.Getwd()
+	module := enclosingModule(cwd)
+	if module == "" {
+		return m.flashMessage("Not inside a Go module; run gosearch from one to go get into it.", true)
+	}
+	version := m.packageVersion(pkg)
+	target := pkg.Path + "@" + version
+	details := []string{"Runs go get " + target + ".", "Module: " + module}
+	return m.confirm("get", fmt.Sprintf("Add %s to %s?", target, module), details, func(m *model) tea.Cmd {
+		m.events.emit(event{Type: "action", Action: "get", Path: pkg.Path, Version: version})
+		gated := policyGateCmd(m.policy, pkg.Path, version, goGetReportCmd(target))
+		return tea.Batch(m.flashMessage(fmt.Sprintf("Running go get %s in %s...", target, module), false), m.audited("get", pkg, version, gated))
+	})
+}
+
+// finishGet reports the outcome of the get key.
+func (m *model) finishGet(msg goGetDoneMsg) tea.Cmd {
+	if msg.err != nil {
+		return m.fail(&ActionError{Action: "get", Command: "go get " + msg.target, Output: msg.output, Err: msg.err})
+	}
+	cwd, _ := os.Getwd()
+	done := fmt.Sprintf("Added '%s' to %s.", msg.target, enclosingModule(cwd))
+	if msg.output != "" {
+		done += "\n" + msg.output
+	}
+	if m.stayOpen {
+		if dryRun != nil {
+			done = "Dry run: would run go get " + msg.target
+		}
+		return m.flashMessage(done, false)
+	}
+	return m.quit(done)
+}
+
+func goInstallCmd(target string) tea.Cmd {
+	return func() tea.Msg {
+		out, err := runMutating("", "go", "install", target)
+		if err != nil {
+			return errMsg(&ActionError{Action: "install", Command: "go install " + target, Output: strings.TrimSpace(string(out)), Err: err})
+		}
+		return nil
+	}
+}
+
+// cloneRepoCmd resolves the repository behind a module path and clones it
+// into the current directory.
+func cloneRepoCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		repo, err := repoURL(path)
+		if err != nil {
+			return errMsg(err)
+		}
+
+		out, err := runMutating("", "git", "clone", "--quiet", repo)
+		if err != nil {
+			return errMsg(&ActionError{Action: "clone", Command: "git clone " + repo, Output: strings.TrimSpace(string(out)), Err: err})
+		}
+		return nil
+	}
+}
+
+func openBrowserCmd(url string) tea.Cmd {
+	return func() tea.Msg {
+		var cmd *exec.Cmd
+		switch runtime.GOOS {
+		case "darwin":
+			cmd = exec.Command("open", url)
+		case "linux":
+			cmd = exec.Command("xdg-open", url)
+		case "windows":
+			cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+		default:
+			return errMsg(fmt.Errorf("unsupported operating system for opening a browser: %s", runtime.GOOS))
+		}
+
+		if err := cmd.Start(); err != nil {
+			return errMsg(&ActionError{Action: "open", Command: strings.Join(cmd.Args, " "), Err: err})
+		}
+		return nil
+	}
+}
+
+// runHookCmd hands the terminal to the user's hook command and quits once it
+// exits.
+func runHookCmd(hook, path, version string) tea.Cmd {
+	command := strings.NewReplacer("{path}", path, "{version}", version).Replace(hook)
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/c", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), "GOSEARCH_PATH="+path, "GOSEARCH_VERSION="+version)
+	if dryRun != nil {
+		dryRun.add("", append([]string{"GOSEARCH_PATH=" + path, "GOSEARCH_VERSION=" + version}, cmd.Args...)...)
+		return tea.Quit
+	}
+
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if err != nil {
+			return errMsg(&ActionError{Action: "hook", Command: fmt.Sprintf("hook '%s'", command), Err: err})
+		}
+		return tea.QuitMsg{}
+	})
+}
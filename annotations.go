@@ -0,0 +1,257 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbletea"
+	"gopkg.in/yaml.v3"
+
+	"gosearch/index"
+)
+
+// annotation is what the user wrote down about a package.
+type annotation struct {
+	Note string   `yaml:"note,omitempty"`
+	Tags []string `yaml:"tags,omitempty"`
+}
+
+// annotationStore holds the notes and tags of every package, keyed by module
+// path, and keeps them in a YAML file next to the config. It is shared with
+// search filters, which may run in the background.
+type annotationStore struct {
+	path string
+
+	mu      sync.RWMutex
+	entries map[string]annotation
+	// shared holds the annotations of the team backend, if one is set up.
+	shared map[string]annotation
+	// saveMu keeps saves in the order they were made.
+	saveMu sync.Mutex
+}
+
+func annotationsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "gosearch", "annotations.yaml"), nil
+}
+
+// loadAnnotations reads the annotations file at path. A missing file is an
+// empty store.
+func loadAnnotations(path string) (*annotationStore, error) {
+	s := &annotationStore{path: path, entries: make(map[string]annotation)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read annotations: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &s.entries); err != nil {
+		return nil, &ParseError{What: "annotations file", Path: path, Err: err}
+	}
+	return s, nil
+}
+
+func (s *annotationStore) get(path string) annotation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.entries[path]
+}
+
+func (s *annotationStore) getShared(path string) annotation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.shared[path]
+}
+
+// setShared replaces the team's annotations.
+func (s *annotationStore) setShared(entries map[string]annotation) {
+	s.mu.Lock()
+	s.shared = entries
+	s.mu.Unlock()
+}
+
+// set replaces the annotation of path, dropping it once it is empty.
+func (s *annotationStore) set(path string, a annotation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a.Note == "" && len(a.Tags) == 0 {
+		delete(s.entries, path)
+		return
+	}
+	s.entries[path] = a
+}
+
+// hasTag reports whether path is tagged with tag by the user or the team,
+// ignoring case.
+func (s *annotationStore) hasTag(path, tag string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	match := func(t string) bool { return strings.EqualFold(t, tag) }
+	return slices.ContainsFunc(s.entries[path].Tags, match) || slices.ContainsFunc(s.shared[path].Tags, match)
+}
+
+// tagFilter implements the tag: query filter.
+func (s *annotationStore) tagFilter(p index.Package, value string) bool {
+	return s.hasTag(p.Path, value)
+}
+
+// save writes the store to its file, replacing it atomically.
+func (s *annotationStore) save() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	s.mu.RLock()
+	data, err := yaml.Marshal(s.entries)
+	s.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode annotations: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	f, err := os.CreateTemp(dir, ".annotations-*")
+	if err != nil {
+		return fmt.Errorf("failed to save annotations: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to save annotations: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to save annotations: %w", err)
+	}
+	return os.Rename(f.Name(), s.path)
+}
+
+func saveAnnotationsCmd(s *annotationStore) tea.Cmd {
+	return func() tea.Msg {
+		if err := s.save(); err != nil {
+			return errMsg(err)
+		}
+		return nil
+	}
+}
+
+// parseTags splits a comma-separated list of tags, dropping blanks and
+// repeats.
+func parseTags(text string) []string {
+	var tags []string
+	for _, tag := range strings.Split(text, ",") {
+		tag = strings.Join(strings.Fields(tag), "-")
+		if tag != "" && !slices.ContainsFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// annotationEditor is the prompt for editing the note or the tags of a
+// package.
+type annotationEditor struct {
+	path string
+	// field is "note" or "tags".
+	field string
+	value string
+}
+
+// annotationEditedMsg is sent when an edit is confirmed.
+type annotationEditedMsg struct {
+	path  string
+	field string
+	value string
+}
+
+func newAnnotationEditor(path, field string, a annotation) annotationEditor {
+	e := annotationEditor{path: path, field: field, value: a.Note}
+	if field == "tags" {
+		e.value = strings.Join(a.Tags, ", ")
+	}
+	return e
+}
+
+func (e annotationEditor) Update(msg tea.Msg) (annotationEditor, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return e, nil
+	}
+
+	switch key.Type {
+	case tea.KeyEsc:
+		return e, closeOverlay
+	case tea.KeyEnter:
+		edited := annotationEditedMsg{path: e.path, field: e.field, value: strings.TrimSpace(e.value)}
+		return e, func() tea.Msg { return edited }
+	case tea.KeyBackspace:
+		if r := []rune(e.value); len(r) > 0 {
+			e.value = string(r[:len(r)-1])
+		}
+	case tea.KeySpace:
+		e.value += " "
+	case tea.KeyRunes:
+		e.value += string(key.Runes)
+	}
+	return e, nil
+}
+
+func (e annotationEditor) View() string {
+	s := strings.Builder{}
+	title, hint := "Note on", "Enter to save, an empty note removes it, Esc to cancel."
+	if e.field == "tags" {
+		title, hint = "Tags of", "Separate tags with commas. Enter to save, Esc to cancel."
+	}
+	s.WriteString(fmt.Sprintf("%s %s\n\n", title, inputStyle.Render(e.path)))
+	s.WriteString(fmt.Sprintf("%s%s\n\n", e.value, inputStyle.Render("|")))
+	s.WriteString(statusMessageStyle.Render(hint))
+	return s.String()
+}
+
+// applyAnnotationEdit stores an edit made in the editor and saves the store.
+func (m *model) applyAnnotationEdit(msg annotationEditedMsg) tea.Cmd {
+	a := m.annotations.get(msg.path)
+	if msg.field == "tags" {
+		a.Tags = parseTags(msg.value)
+	} else {
+		a.Note = msg.value
+	}
+	m.annotations.set(msg.path, a)
+	m.filterPackages()
+	return saveAnnotationsCmd(m.annotations)
+}
+
+// annotationLine describes the note and tags of the selected package.
+func (m model) annotationLine() string {
+	pkg, ok := m.selectedPackage()
+	if !ok {
+		return ""
+	}
+	a := m.annotations.get(pkg.Path)
+	var parts []string
+	if len(a.Tags) > 0 {
+		parts = append(parts, "Tags: "+strings.Join(a.Tags, ", "))
+	}
+	if a.Note != "" {
+		parts = append(parts, "Note: "+a.Note)
+	}
+	if shared := m.annotations.getShared(pkg.Path); len(shared.Tags) > 0 || shared.Note != "" {
+		team := "Team:"
+		if len(shared.Tags) > 0 {
+			team += " " + strings.Join(shared.Tags, ", ")
+		}
+		if shared.Note != "" {
+			team += " – " + shared.Note
+		}
+		parts = append(parts, team)
+	}
+	return strings.Join(parts, " · ")
+}
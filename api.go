@@ -0,0 +1,235 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"path"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// maxAPILines caps the declarations listed in the detail view.
+const maxAPILines = 12
+
+// apiSummary is the exported API of a package of a module, condensed.
+type apiSummary struct {
+	// dir is the package directory relative to the module root, and name
+	// its package name.
+	dir, name string
+	// types and funcs describe each exported type and function, sorted by
+	// name; methods counts the exported methods of each type.
+	types   []apiDecl
+	funcs   []apiDecl
+	methods map[string]int
+	consts  int
+	vars    int
+}
+
+type apiDecl struct {
+	name string
+	// text is how the declaration is listed, e.g. "type Command struct".
+	text string
+}
+
+// apiPackage picks the package whose API sums up the module: the one at
+// the module root, or else the least nested one, first by name. Commands
+// and internal packages only count if there is nothing else.
+func apiPackage(files []goFile) string {
+	type rank struct {
+		hidden bool
+		depth  int
+		dir    string
+	}
+	var best *rank
+	for _, f := range files {
+		hidden := f.file.Name.Name == "main" || slices.Contains(strings.Split(f.dir, "/"), "internal")
+		r := rank{hidden, depth(f.dir), f.dir}
+		if best == nil || r.hidden != best.hidden && !r.hidden ||
+			r.hidden == best.hidden && (r.depth < best.depth || r.depth == best.depth && r.dir < best.dir) {
+			best = &r
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.dir
+}
+
+func depth(dir string) int {
+	if dir == "." {
+		return 0
+	}
+	return strings.Count(dir, "/") + 1
+}
+
+// packageAPI parses the files of the package in dir, of the module whose
+// files in z are named under prefix, that build for this machine's platform
+// and lists what they export.
+func packageAPI(z *zip.Reader, prefix string, files []goFile, dir string) *apiSummary {
+	api := &apiSummary{dir: dir, methods: make(map[string]int)}
+	fset := token.NewFileSet()
+	seen := make(map[string]bool)
+	for _, f := range files {
+		if f.dir != dir || !buildsFor(f, runtime.GOOS, runtime.GOARCH) {
+			continue
+		}
+		parsed, err := parseZipFile(z, fset, prefix+path.Join(f.dir, f.name))
+		if err != nil {
+			continue
+		}
+		api.name = parsed.Name.Name
+		for _, decl := range parsed.Decls {
+			api.add(fset, decl, seen)
+		}
+	}
+	if api.name == "" {
+		return nil
+	}
+	byName := func(a, b apiDecl) int { return strings.Compare(a.name, b.name) }
+	slices.SortFunc(api.types, byName)
+	slices.SortFunc(api.funcs, byName)
+	return api
+}
+
+// add records the exported names declared by decl, skipping those already
+// seen in another file.
+func (api *apiSummary) add(fset *token.FileSet, decl ast.Decl, seen map[string]bool) {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if !d.Name.IsExported() {
+			return
+		}
+		if d.Recv != nil {
+			if recv := receiverName(d.Recv); ast.IsExported(recv) && !seen[recv+"."+d.Name.Name] {
+				seen[recv+"."+d.Name.Name] = true
+				api.methods[recv]++
+			}
+			return
+		}
+		if seen[d.Name.Name] {
+			return
+		}
+		seen[d.Name.Name] = true
+		sig := strings.TrimPrefix(nodeString(fset, d.Type), "func")
+		api.funcs = append(api.funcs, apiDecl{name: d.Name.Name, text: "func " + d.Name.Name + sig})
+
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				if !s.Name.IsExported() || seen[s.Name.Name] {
+					continue
+				}
+				seen[s.Name.Name] = true
+				api.types = append(api.types, apiDecl{name: s.Name.Name, text: "type " + s.Name.Name + typeKind(fset, s)})
+			case *ast.ValueSpec:
+				for _, name := range s.Names {
+					if !name.IsExported() || seen[name.Name] {
+						continue
+					}
+					seen[name.Name] = true
+					if d.Tok == token.CONST {
+						api.consts++
+					} else {
+						api.vars++
+					}
+				}
+			}
+		}
+	}
+}
+
+// receiverName returns the type name of a method receiver, without pointer
+// or type parameters.
+func receiverName(recv *ast.FieldList) string {
+	if len(recv.List) == 0 {
+		return ""
+	}
+	expr := recv.List[0].Type
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// typeKind describes a type declaration briefly: its kind for structs,
+// interfaces, and function types, or else its definition if short.
+func typeKind(fset *token.FileSet, s *ast.TypeSpec) string {
+	eq := " "
+	if s.Assign.IsValid() {
+		eq = " = "
+	}
+	switch s.Type.(type) {
+	case *ast.StructType:
+		return eq + "struct"
+	case *ast.InterfaceType:
+		return eq + "interface"
+	case *ast.FuncType:
+		return eq + "func"
+	}
+	if def := nodeString(fset, s.Type); len(def) <= 30 {
+		return eq + def
+	}
+	return ""
+}
+
+func nodeString(fset *token.FileSet, node ast.Node) string {
+	var b bytes.Buffer
+	if err := printer.Fprint(&b, fset, node); err != nil {
+		return ""
+	}
+	return b.String()
+}
+
+// apiLines lists the summary for the detail view: a count of what the
+// package exports, then its types and functions up to maxAPILines.
+func (api *apiSummary) apiLines() []string {
+	pkg := "package " + api.name
+	if api.dir != "." {
+		pkg += " (" + displayDir(api.dir) + ")"
+	}
+	if api.name == "main" {
+		return []string{pkg + ", a command"}
+	}
+	lines := []string{fmt.Sprintf("%s: %d %s, %d %s, %d %s, %d %s", pkg,
+		len(api.types), plural(len(api.types), "type", "types"),
+		len(api.funcs), plural(len(api.funcs), "func", "funcs"),
+		api.consts, plural(api.consts, "const", "consts"),
+		api.vars, plural(api.vars, "var", "vars"))}
+
+	var decls []string
+	for _, t := range api.types {
+		text := t.text
+		if n := api.methods[t.name]; n > 0 {
+			text += fmt.Sprintf(" (%d %s)", n, plural(n, "method", "methods"))
+		}
+		decls = append(decls, text)
+	}
+	for _, f := range api.funcs {
+		decls = append(decls, f.text)
+	}
+	for i, d := range decls {
+		if i == maxAPILines {
+			lines = append(lines, fmt.Sprintf("… and %d more", len(decls)-i))
+			break
+		}
+		d = clipWidth(d, 100)
+		lines = append(lines, d)
+	}
+	return lines
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// apiObject is an exported identifier of a package as compared across
+// versions: a function, method, type, field, interface method, constant or
+// variable, with text describing it up to what cannot break importers, such
+// as parameter names.
+type apiObject struct {
+	kind string
+	text string
+}
+
+// apiChange is a difference between two versions of a package's API that
+// may break code using it.
+type apiChange struct {
+	pkg, name string
+	message   string
+}
+
+func (c apiChange) String() string {
+	if c.name == "" {
+		return c.pkg + ": " + c.message
+	}
+	return c.pkg + "." + c.name + ": " + c.message
+}
+
+// moduleAPI reads the exported API of every importable package of the zip
+// of modPath@version, by import path, as built for this machine's platform.
+func moduleAPI(modPath, version string) (map[string]map[string]apiObject, error) {
+	z, err := openModuleZip(modPath, version)
+	if err != nil {
+		return nil, err
+	}
+	defer z.Close()
+	prefix := modPath + "@" + version + "/"
+
+	api := make(map[string]map[string]apiObject)
+	fset := token.NewFileSet()
+	for _, f := range moduleGoFiles(&z.Reader, prefix) {
+		if f.file.Name.Name == "main" || slices.Contains(strings.Split(f.dir, "/"), "internal") ||
+			!buildsFor(f, runtime.GOOS, runtime.GOARCH) {
+			continue
+		}
+		parsed, err := parseZipFile(&z.Reader, fset, prefix+path.Join(f.dir, f.name))
+		if err != nil {
+			continue
+		}
+		pkg := modPath
+		if f.dir != "." {
+			pkg += "/" + f.dir
+		}
+		if api[pkg] == nil {
+			api[pkg] = make(map[string]apiObject)
+		}
+		collectAPI(fset, parsed, api[pkg])
+	}
+	return api, nil
+}
+
+func parseZipFile(z *zip.Reader, fset *token.FileSet, name string) (*ast.File, error) {
+	f, err := z.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parser.ParseFile(fset, name, f, parser.SkipObjectResolution)
+}
+
+// collectAPI adds the exported identifiers declared in f to objects. Methods,
+// fields, and interface methods are named "Type.Name".
+func collectAPI(fset *token.FileSet, f *ast.File, objects map[string]apiObject) {
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			name := d.Name.Name
+			kind := "func"
+			if d.Recv != nil {
+				recv := receiverName(d.Recv)
+				if !ast.IsExported(recv) {
+					continue
+				}
+				name, kind = recv+"."+name, "method"
+			}
+			objects[name] = apiObject{kind: kind, text: signature(fset, d.Type)}
+
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.IsExported() {
+						collectType(fset, s, objects)
+					}
+				case *ast.ValueSpec:
+					kind := "var"
+					if d.Tok == token.CONST {
+						kind = "const"
+					}
+					text := ""
+					if s.Type != nil {
+						text = typeString(fset, s.Type)
+					}
+					for _, name := range s.Names {
+						if name.IsExported() {
+							objects[name.Name] = apiObject{kind: kind, text: text}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// collectType adds a type and, for structs and interfaces, its exported
+// fields and methods.
+func collectType(fset *token.FileSet, s *ast.TypeSpec, objects map[string]apiObject) {
+	name := s.Name.Name
+	params := ""
+	if s.TypeParams != nil {
+		params = fieldTypes(fset, s.TypeParams)
+	}
+	switch t := s.Type.(type) {
+	case *ast.StructType:
+		objects[name] = apiObject{kind: "type", text: "struct" + params}
+		for _, field := range t.Fields.List {
+			for _, fieldName := range fieldNames(field) {
+				if ast.IsExported(fieldName) {
+					objects[name+"."+fieldName] = apiObject{kind: "field", text: typeString(fset, field.Type)}
+				}
+			}
+		}
+	case *ast.InterfaceType:
+		objects[name] = apiObject{kind: "type", text: "interface" + params}
+		for _, method := range t.Methods.List {
+			if ft, ok := method.Type.(*ast.FuncType); ok && len(method.Names) > 0 {
+				if method.Names[0].IsExported() {
+					objects[name+"."+method.Names[0].Name] = apiObject{kind: "interface method", text: signature(fset, ft)}
+				}
+			} else {
+				// Embedded interfaces and type constraints change the
+				// method set, so track them as a whole.
+				embedded := objects[name]
+				embedded.text += " " + typeString(fset, method.Type)
+				objects[name] = embedded
+			}
+		}
+	default:
+		eq := ""
+		if s.Assign.IsValid() {
+			eq = "= "
+		}
+		objects[name] = apiObject{kind: "type", text: eq + typeString(fset, s.Type) + params}
+	}
+}
+
+// fieldNames returns the names of a struct field, or the type name of an
+// embedded one.
+func fieldNames(field *ast.Field) []string {
+	if len(field.Names) > 0 {
+		names := make([]string, len(field.Names))
+		for i, n := range field.Names {
+			names[i] = n.Name
+		}
+		return names
+	}
+	expr := field.Type
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			return []string{e.Sel.Name}
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return []string{e.Name}
+		default:
+			return nil
+		}
+	}
+}
+
+// signature writes a function type without its parameter names.
+func signature(fset *token.FileSet, ft *ast.FuncType) string {
+	s := "func"
+	if ft.TypeParams != nil {
+		s += "[" + strings.Trim(fieldTypes(fset, ft.TypeParams), "()") + "]"
+	}
+	s += fieldTypes(fset, ft.Params)
+	if ft.Results != nil {
+		s += " " + fieldTypes(fset, ft.Results)
+	}
+	return s
+}
+
+// fieldTypes writes the types of a field list, one per name, in parentheses.
+func fieldTypes(fset *token.FileSet, fields *ast.FieldList) string {
+	var types []string
+	for _, field := range fields.List {
+		t := typeString(fset, field.Type)
+		for range max(len(field.Names), 1) {
+			types = append(types, t)
+		}
+	}
+	return "(" + strings.Join(types, ", ") + ")"
+}
+
+// typeString writes a type expression, with the parameter names of any
+// function types in it left out.
+func typeString(fset *token.FileSet, expr ast.Expr) string {
+	if ft, ok := expr.(*ast.FuncType); ok {
+		return signature(fset, ft)
+	}
+	return nodeString(fset, expr)
+}
+
+// breakingChanges lists what from old is missing or different in new, and
+// methods added to interfaces, which break their implementations outside
+// the package.
+func breakingChanges(old, new map[string]map[string]apiObject) []apiChange {
+	var changes []apiChange
+	for pkg, objects := range old {
+		newObjects, ok := new[pkg]
+		if !ok {
+			changes = append(changes, apiChange{pkg: pkg, message: "package removed"})
+			continue
+		}
+		for name, obj := range objects {
+			newObj, ok := newObjects[name]
+			switch {
+			case !ok:
+				changes = append(changes, apiChange{pkg: pkg, name: name, message: obj.kind + " removed"})
+			case newObj.kind != obj.kind:
+				changes = append(changes, apiChange{pkg: pkg, name: name, message: fmt.Sprintf("changed from %s to %s", obj.kind, newObj.kind)})
+			case newObj.text != obj.text && !(obj.kind == "const" && obj.text == ""):
+				changes = append(changes, apiChange{pkg: pkg, name: name, message: fmt.Sprintf("changed from %s to %s", describe(obj), describe(newObj))})
+			}
+		}
+		for name, newObj := range newObjects {
+			if _, ok := objects[name]; !ok && newObj.kind == "interface method" {
+				changes = append(changes, apiChange{pkg: pkg, name: name, message: "method added to interface"})
+			}
+		}
+	}
+	slices.SortFunc(changes, func(a, b apiChange) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	return changes
+}
+
+func describe(obj apiObject) string {
+	if obj.text == "" {
+		return obj.kind
+	}
+	return obj.text
+}
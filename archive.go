@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+	"unsafe"
+
+	"github.com/charmbracelet/bubbletea"
+
+	"gosearch/index"
+	"gosearch/search"
+)
+
+// archive holds the entries evicted from memory by the memory budget: the
+// ones published before before. They are read back from path, which is the
+// --index-file itself or, for the network feed, a spill file written at load.
+type archive struct {
+	path    string
+	before  time.Time
+	count   int
+	spilled bool
+}
+
+// archiveChunk is how many entries are decoded at a time when searching the
+// archive, which bounds the memory a search needs.
+const archiveChunk = 50000
+
+// estimateMemory approximates the heap held by packages once loaded into the
+// engine: the structs, their strings, and the engine's copy of each path.
+func estimateMemory(packages []Package) int64 {
+	var size int64
+	for _, p := range packages {
+		size += int64(unsafe.Sizeof(p)) + int64(len(p.Path)+len(p.Version)) + int64(unsafe.Sizeof(p.Path))
+	}
+	return size
+}
+
+// applyMemoryBudget keeps only the entries of the last cfg.KeepMonths months
+// when packages exceed cfg.MemoryBudget. The rest are left in the dump at
+// dumpPath, or spilled to the cache directory if there is no dump.
+func applyMemoryBudget(packages []Package, cfg Config, dumpPath string) ([]Package, *archive, error) {
+	if cfg.MemoryBudget <= 0 || estimateMemory(packages) <= int64(cfg.MemoryBudget) {
+		return packages, nil, nil
+	}
+
+	cutoff := time.Now().AddDate(0, -cfg.KeepMonths, 0)
+	var recent, old []Package
+	for _, p := range packages {
+		if p.Timestamp.Before(cutoff) {
+			if dumpPath == "" {
+				old = append(old, p)
+			}
+		} else {
+			recent = append(recent, p)
+		}
+	}
+	a := &archive{path: dumpPath, before: cutoff, count: len(packages) - len(recent)}
+	if a.count == 0 {
+		return packages, nil, nil
+	}
+
+	if dumpPath == "" {
+		path, err := writeSpill(old)
+		if err != nil {
+			return packages, nil, err
+		}
+		a.path, a.spilled = path, true
+	}
+	return recent, a, nil
+}
+
+func writeSpill(packages []Package) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(dir, "evicted-*.jsonl")
+	if err != nil {
+		return "", fmt.Errorf("failed to create spill file: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, p := range packages {
+		if err := enc.Encode(p); err != nil {
+			os.Remove(f.Name())
+			return "", fmt.Errorf("failed to write spill file: %w", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write spill file: %w", err)
+	}
+	return f.Name(), nil
+}
+
+type archiveSearchedMsg struct {
+	query   string
+	matches []search.Match
+	err     error
+}
+
+// searchArchiveCmd runs query over the archived entries a chunk at a time.
+// The hits of every chunk are ranked together at the end.
+func searchArchiveCmd(engine *search.Engine, a archive, query string) tea.Cmd {
+	return func() tea.Msg {
+		f, err := os.Open(a.path)
+		if err != nil {
+			return archiveSearchedMsg{query: query, err: fmt.Errorf("failed to open archived entries: %w", err)}
+		}
+		defer f.Close()
+
+		var hits []Package
+		err = index.Scan(f, archiveChunk, func(chunk []index.Package) error {
+			old := chunk[:0]
+			for _, p := range chunk {
+				if p.Timestamp.Before(a.before) {
+					old = append(old, p)
+				}
+			}
+			matches, err := engine.SearchCorpus(query, old)
+			for _, match := range matches {
+				hits = append(hits, match.Package)
+			}
+			return err
+		})
+		if err != nil {
+			return archiveSearchedMsg{query: query, err: fmt.Errorf("failed to search archived entries: %w", err)}
+		}
+
+		matches, err := engine.SearchCorpus(query, hits)
+		return archiveSearchedMsg{query: query, matches: matches, err: err}
+	}
+}
+
+// startArchiveSearch searches the archived entries for the current query.
+func (m *model) startArchiveSearch() tea.Cmd {
+	if m.archive == nil || m.searchingArchive || m.input.query == "" || m.archiveQuery == m.input.query {
+		return nil
+	}
+	m.searchingArchive = true
+	return searchArchiveCmd(m.engine, *m.archive, m.input.query)
+}
+
+// mergeArchiveSearch shows the archived matches below the in-memory ones if
+// the query has not changed in the meantime.
+func (m *model) mergeArchiveSearch(msg archiveSearchedMsg) {
+	m.searchingArchive = false
+	if msg.query != m.input.query {
+		return
+	}
+	if msg.err != nil {
+		m.queryErr = msg.err
+		return
+	}
+	m.archiveQuery = msg.query
+	m.archiveMatches = msg.matches
+	m.filterPackages()
+}
+
+// archiveLine describes the archive under the results, or is empty.
+func (m model) archiveLine() string {
+	switch {
+	case m.archive == nil:
+		return ""
+	case m.searchingArchive:
+		return fmt.Sprintf("Searching %d older entries on disk...", m.archive.count)
+	case m.archiveQuery != "" && m.archiveQuery == m.input.query:
+		return fmt.Sprintf("Including %d matches from %d older entries on disk.", len(m.archiveMatches), m.archive.count)
+	default:
+		return fmt.Sprintf("%d entries older than %s are on disk; press Ctrl+O to search them too.",
+			m.archive.count, m.archive.before.Format("2006-01-02"))
+	}
+}
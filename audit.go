@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+	"golang.org/x/mod/modfile"
+)
+
+// auditEntry records one action taken on a package.
+type auditEntry struct {
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"`
+	Path    string    `json:"path"`
+	Version string    `json:"version,omitempty"`
+	// Dir is the working directory and Module the module it is in, if any.
+	Dir    string `json:"dir"`
+	Module string `json:"module,omitempty"`
+}
+
+func auditLogPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "gosearch", "audit.jsonl"), nil
+}
+
+// newAuditEntry describes running action on path@version from the current
+// directory.
+func newAuditEntry(action, path, version string) auditEntry {
+	e := auditEntry{Time: time.Now(), Action: action, Path: path, Version: version}
+	e.Dir, _ = os.Getwd()
+	e.Module = enclosingModule(e.Dir)
+	return e
+}
+
+// enclosingModule returns the path of the module containing dir, if any.
+func enclosingModule(dir string) string {
+	root := findModuleRoot(dir)
+	if root == "" {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	return modfile.ModulePath(data)
+}
+
+// appendAuditEntry adds e to the audit log at path.
+func appendAuditEntry(path string, e auditEntry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return f.Close()
+}
+
+func auditCmd(path string, e auditEntry) tea.Cmd {
+	return func() tea.Msg {
+		if err := appendAuditEntry(path, e); err != nil {
+			return errMsg(err)
+		}
+		return nil
+	}
+}
+
+// audited runs cmd after counting the pick towards the frecency signal and
+// recording the action in the audit log, if it is enabled. Dry runs are not
+// recorded.
+func (m *model) audited(action string, pkg Package, version string, cmd tea.Cmd) tea.Cmd {
+	if dryRun != nil {
+		return cmd
+	}
+	usage := m.recordUsage(pkg.Path)
+	if !m.config.Audit {
+		return tea.Sequence(usage, cmd)
+	}
+	path, err := auditLogPath()
+	if err != nil {
+		return tea.Sequence(usage, func() tea.Msg { return errMsg(err) }, cmd)
+	}
+	return tea.Sequence(usage, auditCmd(path, newAuditEntry(action, pkg.Path, version)), cmd)
+}
+
+// runAction runs the named action on pkg, recording it in the audit log
+// unless it only changes what gosearch shows.
+func (m *model) runAction(name string, pkg Package) tea.Cmd {
+	a := actions[name]
+	details := []string{a.description + ".", "Module: " + pkg.Path + "@" + m.packageVersion(pkg)}
+	return m.confirm(name, fmt.Sprintf("Run %s on %s?", name, pkg.Path), details, func(m *model) tea.Cmd {
+		m.events.emit(event{Type: "action", Action: name, Path: pkg.Path, Version: m.packageVersion(pkg)})
+		cmd := a.run(m, pkg)
+		if a.noAudit {
+			return cmd
+		}
+		return m.audited(name, pkg, m.packageVersion(pkg), cmd)
+	})
+}
+
+// readAuditLog returns the entries of the audit log at path recorded at or
+// after since.
+func readAuditLog(path string, since time.Time) ([]auditEntry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []auditEntry
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var e auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to parse audit log line %d: %w", line, err)
+		}
+		if !e.Time.Before(since) {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// runAudit implements `gosearch audit`: it exports the audit log as JSONL or
+// CSV.
+func runAudit(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	format := fs.String("format", "jsonl", "output `format`: jsonl or csv")
+	sinceFlag := fs.String("since", "", "only export entries recorded at or after `time` (RFC 3339, YYYY-MM-DD, or an age such as 90d)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "jsonl" && *format != "csv" {
+		return fmt.Errorf("unknown --format '%s'; use jsonl or csv", *format)
+	}
+	since, err := parseTimeFlag(*sinceFlag, time.Now())
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+
+	path, err := auditLogPath()
+	if err != nil {
+		return err
+	}
+	entries, err := readAuditLog(path, since)
+	if err != nil {
+		return err
+	}
+
+	if *format == "jsonl" {
+		enc := json.NewEncoder(w)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"time", "action", "path", "version", "dir", "module"})
+	for _, e := range entries {
+		cw.Write([]string{e.Time.Format(time.RFC3339), e.Action, e.Path, e.Version, e.Dir, e.Module})
+	}
+	cw.Flush()
+	return cw.Error()
+}
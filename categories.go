@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+// category is a named group of modules listed by the category browser.
+type category struct {
+	name    string
+	modules []string
+}
+
+// curatedCategories seeds the category browser with well-known modules,
+// grouped the way curated lists such as awesome-go group them.
+var curatedCategories = []category{
+	{"Web frameworks", []string{
+		"github.com/gin-gonic/gin",
+		"github.com/labstack/echo/v4",
+		"github.com/gofiber/fiber/v2",
+		"github.com/go-chi/chi/v5",
+		"github.com/gorilla/mux",
+		"github.com/julienschmidt/httprouter",
+		"github.com/beego/beego/v2",
+	}},
+	{"Logging", []string{
+		"go.uber.org/zap",
+		"github.com/sirupsen/logrus",
+		"github.com/rs/zerolog",
+		"github.com/charmbracelet/log",
+		"github.com/apex/log",
+		"github.com/go-logr/logr",
+	}},
+	{"ORMs and SQL", []string{
+		"gorm.io/gorm",
+		"entgo.io/ent",
+		"github.com/jmoiron/sqlx",
+		"github.com/uptrace/bun",
+		"github.com/Masterminds/squirrel",
+		"github.com/sqlc-dev/sqlc",
+	}},
+	{"Database drivers", []string{
+		"github.com/jackc/pgx/v5",
+		"github.com/lib/pq",
+		"github.com/go-sql-driver/mysql",
+		"github.com/mattn/go-sqlite3",
+		"modernc.org/sqlite",
+		"go.mongodb.org/mongo-driver",
+		"github.com/redis/go-redis/v9",
+	}},
+	{"CLI", []string{
+		"github.com/spf13/cobra",
+		"github.com/urfave/cli/v2",
+		"github.com/alecthomas/kong",
+		"github.com/peterbourgon/ff/v3",
+		"github.com/spf13/pflag",
+	}},
+	{"Terminal UI", []string{
+		"github.com/charmbracelet/bubbletea",
+		"github.com/charmbracelet/lipgloss",
+		"github.com/charmbracelet/bubbles",
+		"github.com/rivo/tview",
+		"github.com/gdamore/tcell/v2",
+		"github.com/fatih/color",
+	}},
+	{"Configuration", []string{
+		"github.com/spf13/viper",
+		"github.com/kelseyhightower/envconfig",
+		"github.com/caarlos0/env/v11",
+		"github.com/knadh/koanf/v2",
+		"github.com/joho/godotenv",
+	}},
+	{"Testing", []string{
+		"github.com/stretchr/testify",
+		"github.com/onsi/ginkgo/v2",
+		"github.com/onsi/gomega",
+		"go.uber.org/mock",
+		"github.com/google/go-cmp",
+		"github.com/DATA-DOG/go-sqlmock",
+	}},
+	{"Serialization", []string{
+		"gopkg.in/yaml.v3",
+		"github.com/BurntSushi/toml",
+		"google.golang.org/protobuf",
+		"github.com/json-iterator/go",
+		"github.com/goccy/go-json",
+		"github.com/vmihailenco/msgpack/v5",
+	}},
+	{"RPC and messaging", []string{
+		"google.golang.org/grpc",
+		"github.com/twitchtv/twirp",
+		"connectrpc.com/connect",
+		"github.com/nats-io/nats.go",
+		"github.com/segmentio/kafka-go",
+		"github.com/rabbitmq/amqp091-go",
+	}},
+	{"Validation", []string{
+		"github.com/go-playground/validator/v10",
+		"github.com/go-ozzo/ozzo-validation/v4",
+		"github.com/asaskevich/govalidator",
+	}},
+	{"Concurrency", []string{
+		"golang.org/x/sync",
+		"github.com/sourcegraph/conc",
+		"github.com/panjf2000/ants/v2",
+		"github.com/alitto/pond",
+	}},
+}
+
+// loadCategories returns the curated categories with the modules listed
+// under categories in the config added to them. Categories only named in the
+// config come after the curated ones, alphabetically.
+func loadCategories(extra map[string][]string) []category {
+	categories := make([]category, len(curatedCategories))
+	for i, c := range curatedCategories {
+		categories[i] = category{name: c.name, modules: slices.Clone(c.modules)}
+	}
+
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		i := slices.IndexFunc(categories, func(c category) bool { return strings.EqualFold(c.name, name) })
+		if i < 0 {
+			categories = append(categories, category{name: name})
+			i = len(categories) - 1
+		}
+		for _, path := range extra[name] {
+			if !slices.Contains(categories[i].modules, path) {
+				categories[i].modules = append(categories[i].modules, path)
+			}
+		}
+	}
+	return categories
+}
+
+// categoryBrowser is the sub-screen for discovering modules by category. It
+// lists the categories and, once one is opened, its modules.
+type categoryBrowser struct {
+	categories []category
+	selected   int
+	// open is the index of the category whose modules are listed, or -1.
+	open   int
+	module int
+}
+
+// categoryChosenMsg is sent when a module is picked in the category browser.
+type categoryChosenMsg struct {
+	path string
+}
+
+func newCategoryBrowser(extra map[string][]string) categoryBrowser {
+	return categoryBrowser{categories: loadCategories(extra), open: -1}
+}
+
+func (b categoryBrowser) Update(msg tea.Msg) (categoryBrowser, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok || len(b.categories) == 0 {
+		return b, nil
+	}
+
+	if b.open < 0 {
+		switch key.String() {
+		case "esc", "ctrl+b":
+			return b, closeOverlay
+		case "up", "k":
+			b.selected = (b.selected - 1 + len(b.categories)) % len(b.categories)
+		case "down", "j":
+			b.selected = (b.selected + 1) % len(b.categories)
+		case "enter", "right", "l":
+			if len(b.categories[b.selected].modules) > 0 {
+				b.open, b.module = b.selected, 0
+			}
+		}
+		return b, nil
+	}
+
+	modules := b.categories[b.open].modules
+	switch key.String() {
+	case "esc", "left", "h":
+		b.open = -1
+	case "ctrl+b":
+		b.open = -1
+		return b, closeOverlay
+	case "up", "k":
+		b.module = (b.module - 1 + len(modules)) % len(modules)
+	case "down", "j":
+		b.module = (b.module + 1) % len(modules)
+	case "enter":
+		chosen := categoryChosenMsg{path: modules[b.module]}
+		return b, func() tea.Msg { return chosen }
+	}
+	return b, nil
+}
+
+func (b categoryBrowser) View(pageSize int) string {
+	s := strings.Builder{}
+	var lines []string
+	var selected int
+	var hint string
+	if b.open < 0 {
+		s.WriteString("Browse by category\n\n")
+		for _, c := range b.categories {
+			lines = append(lines, fmt.Sprintf("%s %d", padWidth(c.name, 20), len(c.modules)))
+		}
+		selected = b.selected
+		hint = "Use ↑↓ to navigate, Enter to list the modules, Esc to go back."
+	} else {
+		s.WriteString(fmt.Sprintf("Modules in %s\n\n", inputStyle.Render(b.categories[b.open].name)))
+		lines = b.categories[b.open].modules
+		selected = b.module
+		hint = "Use ↑↓ to navigate, Enter to search for the module, Esc to go back to the categories."
+	}
+
+	offset := 0
+	if selected >= pageSize {
+		offset = selected - pageSize + 1
+	}
+	end := min(offset+pageSize, len(lines))
+	for i := offset; i < end; i++ {
+		if i == selected {
+			s.WriteString(selectedItemStyle.Render(lines[i]))
+		} else {
+			s.WriteString(itemStyle.Render(lines[i]))
+		}
+		s.WriteString("\n")
+	}
+
+	s.WriteString("\n")
+	s.WriteString(statusMessageStyle.Render(hint))
+	return s.String()
+}
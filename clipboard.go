@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// clipboardBackends are the ways of copying text that the clipboard option
+// and --clipboard can pick, besides "auto".
+var clipboardBackends = map[string]func(text string) error{
+	"pbcopy":  func(text string) error { return runClipboardCommand(text, "pbcopy") },
+	"wl-copy": func(text string) error { return runClipboardCommand(text, "wl-copy") },
+	"xclip":   func(text string) error { return runClipboardCommand(text, "xclip", "-selection", "clipboard", "-i") },
+	"xsel":    func(text string) error { return runClipboardCommand(text, "xsel", "--clipboard", "--input") },
+	"windows": copyNative,
+	"osc52":   copyOSC52,
+}
+
+// clipboardNames lists the names the clipboard option accepts.
+func clipboardNames() []string {
+	names := []string{"auto"}
+	for name := range clipboardBackends {
+		names = append(names, name)
+	}
+	slices.Sort(names[1:])
+	return names
+}
+
+// copyToClipboard copies text with the named backend, or with "auto" the
+// one suited to the session: OSC 52 over SSH, where the local terminal owns
+// the clipboard, and otherwise the system clipboard, falling back to OSC 52
+// if no clipboard tool is installed.
+func copyToClipboard(backend, text string) error {
+	if backend == "" || backend == "auto" {
+		backend = detectClipboard()
+	}
+	copyText, ok := clipboardBackends[backend]
+	if !ok {
+		return fmt.Errorf("unknown clipboard backend '%s'", backend)
+	}
+	if err := copyText(text); err != nil {
+		return &ClipboardError{Backend: backend, Err: err}
+	}
+	return nil
+}
+
+func detectClipboard() string {
+	if os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != "" {
+		return "osc52"
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return "pbcopy"
+	case "windows":
+		return "windows"
+	}
+	var candidates []string
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		candidates = append(candidates, "wl-copy")
+	}
+	if os.Getenv("DISPLAY") != "" {
+		candidates = append(candidates, "xclip", "xsel")
+	}
+	for _, name := range candidates {
+		if _, err := exec.LookPath(name); err == nil {
+			return name
+		}
+	}
+	return "osc52"
+}
+
+// clipboardTimeout is how long a clipboard tool may take. Some, like xclip
+// under some X setups, never return.
+const clipboardTimeout = 3 * time.Second
+
+// runClipboardCommand copies text by piping it to a clipboard tool.
+func runClipboardCommand(text, name string, args ...string) error {
+	defer track()()
+	ctx, cancel := context.WithTimeout(appCtx, clipboardTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	cmd.Stdin = strings.NewReader(text)
+	// xclip and xsel leave a child behind to serve the selection, which
+	// holds on to stderr; the copy is done once the tool itself exits.
+	cmd.WaitDelay = 100 * time.Millisecond
+
+	if err := cmd.Run(); err != nil && !errors.Is(err, exec.ErrWaitDelay) {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("clipboard command '%s' did not finish within %s", name, clipboardTimeout)
+		}
+		errorOutput := strings.TrimSpace(stderr.String())
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("clipboard command '%s' exited with error %d: %w (Stderr: %s)", name, exitErr.ExitCode(), err, errorOutput)
+		}
+		if _, lookErr := exec.LookPath(name); lookErr != nil {
+			return fmt.Errorf("clipboard command '%s' not found. Please ensure it's installed and in your PATH, or set clipboard to another backend", name)
+		}
+		return fmt.Errorf("clipboard command '%s' failed: %w (Stderr: %s)", name, err, errorOutput)
+	}
+	return nil
+}
+
+// copyOSC52 asks the terminal to set the clipboard with an OSC 52 escape
+// sequence, which also works over SSH as long as the terminal supports it.
+// Inside tmux the sequence is wrapped to pass through to the outer terminal.
+func copyOSC52(text string) error {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+
+	var tty io.Writer = os.Stderr
+	if f, err := os.OpenFile(terminalDevice, os.O_WRONLY, 0); err == nil {
+		defer f.Close()
+		tty = f
+	}
+	if _, err := io.WriteString(tty, seq); err != nil {
+		return fmt.Errorf("failed to write to the terminal: %w", err)
+	}
+	return nil
+}
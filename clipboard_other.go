@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import "fmt"
+
+const terminalDevice = "/dev/tty"
+
+func copyNative(string) error {
+	return fmt.Errorf("the windows clipboard backend only works on Windows")
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+)
+
+const terminalDevice = "CONOUT$"
+
+var (
+	user32           = syscall.NewLazyDLL("user32.dll")
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	openClipboard    = user32.NewProc("OpenClipboard")
+	closeClipboard   = user32.NewProc("CloseClipboard")
+	emptyClipboard   = user32.NewProc("EmptyClipboard")
+	setClipboardData = user32.NewProc("SetClipboardData")
+	globalAlloc      = kernel32.NewProc("GlobalAlloc")
+	globalFree       = kernel32.NewProc("GlobalFree")
+	globalLock       = kernel32.NewProc("GlobalLock")
+	globalUnlock     = kernel32.NewProc("GlobalUnlock")
+	moveMemory       = kernel32.NewProc("RtlMoveMemory")
+)
+
+const (
+	cfUnicodeText = 13
+	gmemMoveable  = 0x0002
+)
+
+// copyNative puts text on the Windows clipboard as UTF-16 through the
+// user32 clipboard API.
+func copyNative(text string) error {
+	if r, _, err := openClipboard.Call(0); r == 0 {
+		return fmt.Errorf("failed to open the clipboard: %w", err)
+	}
+	defer closeClipboard.Call()
+	if r, _, err := emptyClipboard.Call(); r == 0 {
+		return fmt.Errorf("failed to empty the clipboard: %w", err)
+	}
+
+	data := append(utf16.Encode([]rune(text)), 0)
+	size := uintptr(len(data) * 2)
+	mem, _, err := globalAlloc.Call(gmemMoveable, size)
+	if mem == 0 {
+		return fmt.Errorf("failed to allocate clipboard memory: %w", err)
+	}
+	p, _, err := globalLock.Call(mem)
+	if p == 0 {
+		globalFree.Call(mem)
+		return fmt.Errorf("failed to lock clipboard memory: %w", err)
+	}
+	moveMemory.Call(p, uintptr(unsafe.Pointer(&data[0])), size)
+	globalUnlock.Call(mem)
+
+	// The clipboard owns the memory once SetClipboardData succeeds.
+	if r, _, err := setClipboardData.Call(cfUnicodeText, mem); r == 0 {
+		globalFree.Call(mem)
+		return fmt.Errorf("failed to set the clipboard: %w", err)
+	}
+	return nil
+}
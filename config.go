@@ -0,0 +1,291 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the user settings read from ~/.config/gosearch/config.yaml.
+type Config struct {
+	// EnterAction names the action run when Enter is pressed on a result.
+	EnterAction string `yaml:"enter_action"`
+	// Confirm names the actions, and the bulk-upgrade, refresh, and undo steps,
+	// that ask before they run.
+	Confirm []string `yaml:"confirm"`
+	// Hook is the shell command run by the "hook" action. The placeholders
+	// {path} and {version} are replaced with the selected package.
+	Hook string `yaml:"hook"`
+	// Matcher is the search algorithm used at startup, e.g. "fuzzy" or "regex".
+	Matcher string `yaml:"matcher"`
+	// Ranking weights the signals that order search results by name.
+	Ranking map[string]float64 `yaml:"ranking"`
+	// PreferredHosts are the path prefixes the hosts ranking signal favors.
+	PreferredHosts []string `yaml:"preferred_hosts"`
+	// Icons renders Nerd Font host and status icons next to results.
+	Icons bool `yaml:"icons"`
+	// StandardLibrary adds the packages of the installed Go toolchain's
+	// standard library to the searched set.
+	StandardLibrary bool `yaml:"standard_library"`
+	// StatusLine is a text/template for the bottom status line.
+	StatusLine string `yaml:"status_line"`
+	// RefreshInterval is how old the loaded data may get while the TUI is
+	// open before it is refreshed in the background. Zero disables it.
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+	// MemoryBudget caps the estimated memory held by loaded index entries.
+	// Above it only the last KeepMonths months stay in memory and older
+	// entries are searched from disk on demand. Zero means no limit.
+	MemoryBudget byteSize `yaml:"memory_budget"`
+	KeepMonths   int      `yaml:"keep_months"`
+	// Categories adds modules to the category browser, by category name.
+	Categories map[string][]string `yaml:"categories"`
+	// Team shares annotations through a git repository or an HTTP endpoint.
+	Team TeamConfig `yaml:"team"`
+	// SettingsSync is where `gosearch settings` keeps the encrypted copy
+	// of the config, annotations, and history shared between machines.
+	SettingsSync SettingsSyncConfig `yaml:"settings_sync"`
+	// Policy is the path of a policy file restricting which packages may be
+	// used (see Policy).
+	Policy string `yaml:"policy"`
+	// Audit records every selection and install in audit.jsonl next to the
+	// config file.
+	Audit bool `yaml:"audit"`
+	// SyncRateLimit caps how many bytes per second gosearch sync and
+	// background refreshes read from the index feed. Zero means no limit.
+	SyncRateLimit byteSize `yaml:"sync_rate_limit"`
+	// MaxEntries caps how many new entries of the index feed are fetched at
+	// startup; later launches carry on from the cache. Zero means the whole
+	// feed.
+	MaxEntries int `yaml:"max_entries"`
+	// IndexURL is the index feed to load instead of index.golang.org.
+	IndexURL string `yaml:"index_url"`
+	// PageSize caps how many results are shown at once; zero fits them to
+	// the terminal.
+	PageSize int `yaml:"page_size"`
+	// Keys rebinds the commands of the results screen, by command name.
+	Keys map[string]keyList `yaml:"keys"`
+	// Colors overrides the palette, by color name.
+	Colors map[string]string `yaml:"colors"`
+	// StayOpen keeps the TUI running after a copy action instead of
+	// quitting.
+	StayOpen bool `yaml:"stay_open"`
+	// Clipboard names the backend the copy actions use; "auto" picks one
+	// suited to the session.
+	Clipboard string `yaml:"clipboard"`
+	// GitHubMetadata shows the stars, archive status, and last push of the
+	// GitHub repositories of results, from the GitHub API.
+	GitHubMetadata bool `yaml:"github_metadata"`
+	// History keeps what is picked in usage.json for the frecency ranking
+	// signal and the history browser.
+	History bool `yaml:"history"`
+}
+
+// byteSize is a size in bytes that may be written with a unit, like 512MiB.
+type byteSize int64
+
+func (b *byteSize) UnmarshalYAML(value *yaml.Node) error {
+	n, err := parseByteSize(value.Value)
+	if err != nil {
+		return err
+	}
+	*b = n
+	return nil
+}
+
+func parseByteSize(s string) (byteSize, error) {
+	units := []struct {
+		suffix string
+		scale  int64
+	}{
+		{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30},
+		{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"B", 1},
+	}
+	num, scale := strings.TrimSpace(s), int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(num, u.suffix) {
+			num, scale = strings.TrimSpace(strings.TrimSuffix(num, u.suffix)), u.scale
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size '%s'", s)
+	}
+	return byteSize(n * float64(scale)), nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		EnterAction:     "copy",
+		Confirm:         []string{"install", "clone", "bulk-upgrade", "refresh", "undo"},
+		Matcher:         "fuzzy",
+		Ranking:         map[string]float64{"match": 1, "tools": 0.5, "frecency": 0.3, "hosts": 0.5},
+		StatusLine:      defaultStatusLine,
+		RefreshInterval: time.Hour,
+		KeepMonths:      12,
+		Clipboard:       "auto",
+		StandardLibrary: true,
+		GitHubMetadata:  true,
+		History:         true,
+	}
+}
+
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "gosearch", "config.yaml"), nil
+}
+
+// cacheDir returns the directory gosearch keeps derived data in.
+func cacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	return filepath.Join(dir, "gosearch"), nil
+}
+
+// loadConfig reads the config file, falling back to defaults for any option
+// that is not set, then the project's .gosearch.yaml and the GOSEARCH_*
+// environment variables over it; flags override all of them. Missing files
+// are not an error.
+func loadConfig() (Config, error) {
+	cfg := defaultConfig()
+
+	path, err := configPath()
+	if err != nil {
+		return cfg, err
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return cfg, fmt.Errorf("failed to read config file: %w", err)
+	default:
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return cfg, &ParseError{What: "config file", Path: path, Err: err}
+		}
+		if err := checkConfig(cfg, path); err != nil {
+			return cfg, err
+		}
+	}
+
+	cwd, _ := os.Getwd()
+	if overlay := findProjectConfig(cwd); overlay != "" {
+		if err := applyProjectConfig(&cfg, overlay); err != nil {
+			return cfg, err
+		}
+	}
+
+	if err := applyConfigEnv(&cfg, os.Environ()); err != nil {
+		return cfg, err
+	}
+	if err := checkConfig(cfg, "the GOSEARCH_* environment"); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// checkConfig validates cfg as read from path.
+func checkConfig(cfg Config, path string) error {
+	if _, ok := actions[cfg.EnterAction]; !ok {
+		return fmt.Errorf("unknown enter_action '%s' in %s", cfg.EnterAction, path)
+	}
+	if err := checkConfirm(cfg.Confirm); err != nil {
+		return fmt.Errorf("%w in %s", err, path)
+	}
+	if cfg.EnterAction == "hook" && cfg.Hook == "" {
+		return fmt.Errorf("enter_action is 'hook' but no hook command is configured in %s", path)
+	}
+	if cfg.MaxEntries < 0 {
+		return fmt.Errorf("max_entries must not be negative in %s", path)
+	}
+	if !slices.Contains(clipboardNames(), cfg.Clipboard) {
+		return fmt.Errorf("unknown clipboard '%s' in %s (want one of %s)", cfg.Clipboard, path, strings.Join(clipboardNames(), ", "))
+	}
+	if cfg.PageSize < 0 {
+		return fmt.Errorf("page_size must not be negative in %s", path)
+	}
+	if _, err := newKeyMap(cfg.Keys); err != nil {
+		return fmt.Errorf("%w in %s", err, path)
+	}
+	if err := checkColors(cfg.Colors); err != nil {
+		return fmt.Errorf("%w in %s", err, path)
+	}
+	if cfg.KeepMonths < 1 {
+		return fmt.Errorf("keep_months must be at least 1 in %s", path)
+	}
+	return nil
+}
+
+// projectConfigName is the per-project overlay of the config file, checked
+// into a repository to share settings with everyone working in it.
+const projectConfigName = ".gosearch.yaml"
+
+// userOnlyOptions cannot be set by a project overlay: a checked-in file must
+// not pick the commands gosearch runs or where it sends the user's settings.
+var userOnlyOptions = []string{"hook", "settings_sync"}
+
+// findProjectConfig looks for .gosearch.yaml in dir and its parents, up to
+// the root of the repository containing dir.
+func findProjectConfig(dir string) string {
+	for dir != "" {
+		path := filepath.Join(dir, projectConfigName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return ""
+}
+
+// applyProjectConfig lays the overlay at path over cfg. Options it sets
+// replace those of the config file, except that it can only add to the
+// confirmations and turn the audit trail on, never drop what the user set
+// up; a relative policy path is taken from the overlay's directory.
+func applyProjectConfig(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read project config: %w", err)
+	}
+	var options map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &options); err != nil {
+		return &ParseError{What: "project config", Path: path, Err: err}
+	}
+	for _, name := range userOnlyOptions {
+		if _, ok := options[name]; ok {
+			return fmt.Errorf("%s cannot be set in %s; set it in your own config file", name, path)
+		}
+	}
+	policy, confirm, audit := cfg.Policy, cfg.Confirm, cfg.Audit
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return &ParseError{What: "project config", Path: path, Err: err}
+	}
+	for _, name := range confirm {
+		if !slices.Contains(cfg.Confirm, name) {
+			cfg.Confirm = append(cfg.Confirm, name)
+		}
+	}
+	cfg.Audit = cfg.Audit || audit
+	if cfg.Policy != policy && cfg.Policy != "" && !filepath.IsAbs(cfg.Policy) && !strings.HasPrefix(cfg.Policy, "~/") {
+		cfg.Policy = filepath.Join(filepath.Dir(path), cfg.Policy)
+	}
+	return checkConfig(*cfg, path)
+}
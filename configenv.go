@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configEnvPrefix starts the environment variables that set config options:
+// GOSEARCH_ and the option's name in capitals, like GOSEARCH_ENTER_ACTION,
+// with nested options joined by an underscore, like GOSEARCH_TEAM_GIT.
+const configEnvPrefix = "GOSEARCH_"
+
+// applyConfigEnv sets the options of cfg named by GOSEARCH_* variables in
+// environ. Text options take the value as it is; the others parse it as
+// YAML, so lists and maps are written inline, like [a, b] or {match: 1}.
+// Other GOSEARCH_ variables are left alone, as gosearch also sets some for
+// the hook.
+func applyConfigEnv(cfg *Config, environ []string) error {
+	vars := make(map[string]string)
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		if rest, ok := strings.CutPrefix(name, configEnvPrefix); ok {
+			vars[rest] = value
+		}
+	}
+	if len(vars) == 0 {
+		return nil
+	}
+	return setFromEnv(reflect.ValueOf(cfg).Elem(), "", vars)
+}
+
+// setFromEnv sets the fields of the struct v, whose options are named with
+// prefix, from vars.
+func setFromEnv(v reflect.Value, prefix string, vars map[string]string) error {
+	t := v.Type()
+	for i := range t.NumField() {
+		tag, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name := prefix + strings.ToUpper(tag)
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := setFromEnv(field, name+"_", vars); err != nil {
+				return err
+			}
+			continue
+		}
+		value, ok := vars[name]
+		if !ok {
+			continue
+		}
+		if field.Kind() == reflect.String {
+			field.SetString(value)
+			continue
+		}
+		if err := yaml.Unmarshal([]byte(value), field.Addr().Interface()); err != nil {
+			return fmt.Errorf("invalid %s%s: %w", configEnvPrefix, name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+// confirmSteps are what the confirm option can name besides actions.
+var confirmSteps = []string{"bulk-upgrade", "refresh", "undo"}
+
+// checkConfirm reports names in the confirm option that neither an action
+// nor a confirm step has.
+func checkConfirm(names []string) error {
+	for _, name := range names {
+		if _, ok := actions[name]; !ok && !slices.Contains(confirmSteps, name) {
+			return fmt.Errorf("unknown action '%s' in confirm", name)
+		}
+	}
+	return nil
+}
+
+// confirmDialog asks before running something that changes the project or
+// loads a lot from the network. Only Y goes ahead, so a stray key cannot.
+type confirmDialog struct {
+	title   string
+	details []string
+	// back is the screen to return to, where run then runs.
+	back state
+	run  func(m *model) tea.Cmd
+}
+
+type confirmAnsweredMsg struct {
+	yes bool
+}
+
+func (d confirmDialog) Update(msg tea.Msg) (confirmDialog, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		answer := confirmAnsweredMsg{yes: strings.ToLower(key.String()) == "y"}
+		return d, func() tea.Msg { return answer }
+	}
+	return d, nil
+}
+
+func (d confirmDialog) View() string {
+	s := strings.Builder{}
+	s.WriteString(warningStyle.Render(d.title) + "\n\n")
+	for _, line := range d.details {
+		s.WriteString(itemStyle.Render(line) + "\n")
+	}
+	s.WriteString("\n")
+	s.WriteString(statusMessageStyle.Render("Press Y to go ahead, any other key to cancel."))
+	return s.String()
+}
+
+// confirm runs run right away, or, if the confirm option names name, once
+// the user agrees in a dialog.
+func (m *model) confirm(name, title string, details []string, run func(m *model) tea.Cmd) tea.Cmd {
+	if !slices.Contains(m.config.Confirm, name) {
+		return run(m)
+	}
+	back := m.state
+	if !m.setState(stateConfirming) {
+		return nil
+	}
+	m.confirmation = confirmDialog{title: title, details: details, back: back, run: run}
+	return nil
+}
+
+// answerConfirm goes back to the screen the dialog was opened from, running
+// what it asked about if the answer was yes.
+func (m *model) answerConfirm(msg confirmAnsweredMsg) tea.Cmd {
+	if m.state != stateConfirming {
+		return nil
+	}
+	d := m.confirmation
+	m.confirmation = confirmDialog{}
+	if !m.setState(d.back) {
+		return nil
+	}
+	if !msg.yes {
+		return m.flashMessage("Cancelled.", false)
+	}
+	return d.run(m)
+}
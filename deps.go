@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// goModFetchers bounds the concurrent go.mod downloads of a build list walk.
+const goModFetchers = 8
+
+// fetchGoMod downloads and parses the go.mod file of path@version.
+func fetchGoMod(path, version string) (*modfile.File, error) {
+	escaped, err := module.EscapeVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version '%s': %w", version, err)
+	}
+	resp, err := proxyGet(path, "@v/"+escaped+".mod")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod of %s@%s: %w", path, version, err)
+	}
+	f, err := modfile.ParseLax("go.mod", data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go.mod of %s@%s: %w", path, version, err)
+	}
+	return f, nil
+}
+
+// buildList runs minimal version selection over the module graph below root,
+// reading go.mod files from the proxy, and returns the selected version of
+// every module root depends on, sorted by path. Like the go command, it
+// ignores replace and exclude directives outside the main module, and it
+// prunes the graph as for a main module requiring root at go 1.17 or later:
+// the requirements of a module at go 1.17 or later are included, but not
+// theirs in turn.
+func buildList(root module.Version) ([]module.Version, error) {
+	selected := map[string]string{root.Path: root.Version}
+	visited := map[module.Version]bool{root: true}
+
+	frontier := []module.Version{root}
+	for len(frontier) > 0 {
+		mods := make([]*modfile.File, len(frontier))
+		errs := make([]error, len(frontier))
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, goModFetchers)
+		for i, mv := range frontier {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				mods[i], errs[i] = fetchGoMod(mv.Path, mv.Version)
+			}()
+		}
+		wg.Wait()
+
+		var next []module.Version
+		for i, f := range mods {
+			if errs[i] != nil {
+				return nil, errs[i]
+			}
+			pruned := f.Go != nil && semver.Compare("v"+f.Go.Version, "v1.17") >= 0
+			for _, req := range f.Require {
+				mv := req.Mod
+				if semver.Compare(mv.Version, selected[mv.Path]) > 0 {
+					selected[mv.Path] = mv.Version
+				}
+				if !pruned && !visited[mv] {
+					visited[mv] = true
+					next = append(next, mv)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	// Versions below the selected ones were only walked for their
+	// requirements.
+	var list []module.Version
+	for path, version := range selected {
+		if path != root.Path {
+			list = append(list, module.Version{Path: path, Version: version})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list, nil
+}
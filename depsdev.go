@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+// depsDevInsights is what deps.dev tells about a module version beyond the
+// proxy's metadata.
+type depsDevInsights struct {
+	licenses []string
+	// dependents counts the packages deps.dev knows to depend on the
+	// version, direct those that require it themselves; -1 if unknown.
+	dependents, direct int
+	// scorecard is the OpenSSF Scorecard score of the source repository,
+	// out of 10, or -1 if it has none.
+	scorecard float64
+}
+
+// fetchDepsDevInsights looks up the licenses, dependents and scorecard of a
+// module version. Only a failed version lookup is an error; the dependents
+// and the scorecard are left unknown if their lookups fail.
+func fetchDepsDevInsights(modPath, version string) (depsDevInsights, error) {
+	in := depsDevInsights{dependents: -1, direct: -1, scorecard: -1}
+	info, err := fetchDepsDevVersion(modPath, version)
+	if err != nil {
+		return in, err
+	}
+	in.licenses = info.Licenses
+	if n, direct, err := fetchDependents(modPath, version); err == nil {
+		in.dependents, in.direct = n, direct
+	}
+	for _, p := range info.RelatedProjects {
+		if p.RelationType != "SOURCE_REPO" {
+			continue
+		}
+		var project struct {
+			Scorecard *struct {
+				OverallScore float64 `json:"overallScore"`
+			} `json:"scorecard"`
+		}
+		if err := depsDevGet(depsDevProjectAPIURL+"/"+url.PathEscape(p.ProjectKey.ID), &project); err == nil && project.Scorecard != nil {
+			in.scorecard = project.Scorecard.OverallScore
+		}
+		break
+	}
+	return in, nil
+}
+
+// fetchDependents counts the packages that depend on a module version, and
+// of those the ones that require it directly.
+func fetchDependents(modPath, version string) (int, int, error) {
+	var counts struct {
+		DependentCount       int `json:"dependentCount"`
+		DirectDependentCount int `json:"directDependentCount"`
+	}
+	endpoint := fmt.Sprintf("%s/%s/versions/%s:dependents", depsDevDependentsAPIURL, url.PathEscape(modPath), url.PathEscape(version))
+	if err := depsDevGet(endpoint, &counts); err != nil {
+		return 0, 0, err
+	}
+	return counts.DependentCount, counts.DirectDependentCount, nil
+}
+
+// dependentCounts are the dependents deps.dev counted for modules, by path,
+// as far as they were looked up this session; -1 while a lookup runs.
+type dependentCounts map[string]int
+
+// popularityScore scores path by its dependents on a log scale, reaching 1
+// at a million.
+func (d dependentCounts) popularityScore(path string) float64 {
+	n := d[path]
+	if n <= 0 {
+		return 0
+	}
+	return min(1, math.Log10(float64(n)+1)/6)
+}
+
+type dependentsCountedMsg struct {
+	path  string
+	count int
+	err   error
+}
+
+func countDependentsCmd(path, version string) tea.Cmd {
+	return func() tea.Msg {
+		n, _, err := fetchDependents(path, version)
+		return dependentsCountedMsg{path: path, count: n, err: err}
+	}
+}
+
+// sortsByPopularity reports whether the popularity signal weighs in the
+// ranking, which is when dependents are counted for the visible rows.
+func (m model) sortsByPopularity() bool {
+	return m.config.Ranking["popularity"] != 0 && m.dependents != nil
+}
+
+// checkVisiblePopularity counts the dependents of the visible rows not yet
+// counted, while results are sorted by popularity.
+func (m *model) checkVisiblePopularity() tea.Cmd {
+	if !m.sortsByPopularity() {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for _, item := range m.list.Visible() {
+		path := item.Package.Path
+		if _, seen := m.dependents[path]; seen || isStdPath(path) {
+			continue
+		}
+		m.dependents[path] = -1
+		cmds = append(cmds, countDependentsCmd(path, m.packageVersion(item.Package)))
+	}
+	return tea.Batch(cmds...)
+}
+
+// finishDependentsCount ranks the results again with the new count.
+func (m *model) finishDependentsCount(msg dependentsCountedMsg) tea.Cmd {
+	if msg.err != nil {
+		// Counted as none, so that it is not looked up again.
+		m.dependents[msg.path] = 0
+		return nil
+	}
+	m.dependents[msg.path] = msg.count
+	pkg, selected := m.selectedPackage()
+	m.filterPackages()
+	if selected {
+		m.selectPath(pkg.Path)
+	}
+	return m.resolveVisibleLatest()
+}
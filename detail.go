@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+// detailView is the sub-screen describing a single module.
+type detailView struct {
+	path     string
+	loading  bool
+	latest   VersionInfo
+	insights depsDevInsights
+	zip      *zipInfo
+	err      error
+	// scanning is set while the module's zip is inspected; scanErr is why
+	// that failed.
+	scanning bool
+	scanErr  error
+	// weight is what adopting the module would add to the current
+	// project, if there is one.
+	weighing  bool
+	weight    *dependencyWeight
+	weightErr error
+	// vulns are the known vulnerabilities of the latest version, once
+	// osv.dev answered.
+	vulnsLoading bool
+	vulns        []vulnerability
+	vulnsErr     error
+	keys         keyMap
+}
+
+type detailLoadedMsg struct {
+	path     string
+	latest   VersionInfo
+	insights depsDevInsights
+	zip      *zipInfo
+	err      error
+}
+
+// detailActionsMsg asks for the actions menu of the module on display.
+type detailActionsMsg struct {
+	path string
+}
+
+func newDetailView(path string, keys keyMap) detailView {
+	return detailView{path: path, loading: true, keys: keys}
+}
+
+func fetchDetailCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		latest, err := fetchLatest(path)
+		if err != nil {
+			return detailLoadedMsg{path: path, err: fmt.Errorf("failed to look up '%s': %w", path, err)}
+		}
+		// What deps.dev knows and the size are niceties; the rest of the
+		// view stands without them.
+		insights, _ := fetchDepsDevInsights(path, latest.Version)
+		msg := detailLoadedMsg{path: path, latest: latest, insights: insights}
+		if zip, err := fetchZipInfo(path, latest.Version); err == nil {
+			msg.zip = &zip
+		}
+		return msg
+	}
+}
+
+func (d detailView) Update(msg tea.Msg) (detailView, tea.Cmd) {
+	switch msg := msg.(type) {
+	case detailLoadedMsg:
+		if msg.path == d.path {
+			d.loading = false
+			d.latest, d.insights, d.zip, d.err = msg.latest, msg.insights, msg.zip, msg.err
+		}
+
+	case moduleScannedMsg:
+		if msg.path == d.path {
+			d.scanning, d.scanErr = false, msg.err
+		}
+
+	case weightEstimatedMsg:
+		if msg.path == d.path {
+			d.weighing, d.weight, d.weightErr = false, msg.weight, msg.err
+		}
+
+	case vulnsLoadedMsg:
+		if msg.path == d.path && msg.version == d.latest.Version {
+			d.vulnsLoading, d.vulns, d.vulnsErr = false, msg.vulns, msg.err
+		}
+
+	case tea.KeyMsg:
+		if msg.String() == "esc" {
+			return d, closeOverlay
+		}
+		if d.keys.command(msg) == "actions" {
+			chosen := detailActionsMsg{path: d.path}
+			return d, func() tea.Msg { return chosen }
+		}
+	}
+	return d, nil
+}
+
+// View renders the module's metadata followed by what scan found in its zip
+// and extra, lines the model knows about it such as notes and policy
+// findings.
+func (d detailView) View(scan moduleScan, extra []string) string {
+	s := strings.Builder{}
+	s.WriteString(inputStyle.Render(d.path) + "\n\n")
+
+	switch {
+	case d.loading:
+		s.WriteString(statusMessageStyle.Render("Loading module details from proxy.golang.org...") + "\n")
+	case d.err != nil:
+		s.WriteString(errorStyle.Render(d.err.Error()) + "\n")
+	default:
+		s.WriteString(itemStyle.Render("Latest:    "+d.latest.Version) + "\n")
+		if !d.latest.Time.IsZero() {
+			s.WriteString(itemStyle.Render("Published: "+d.latest.Time.Format("2006-01-02 15:04 MST")) + "\n")
+		}
+		license := "unknown"
+		if len(d.insights.licenses) > 0 {
+			license = strings.Join(d.insights.licenses, ", ")
+		}
+		s.WriteString(itemStyle.Render("License:   "+license) + "\n")
+		if d.insights.dependents >= 0 {
+			s.WriteString(itemStyle.Render(fmt.Sprintf("Used by:   %d packages, %d directly", d.insights.dependents, d.insights.direct)) + "\n")
+		}
+		if d.insights.scorecard >= 0 {
+			s.WriteString(itemStyle.Render(fmt.Sprintf("Scorecard: %.1f/10 (OpenSSF)", d.insights.scorecard)) + "\n")
+		}
+		if d.zip != nil {
+			size := formatBytes(d.zip.Size) + " zipped"
+			if d.zip.Files >= 0 {
+				size += fmt.Sprintf(", %d files", d.zip.Files)
+			}
+			s.WriteString(itemStyle.Render("Size:      "+size) + "\n")
+		}
+		switch {
+		case d.weighing:
+			s.WriteString(statusMessageStyle.Render("Comparing its dependencies with the current module...") + "\n")
+		case d.weightErr != nil:
+			s.WriteString(errorStyle.Render(d.weightErr.Error()) + "\n")
+		case d.weight != nil:
+			s.WriteString(itemStyle.Render("Adds:      "+d.weight.String()) + "\n")
+		}
+		switch {
+		case d.vulnsLoading:
+			s.WriteString(statusMessageStyle.Render("Looking up known vulnerabilities on osv.dev...") + "\n")
+		case d.vulnsErr != nil:
+			s.WriteString(errorStyle.Render(d.vulnsErr.Error()) + "\n")
+		case len(d.vulns) == 0:
+			s.WriteString(itemStyle.Render("Vulns:     none known") + "\n")
+		default:
+			for i, v := range d.vulns {
+				label := "           "
+				if i == 0 {
+					label = "Vulns:     "
+				}
+				line := v.line()
+				if v.Summary != "" {
+					line += " " + v.Summary
+				}
+				s.WriteString(itemStyle.Foreground(errorStyle.GetForeground()).Render(label+line) + "\n")
+			}
+		}
+		switch {
+		case d.scanning:
+			s.WriteString(statusMessageStyle.Render("Inspecting the module zip...") + "\n")
+		case d.scanErr != nil:
+			s.WriteString(errorStyle.Render(d.scanErr.Error()) + "\n")
+		case scan.version == d.latest.Version:
+			s.WriteString(itemStyle.Render("Cgo:       "+scan.cgoLine()) + "\n")
+			goos, goarch := scan.platformLines()
+			if goos == "" {
+				goos = "any"
+			}
+			s.WriteString(itemStyle.Render("Platforms: "+goos) + "\n")
+			if goarch != "" {
+				s.WriteString(itemStyle.Render("Arch:      "+goarch) + "\n")
+			}
+			if scan.api != nil {
+				for i, line := range scan.api.apiLines() {
+					label := "           "
+					if i == 0 {
+						label = "API:       "
+					}
+					s.WriteString(itemStyle.Render(label+line) + "\n")
+				}
+			}
+		}
+	}
+	for _, line := range extra {
+		s.WriteString(itemStyle.Render(line) + "\n")
+	}
+
+	s.WriteString("\n")
+	s.WriteString(statusMessageStyle.Render(joinHints(d.keys.hint("for actions", "actions"), "Esc to go back")))
+	return s.String()
+}
+
+// openDetail shows the detail view of the module at path.
+func (m *model) openDetail(path string) tea.Cmd {
+	if !m.setState(stateDetail) {
+		return nil
+	}
+	m.detail = newDetailView(path, m.keys)
+	return tea.Batch(fetchDetailCmd(path), m.checkMigration(path))
+}
+
+// detailLines lists what the model knows about path beyond the proxy's
+// metadata.
+func (m model) detailLines(path string) []string {
+	var lines []string
+	if reason := m.violation(Package{Path: path}); reason != "" {
+		lines = append(lines, "Policy:    "+reason)
+	}
+	if mig := m.migrations[path]; mig != nil {
+		lines = append(lines, "Moved:     to "+mig.to+" ("+firstLine(mig.reason)+")")
+	}
+	if tools := m.tools.toolsOf(path); len(tools) > 0 {
+		lines = append(lines, "Tools:     "+strings.Join(tools, ", ")+" ("+m.tools.imports[tools[0]]+")")
+	}
+	a := m.annotations.get(path)
+	if len(a.Tags) > 0 {
+		lines = append(lines, "Tags:      "+strings.Join(a.Tags, ", "))
+	}
+	if a.Note != "" {
+		lines = append(lines, "Note:      "+a.Note)
+	}
+	if shared := m.annotations.getShared(path); len(shared.Tags) > 0 || shared.Note != "" {
+		var team []string
+		if len(shared.Tags) > 0 {
+			team = append(team, strings.Join(shared.Tags, ", "))
+		}
+		if shared.Note != "" {
+			team = append(team, shared.Note)
+		}
+		lines = append(lines, "Team:      "+strings.Join(team, " – "))
+	}
+	return lines
+}
+
+// deepLink is what the command line asked to do once packages are loaded.
+type deepLink struct {
+	// selection is the 1-based position of the result to select, or 0.
+	selection int
+	action    string
+	// open names a module whose detail view to show.
+	open string
+}
+
+// followDeepLink applies the deep link after the first load.
+func (m *model) followDeepLink() tea.Cmd {
+	link := m.deepLink
+	m.deepLink = deepLink{}
+	if link.open != "" {
+		return m.openDetail(link.open)
+	}
+	if link.selection > 0 {
+		if link.selection > len(m.list.matches) {
+			return m.fail(fmt.Errorf("--select %d: only %d results match", link.selection, len(m.list.matches)))
+		}
+		m.list.Select(link.selection - 1)
+	}
+	if link.action != "" {
+		pkg, ok := m.selectedPackage()
+		if !ok {
+			return m.fail(fmt.Errorf("--action %s: no package matches the query", link.action))
+		}
+		return m.runAction(link.action, pkg)
+	}
+	return nil
+}
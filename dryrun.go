@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+// commandLog collects the commands --dry-run kept from running.
+type commandLog struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+// dryRun is set by --dry-run. Commands that change the project or the
+// system are then recorded in it instead of run; lookups still run.
+var dryRun *commandLog
+
+func (l *commandLog) add(dir string, args ...string) {
+	line := shellJoin(args)
+	if dir != "" {
+		line = "(cd " + shellQuote(dir) + " && " + line + ")"
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, line)
+}
+
+// summary lists the recorded commands for the message gosearch exits with.
+func (l *commandLog) summary() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.lines) == 0 {
+		return "Dry run: nothing would have been run."
+	}
+	return "Dry run, nothing was changed. Would have run:\n  " + strings.Join(l.lines, "\n  ")
+}
+
+// runMutating runs a command that changes the project or the system, in dir
+// if set, and returns its combined output. Under --dry-run it only records
+// the command.
+func runMutating(dir, name string, args ...string) ([]byte, error) {
+	if dryRun != nil {
+		dryRun.add(dir, append([]string{name}, args...)...)
+		return nil, nil
+	}
+	defer track()()
+	cmd := command(name, args...)
+	cmd.Dir = dir
+	return cmd.CombinedOutput()
+}
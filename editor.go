@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gosearch/index"
+	"gosearch/search"
+)
+
+// editorRequest is a request of the editor protocol: one JSON object per
+// line, answered by an editorResponse with the same ID.
+type editorRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type editorResponse struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Result any             `json:"result,omitempty"`
+	Error  *editorError    `json:"error,omitempty"`
+}
+
+type editorError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error codes follow JSON-RPC.
+const (
+	editorParseError    = -32700
+	editorUnknownMethod = -32601
+	editorInvalidParams = -32602
+)
+
+// editorDefaultResults is how many completions are returned unless a
+// request asks for a number.
+const editorDefaultResults = 20
+
+type completeParams struct {
+	Prefix string `json:"prefix"`
+	Limit  int    `json:"limit"`
+}
+
+type completionItem struct {
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+}
+
+// runEditorServer implements `gosearch editor-server`: it loads the index
+// and answers completion requests for import paths on stdin/stdout until
+// stdin closes or a shutdown request arrives.
+func runEditorServer(args []string, r io.Reader, w io.Writer) error {
+	fs := flag.NewFlagSet("editor-server", flag.ContinueOnError)
+	indexFile := fs.String("index-file", "", "complete from a newline-delimited JSON `file`, such as one written by gosearch sync, instead of the network")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	var source index.Source = feedSource(cfg)
+	if *indexFile != "" {
+		dir, _ := cacheDir()
+		source = index.FileSource{Path: *indexFile, CacheDir: dir}
+	}
+	packages, err := source.Fetch(appCtx, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	engine := search.NewEngine(packages)
+	if err := engine.SetMatcher(cfg.Matcher); err != nil {
+		return err
+	}
+	dir, _ := os.Getwd()
+	ranker, err := newRanker(cfg.Ranking, findProjectTools(dir), nil, cfg.PreferredHosts, nil)
+	if err != nil {
+		return err
+	}
+	engine.SetRanker(ranker)
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(editorResponse{Method: "ready", Result: map[string]int{"packages": len(packages)}}); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var req editorRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			if err := enc.Encode(editorResponse{Error: &editorError{editorParseError, err.Error()}}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		resp := editorResponse{ID: req.ID}
+		switch req.Method {
+		case "complete":
+			var params completeParams
+			if err := json.Unmarshal(req.Params, &params); err != nil {
+				resp.Error = &editorError{editorInvalidParams, err.Error()}
+				break
+			}
+			items, err := completeImportPath(engine, params)
+			if err != nil {
+				resp.Error = &editorError{editorInvalidParams, err.Error()}
+				break
+			}
+			resp.Result = map[string]any{"items": items}
+		case "shutdown":
+			return enc.Encode(editorResponse{ID: req.ID, Result: map[string]bool{"ok": true}})
+		default:
+			resp.Error = &editorError{editorUnknownMethod, fmt.Sprintf("unknown method '%s'", req.Method)}
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// completeImportPath ranks the import paths matching a partially typed one.
+// Paths extending the prefix come first, then other matches in the engine's
+// order. Each path is listed once, with the highest version the index has.
+func completeImportPath(engine *search.Engine, params completeParams) ([]completionItem, error) {
+	limit := params.Limit
+	if limit <= 0 {
+		limit = editorDefaultResults
+	}
+	matches, err := queryMatches(appCtx, engine, params.Prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []completionItem{}
+	for _, m := range newestPerPath(matches, matchPackage) {
+		items = append(items, completionItem{Path: m.Package.Path, Version: m.Package.Version})
+	}
+	slices.SortStableFunc(items, func(a, b completionItem) int {
+		return cmp.Compare(prefixRank(a.Path, params.Prefix), prefixRank(b.Path, params.Prefix))
+	})
+	return items[:min(limit, len(items))], nil
+}
+
+// queryMatches collects the matches engine streams for q. It fails if ctx is
+// done before they all arrived.
+func queryMatches(ctx context.Context, engine *search.Engine, q string) ([]search.Match, error) {
+	stream, err := engine.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	var matches []search.Match
+	for m := range stream {
+		matches = append(matches, m)
+	}
+	return matches, ctx.Err()
+}
+
+func prefixRank(path, prefix string) int {
+	if strings.HasPrefix(path, prefix) {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// NetworkError is a failed request to the proxy, the index, or another
+// service.
+type NetworkError struct {
+	// Op describes the request, like "failed to query module proxy"; it is
+	// left empty when Err says it already.
+	Op  string
+	URL string
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	if e.Op == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Op, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error { return e.Err }
+
+// asNetworkError marks err as a NetworkError if an HTTP request failed
+// somewhere below it, for errors from code that does not make its own, like
+// the index feed.
+func asNetworkError(err error) error {
+	var urlErr *url.Error
+	var network *NetworkError
+	if errors.As(err, &network) || !errors.As(err, &urlErr) {
+		return err
+	}
+	return &NetworkError{URL: urlErr.URL, Err: err}
+}
+
+// ParseError is a file, or a response, that could not be read as expected.
+type ParseError struct {
+	// What was parsed, like "config file", and from which path, if any.
+	What string
+	Path string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("failed to parse %s: %v", e.What, e.Err)
+	}
+	return fmt.Sprintf("failed to parse %s %s: %v", e.What, e.Path, e.Err)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// ClipboardError is a copy that the clipboard backend did not take.
+type ClipboardError struct {
+	Backend string
+	Err     error
+}
+
+func (e *ClipboardError) Error() string {
+	return fmt.Sprintf("failed to copy with the %s clipboard: %v", e.Backend, e.Err)
+}
+
+func (e *ClipboardError) Unwrap() error { return e.Err }
+
+// ActionError is a command run for an action, like go get or the hook,
+// that failed.
+type ActionError struct {
+	Action string
+	// Command is what was run, and Output what it printed.
+	Command string
+	Output  string
+	Err     error
+}
+
+func (e *ActionError) Error() string {
+	msg := fmt.Sprintf("%s failed: %v", e.Command, e.Err)
+	if e.Output != "" {
+		msg += "\n" + e.Output
+	}
+	return msg
+}
+
+func (e *ActionError) Unwrap() error { return e.Err }
+
+// Exit codes of gosearch, so scripts can tell failures apart. Flag errors
+// exit with 2, as the flag package has them.
+const (
+	exitFailure   = 1
+	exitNetwork   = 3
+	exitParse     = 4
+	exitClipboard = 5
+	exitAction    = 6
+)
+
+// exitCode maps err to the exit code for its kind.
+func exitCode(err error) int {
+	err = asNetworkError(err)
+	var (
+		network   *NetworkError
+		parse     *ParseError
+		clipboard *ClipboardError
+		action    *ActionError
+	)
+	switch {
+	case errors.As(err, &action):
+		return exitAction
+	case errors.As(err, &clipboard):
+		return exitClipboard
+	case errors.As(err, &network):
+		return exitNetwork
+	case errors.As(err, &parse):
+		return exitParse
+	}
+	return exitFailure
+}
+
+// recoveryHint suggests what to do about err, shown under it when gosearch
+// stops on it.
+func recoveryHint(err error) string {
+	err = asNetworkError(err)
+	var (
+		network   *NetworkError
+		parse     *ParseError
+		clipboard *ClipboardError
+		action    *ActionError
+	)
+	switch {
+	case errors.As(err, &action):
+		if action.Action == "hook" {
+			return "Check the hook command in the config file; --dry-run shows what it expands to."
+		}
+		return "Run the command above in a shell to see what goes wrong."
+	case errors.As(err, &clipboard):
+		return "Pick another backend with --clipboard or the clipboard option (osc52 works over SSH), or use the print action."
+	case errors.As(err, &network):
+		return "Check the network connection and GOPROXY, or search offline with --local or --index-file."
+	case errors.As(err, &parse) && parse.Path != "":
+		return "Fix or move aside " + parse.Path + " and run gosearch again."
+	}
+	return ""
+}
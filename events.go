@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// event is one line of the --events-fd/--events-file stream.
+type event struct {
+	Time time.Time `json:"time"`
+	// Type is "ready", "query", "select", "action", or "exit".
+	Type  string `json:"type"`
+	Query string `json:"query,omitempty"`
+	// Results counts the matches of a query, or the packages loaded when
+	// ready.
+	Results *int `json:"results,omitempty"`
+	// Index is the 1-based position of the selected result, as --select
+	// takes it.
+	Index   int    `json:"index,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Version string `json:"version,omitempty"`
+	Action  string `json:"action,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// eventStream writes session events as NDJSON for wrappers and tests. Like
+// telemetry it is shared by pointer, and a nil stream drops everything.
+type eventStream struct {
+	mu sync.Mutex
+	w  io.WriteCloser
+
+	lastQuery     *string
+	lastSelection string
+}
+
+// openEventStream opens the stream requested on the command line: an
+// inherited file descriptor, or a file (or named pipe) at path.
+func openEventStream(fd int, path string) (*eventStream, error) {
+	switch {
+	case fd > 0 && path != "":
+		return nil, fmt.Errorf("--events-fd and --events-file are mutually exclusive")
+	case fd > 0:
+		f := os.NewFile(uintptr(fd), "events")
+		if _, err := f.Stat(); err != nil {
+			return nil, fmt.Errorf("failed to open event descriptor %d: %w", fd, err)
+		}
+		return &eventStream{w: f}, nil
+	case path != "":
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open event file: %w", err)
+		}
+		return &eventStream{w: f}, nil
+	}
+	return nil, nil
+}
+
+// emit writes e. The first failed write closes the stream rather than
+// interrupting the session over a reader that went away.
+func (s *eventStream) emit(e event) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.w == nil {
+		return
+	}
+	e.Time = time.Now()
+	line, _ := json.Marshal(e)
+	if _, err := s.w.Write(append(line, '\n')); err != nil {
+		s.w.Close()
+		s.w = nil
+	}
+}
+
+// observe emits query and select events for whatever changed in m since the
+// last call.
+func (s *eventStream) observe(m model) {
+	if s == nil || m.state == stateLoading {
+		return
+	}
+	if s.lastQuery == nil || *s.lastQuery != m.input.query {
+		query := m.input.query
+		s.lastQuery = &query
+		results := len(m.list.matches)
+		s.emit(event{Type: "query", Query: query, Results: &results})
+	}
+	selection := ""
+	pkg, ok := m.selectedPackage()
+	if ok {
+		selection = pkg.Path + "@" + pkg.Version
+	}
+	if selection != s.lastSelection {
+		s.lastSelection = selection
+		if ok {
+			s.emit(event{Type: "select", Index: m.list.selectedIndex + 1, Path: pkg.Path, Version: m.packageVersion(pkg)})
+		}
+	}
+}
+
+// close emits the exit event and closes the stream.
+func (s *eventStream) close(message string) {
+	if s == nil {
+		return
+	}
+	s.emit(event{Type: "exit", Message: message})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.w != nil {
+		s.w.Close()
+		s.w = nil
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbletea"
+
+	"gosearch/index"
+	"gosearch/search"
+)
+
+// favoritesQuery is the filter that keeps only the starred packages.
+const favoritesQuery = "is:fav"
+
+// moduleList holds module paths the user picked out, the favorites or the
+// watchlist, kept in a JSON file next to the config. Like the annotations,
+// it is shared with search filters running in the background.
+type moduleList struct {
+	path string
+	// what names the list in errors.
+	what string
+
+	mu    sync.RWMutex
+	paths map[string]bool
+	// saveMu keeps saves in the order they were made.
+	saveMu sync.Mutex
+}
+
+func favoritesPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "gosearch", "favorites.json"), nil
+}
+
+// loadFavorites reads the favorites file at path, a JSON list of module
+// paths. A missing file is an empty list.
+func loadFavorites(path string) (*moduleList, error) {
+	return loadModuleList(path, "favorites")
+}
+
+func loadModuleList(path, what string) (*moduleList, error) {
+	s := &moduleList{path: path, what: what, paths: make(map[string]bool)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", what, err)
+	}
+	var paths []string
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return nil, &ParseError{What: what + " file", Path: path, Err: err}
+	}
+	for _, p := range paths {
+		s.paths[p] = true
+	}
+	return s, nil
+}
+
+func (s *moduleList) has(path string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.paths[path]
+}
+
+// toggle stars path, or unstars it if it was starred, and reports whether it
+// is starred now.
+func (s *moduleList) toggle(path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.paths[path] {
+		delete(s.paths, path)
+		return false
+	}
+	s.paths[path] = true
+	return true
+}
+
+// filter implements the is:fav query filter.
+func (s *moduleList) filter(p index.Package, value string) bool {
+	return strings.EqualFold(value, "fav") && s.has(p.Path)
+}
+
+// save writes the list to its file, sorted, replacing it atomically.
+func (s *moduleList) save() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	s.mu.RLock()
+	paths := make([]string, 0, len(s.paths))
+	for p := range s.paths {
+		paths = append(paths, p)
+	}
+	s.mu.RUnlock()
+	slices.Sort(paths)
+	data, err := json.MarshalIndent(paths, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", s.what, err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	f, err := os.CreateTemp(dir, "."+s.what+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to save %s: %w", s.what, err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to save %s: %w", s.what, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to save %s: %w", s.what, err)
+	}
+	return os.Rename(f.Name(), s.path)
+}
+
+func saveModuleListCmd(s *moduleList) tea.Cmd {
+	return func() tea.Msg {
+		if err := s.save(); err != nil {
+			return errMsg(err)
+		}
+		return nil
+	}
+}
+
+// toggleFavorite stars or unstars pkg and saves the favorites in the
+// background.
+func (m *model) toggleFavorite(pkg Package) tea.Cmd {
+	starred := m.favorites.toggle(pkg.Path)
+	m.filterPackages()
+	m.selectPath(pkg.Path)
+	msg := fmt.Sprintf("Starred %s.", pkg.Path)
+	if !starred {
+		msg = fmt.Sprintf("Unstarred %s.", pkg.Path)
+	}
+	return tea.Batch(saveModuleListCmd(m.favorites), m.flashMessage(msg, false))
+}
+
+// toggleFavoritesOnly adds the is:fav filter to the query, or takes it out.
+func (m *model) toggleFavoritesOnly() {
+	words := strings.Fields(m.input.query)
+	if i := slices.Index(words, favoritesQuery); i >= 0 {
+		words = slices.Delete(words, i, i+1)
+	} else {
+		words = append([]string{favoritesQuery}, words...)
+	}
+	m.input.query = strings.Join(words, " ")
+	m.filterPackages()
+}
+
+// floatFavorites moves the starred matches to the top, keeping the order of
+// both the starred and the other ones.
+func (m model) floatFavorites(matches []search.Match) []search.Match {
+	slices.SortStableFunc(matches, func(a, b search.Match) int {
+		switch fa, fb := m.favorites.has(a.Package.Path), m.favorites.has(b.Package.Path); {
+		case fa && !fb:
+			return -1
+		case fb && !fa:
+			return 1
+		}
+		return 0
+	})
+	return matches
+}
+
+// favoriteBadge marks the starred results, unless the icons show a star for
+// them.
+func (m model) favoriteBadge(pkg Package) string {
+	if m.config.Icons || !m.favorites.has(pkg.Path) {
+		return ""
+	}
+	return warningStyle.Render("♥") + " "
+}
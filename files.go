@@ -0,0 +1,365 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+
+	"gosearch/search"
+)
+
+// maxViewedFileSize caps how much of a file the viewer reads.
+const maxViewedFileSize = 1 << 20
+
+// fileRow is a row of the file tree: a directory or a file, named by its
+// path relative to the module root.
+type fileRow struct {
+	name  string
+	dir   bool
+	depth int
+}
+
+// fileTree lays out files, sorted, as a tree with a row for each directory
+// before its contents. The contents of collapsed directories are left out.
+func fileTree(files []string, collapsed map[string]bool) []fileRow {
+	var rows []fileRow
+	emitted := make(map[string]bool)
+	hidden := func(name string) bool {
+		for d := path.Dir(name); d != "."; d = path.Dir(d) {
+			if collapsed[d] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, f := range files {
+		parts := strings.Split(f, "/")
+		for i := 1; i < len(parts); i++ {
+			dir := strings.Join(parts[:i], "/")
+			if !emitted[dir] {
+				emitted[dir] = true
+				if !hidden(dir) {
+					rows = append(rows, fileRow{name: dir, dir: true, depth: i - 1})
+				}
+			}
+		}
+		if !hidden(f) {
+			rows = append(rows, fileRow{name: f, depth: len(parts) - 1})
+		}
+	}
+	return rows
+}
+
+// fileBrowser is the sub-screen listing the files of a module zip, searched
+// by name, with a viewer for one file at a time.
+type fileBrowser struct {
+	path, version string
+	matcher       search.Matcher
+	input         searchInput
+	loading       bool
+	files         []string
+	err           error
+	collapsed     map[string]bool
+	// rows are the rows on display: the tree without a query and the
+	// matching files with one, highlighted at highlights.
+	rows       []fileRow
+	highlights map[string][]int
+	selected   int
+	height     int
+	// viewer, if set, shows a file over the list.
+	viewer *fileViewer
+}
+
+// fileViewer shows the contents of a file of a module zip.
+type fileViewer struct {
+	name    string
+	loading bool
+	lines   []string
+	binary  bool
+	size    int
+	err     error
+	offset  int
+	// at is a line to point out, counted from 1, or 0 for none.
+	at int
+}
+
+type moduleFilesMsg struct {
+	path, version string
+	files         []string
+	err           error
+}
+
+// moduleFileMsg carries a file read from a module zip, highlighted and
+// split into lines unless it is binary.
+type moduleFileMsg struct {
+	name   string
+	lines  []string
+	binary bool
+	size   int
+	err    error
+}
+
+func newFileBrowser(path, version string, matcher search.Matcher, height int) fileBrowser {
+	return fileBrowser{path: path, version: version, matcher: matcher, loading: true, collapsed: make(map[string]bool), height: height}
+}
+
+// listModuleFilesCmd lists the files in the zip of path@version, resolving
+// the latest version if none is given.
+func listModuleFilesCmd(path, version string) tea.Cmd {
+	return func() tea.Msg {
+		if version == "" {
+			info, err := fetchLatest(path)
+			if err != nil {
+				return moduleFilesMsg{path: path, err: fmt.Errorf("failed to look up '%s': %w", path, err)}
+			}
+			version = info.Version
+		}
+		z, err := openModuleZip(path, version)
+		if err != nil {
+			return moduleFilesMsg{path: path, version: version, err: err}
+		}
+		defer z.Close()
+		prefix := path + "@" + version + "/"
+		var files []string
+		for _, f := range z.File {
+			if name, ok := strings.CutPrefix(f.Name, prefix); ok && name != "" {
+				files = append(files, name)
+			}
+		}
+		slices.Sort(files)
+		return moduleFilesMsg{path: path, version: version, files: files}
+	}
+}
+
+// readModuleFileCmd reads the file name, relative to the module root, from
+// the zip of path@version.
+func readModuleFileCmd(path, version, name string) tea.Cmd {
+	return func() tea.Msg {
+		z, err := openModuleZip(path, version)
+		if err != nil {
+			return moduleFileMsg{name: name, err: err}
+		}
+		defer z.Close()
+		f, err := z.Open(path + "@" + version + "/" + name)
+		if err != nil {
+			return moduleFileMsg{name: name, err: fmt.Errorf("failed to open %s: %w", name, err)}
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return moduleFileMsg{name: name, err: fmt.Errorf("failed to open %s: %w", name, err)}
+		}
+		content, err := io.ReadAll(io.LimitReader(f, maxViewedFileSize))
+		if err != nil {
+			return moduleFileMsg{name: name, err: fmt.Errorf("failed to read %s: %w", name, err)}
+		}
+		msg := moduleFileMsg{name: name, size: int(info.Size())}
+		if bytes.IndexByte(content, 0) >= 0 {
+			msg.binary = true
+			return msg
+		}
+		text := strings.ReplaceAll(string(content), "\t", "    ")
+		msg.lines = highlightLines(name, strings.TrimSuffix(text, "\n"))
+		return msg
+	}
+}
+
+// filter lays out the rows for the query.
+func (b *fileBrowser) filter() {
+	b.selected = 0
+	b.highlights = nil
+	if b.input.query == "" {
+		b.rows = fileTree(b.files, b.collapsed)
+		return
+	}
+	results, err := b.matcher.Find(b.input.query, b.files)
+	b.rows = nil
+	if err != nil {
+		return
+	}
+	b.highlights = make(map[string][]int, len(results))
+	for _, r := range results {
+		name := b.files[r.Index]
+		b.rows = append(b.rows, fileRow{name: name})
+		b.highlights[name] = r.MatchedIndexes
+		if h, ok := b.matcher.(search.Highlighter); ok && len(r.MatchedIndexes) == 0 {
+			b.highlights[name] = h.Highlight(b.input.query, name)
+		}
+	}
+}
+
+func (b fileBrowser) Update(msg tea.Msg) (fileBrowser, tea.Cmd) {
+	switch msg := msg.(type) {
+	case moduleFilesMsg:
+		if msg.path == b.path {
+			b.loading = false
+			b.version, b.files, b.err = msg.version, msg.files, msg.err
+			b.filter()
+		}
+		return b, nil
+
+	case moduleFileMsg:
+		if b.viewer != nil && msg.name == b.viewer.name {
+			b.viewer.load(msg)
+		}
+		return b, nil
+
+	case tea.KeyMsg:
+		if b.viewer != nil {
+			if msg.String() == "esc" {
+				b.viewer = nil
+			} else {
+				b.viewer.scroll(msg, b.height)
+			}
+			return b, nil
+		}
+		switch msg.String() {
+		case "esc":
+			return b, closeOverlay
+		case "up":
+			if len(b.rows) > 0 {
+				b.selected = (b.selected - 1 + len(b.rows)) % len(b.rows)
+			}
+		case "down":
+			if len(b.rows) > 0 {
+				b.selected = (b.selected + 1) % len(b.rows)
+			}
+		case "pgup":
+			b.selected = max(b.selected-b.height, 0)
+		case "pgdown":
+			b.selected = max(min(b.selected+b.height, len(b.rows)-1), 0)
+		case "enter":
+			if len(b.rows) == 0 {
+				return b, nil
+			}
+			row := b.rows[b.selected]
+			if row.dir {
+				b.collapsed[row.name] = !b.collapsed[row.name]
+				b.rows = fileTree(b.files, b.collapsed)
+				return b, nil
+			}
+			b.viewer = &fileViewer{name: row.name, loading: true}
+			return b, readModuleFileCmd(b.path, b.version, row.name)
+		default:
+			query := b.input.query
+			b.input, _ = b.input.Update(msg)
+			if b.input.query != query {
+				b.filter()
+			}
+		}
+	}
+	return b, nil
+}
+
+// load fills the viewer with a file read from the zip.
+func (v *fileViewer) load(msg moduleFileMsg) {
+	v.loading = false
+	v.lines, v.binary, v.size, v.err = msg.lines, msg.binary, msg.size, msg.err
+}
+
+// scroll moves the viewer by the key pressed, height lines being visible.
+func (v *fileViewer) scroll(msg tea.KeyMsg, height int) {
+	last := max(len(v.lines)-height, 0)
+	switch msg.String() {
+	case "up", "k":
+		v.offset = max(v.offset-1, 0)
+	case "down", "j":
+		v.offset = min(v.offset+1, last)
+	case "pgup":
+		v.offset = max(v.offset-height, 0)
+	case "pgdown", " ":
+		v.offset = min(v.offset+height, last)
+	case "home", "g":
+		v.offset = 0
+	case "end", "G":
+		v.offset = last
+	}
+}
+
+func (v fileViewer) View(height int) string {
+	s := strings.Builder{}
+	switch {
+	case v.loading:
+		s.WriteString(statusMessageStyle.Render("Reading the file...") + "\n")
+	case v.err != nil:
+		s.WriteString(errorStyle.Render(v.err.Error()) + "\n")
+	case v.binary:
+		s.WriteString(statusMessageStyle.Render(fmt.Sprintf("Binary file, %d bytes.", v.size)) + "\n")
+	default:
+		end := min(v.offset+height, len(v.lines))
+		width := len(strconv.Itoa(len(v.lines)))
+		for i := v.offset; i < end; i++ {
+			gutter := versionStyle.Render(fmt.Sprintf("%*d │", width, i+1))
+			if i+1 == v.at {
+				gutter = warningStyle.Render(fmt.Sprintf("%*d ▶", width, i+1))
+			}
+			s.WriteString(gutter + " " + v.lines[i] + "\n")
+		}
+		status := fmt.Sprintf("Lines %d-%d of %d", v.offset+1, end, len(v.lines))
+		if v.size > maxViewedFileSize {
+			status += fmt.Sprintf(", truncated to the first %d of %d bytes", maxViewedFileSize, v.size)
+		}
+		s.WriteString("\n" + statusMessageStyle.Render(status) + "\n")
+	}
+	s.WriteString("\n")
+	s.WriteString(statusMessageStyle.Render("Use ↑↓ and PgUp/PgDn to scroll, Esc to go back to the list."))
+	return s.String()
+}
+
+func (b fileBrowser) View() string {
+	s := strings.Builder{}
+	if b.viewer != nil {
+		s.WriteString(inputStyle.Render(b.path+"@"+b.version+"/"+b.viewer.name) + "\n\n")
+		s.WriteString(b.viewer.View(b.height))
+		return s.String()
+	}
+
+	title := b.path
+	if b.version != "" {
+		title += "@" + b.version
+	}
+	s.WriteString(fmt.Sprintf("Files of %s\n\n", inputStyle.Render(title)))
+	s.WriteString(b.input.View() + "\n\n")
+
+	switch {
+	case b.loading:
+		s.WriteString(statusMessageStyle.Render("Downloading the module zip from proxy.golang.org...") + "\n")
+	case b.err != nil:
+		s.WriteString(errorStyle.Render(b.err.Error()) + "\n")
+	case len(b.rows) == 0:
+		s.WriteString(statusMessageStyle.Render("No files match.") + "\n")
+	default:
+		offset := max(b.selected-b.height+1, 0)
+		end := min(offset+b.height, len(b.rows))
+		for i := offset; i < end; i++ {
+			row := b.rows[i]
+			var line string
+			switch {
+			case b.input.query != "":
+				line = renderPath(row.name, b.highlights[row.name])
+			case row.dir && b.collapsed[row.name]:
+				line = strings.Repeat("  ", row.depth) + "▸ " + path.Base(row.name) + "/"
+			case row.dir:
+				line = strings.Repeat("  ", row.depth) + "▾ " + path.Base(row.name) + "/"
+			default:
+				line = strings.Repeat("  ", row.depth) + "  " + path.Base(row.name)
+			}
+			if i == b.selected {
+				s.WriteString(selectedItemStyle.Render(line) + "\n")
+			} else {
+				s.WriteString(itemStyle.Render(line) + "\n")
+			}
+		}
+		s.WriteString("\n" + statusMessageStyle.Render(fmt.Sprintf("%d files", len(b.files))) + "\n")
+	}
+
+	s.WriteString("\n")
+	s.WriteString(statusMessageStyle.Render("Type to search file names, use ↑↓ to navigate, Enter to view a file or fold a directory, Esc to go back."))
+	return s.String()
+}
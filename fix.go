@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"gosearch/search"
+)
+
+// runFix implements `gosearch fix`: it finds the imports that errors of the
+// go command, given as arguments or on stdin, say no module provides,
+// resolves the module of each through the proxy, and offers to go get it.
+// The prompt reads from stdin, so errors piped in need --yes to be fixed.
+func runFix(args []string, stdin io.Reader, w io.Writer) error {
+	flags := flag.NewFlagSet("fix", flag.ContinueOnError)
+	yes := flags.Bool("yes", false, "run go get without asking")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	text := strings.Join(flags.Args(), "\n")
+	interactive := text != ""
+	if !interactive {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read the errors: %w", err)
+		}
+		text = string(data)
+	}
+	var paths []string
+	for _, line := range strings.Split(text, "\n") {
+		if path, ok := missingImportPath(line); ok && !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no missing module errors found; usage: gosearch fix \"<go build output>\"")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	var policy *Policy
+	if cfg.Policy != "" {
+		if policy, err = loadPolicy(cfg.Policy); err != nil {
+			return err
+		}
+	}
+	answers := bufio.NewScanner(stdin)
+	// Undo goes back to before the first go get of the run.
+	backedUp := false
+
+	for _, path := range paths {
+		if first, _, _ := strings.Cut(path, "/"); !strings.Contains(first, ".") {
+			fmt.Fprintf(w, "%s: not a module path; search for candidates with gosearch 'pkg:%s'\n", path, search.PackageName(path))
+			continue
+		}
+		modPath, latest, err := resolveModule(path)
+		if err != nil {
+			fmt.Fprintf(w, "%s: %v; search for candidates with gosearch 'pkg:%s'\n", path, err, search.PackageName(path))
+			continue
+		}
+		target := modPath + "@" + latest.Version
+		if policy != nil {
+			reason, err := policy.check(modPath, latest.Version)
+			if err != nil {
+				return fmt.Errorf("failed to check %s against the policy: %w", target, err)
+			}
+			if reason != "" {
+				fmt.Fprintf(w, "%s: provided by %s, which the policy forbids: %s\n", path, target, reason)
+				continue
+			}
+		}
+
+		fmt.Fprintf(w, "%s: provided by %s\n", path, target)
+		if !*yes {
+			if !interactive {
+				fmt.Fprintf(w, "  go get %s\n", target)
+				continue
+			}
+			fmt.Fprintf(w, "Run go get %s? [Y/n] ", target)
+			if !answers.Scan() {
+				return nil
+			}
+			if answer := strings.ToLower(strings.TrimSpace(answers.Text())); answer != "" && answer != "y" && answer != "yes" {
+				continue
+			}
+		}
+
+		if dryRun != nil {
+			fmt.Fprintf(w, "Dry run: would run go get %s\n", target)
+			continue
+		}
+		if cfg.Audit {
+			logPath, err := auditLogPath()
+			if err != nil {
+				return err
+			}
+			if err := appendAuditEntry(logPath, newAuditEntry("get", modPath, latest.Version)); err != nil {
+				return err
+			}
+		}
+		if !backedUp {
+			if err := backupModFiles("", "gosearch fix"); err != nil {
+				return err
+			}
+			backedUp = true
+		}
+		cmd := command("go", "get", target)
+		cmd.Stdout, cmd.Stderr = w, w
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("go get %s failed: %w", target, err)
+		}
+	}
+	return nil
+}
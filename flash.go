@@ -0,0 +1,34 @@
+package main
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+// flashDuration is how long a flashed status message stays up.
+const flashDuration = 2 * time.Second
+
+type flashExpiredMsg struct {
+	id int
+}
+
+// flashMessage shows message under the results until it expires or another
+// one replaces it.
+func (m *model) flashMessage(message string, isErr bool) tea.Cmd {
+	m.flashID++
+	m.flash, m.flashErr = message, isErr
+	id := m.flashID
+	return tea.Tick(flashDuration, func(time.Time) tea.Msg { return flashExpiredMsg{id: id} })
+}
+
+// flashView renders the flashed message, if one is up.
+func (m model) flashView() string {
+	if m.flash == "" {
+		return ""
+	}
+	if m.flashErr {
+		return errorStyle.Render(m.flash)
+	}
+	return successMessageStyle.Render(m.flash)
+}
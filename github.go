@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+const githubAPIURL = "https://api.github.com/repos"
+
+// errGitHubRateLimited is returned once GitHub refuses more requests for
+// the hour.
+var errGitHubRateLimited = errors.New("GitHub API rate limit reached")
+
+// repoMeta is what GitHub tells about the repository of a module.
+type repoMeta struct {
+	Stars    int       `json:"stargazers_count"`
+	Archived bool      `json:"archived"`
+	PushedAt time.Time `json:"pushed_at"`
+}
+
+// githubRepo returns the owner/name of the GitHub repository of a module
+// path under github.com.
+func githubRepo(path string) (string, bool) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "github.com" || len(parts) < 3 {
+		return "", false
+	}
+	return parts[1] + "/" + parts[2], true
+}
+
+// githubToken is the token sent to the GitHub API, from GITHUB_TOKEN or the
+// gh CLI's GH_TOKEN. Without one GitHub allows 60 requests an hour.
+func githubToken() string {
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token
+	}
+	return os.Getenv("GH_TOKEN")
+}
+
+// fetchRepoMeta looks up the GitHub repository owner/name.
+func fetchRepoMeta(repo string) (repoMeta, error) {
+	var meta repoMeta
+	endpoint := githubAPIURL + "/" + repo
+	req, err := http.NewRequestWithContext(appCtx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return meta, fmt.Errorf("failed to create GitHub request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := githubToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return meta, &NetworkError{Op: "failed to query GitHub", URL: endpoint, Err: err}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0":
+		return meta, errGitHubRateLimited
+	default:
+		return meta, &NetworkError{Op: "received non-OK status from GitHub", URL: endpoint, Err: errors.New(resp.Status)}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+		return meta, &ParseError{What: "GitHub response", Err: err}
+	}
+	return meta, nil
+}
+
+type repoMetaMsg struct {
+	repo string
+	meta repoMeta
+	err  error
+}
+
+func fetchRepoMetaCmd(repo string) tea.Cmd {
+	return func() tea.Msg {
+		meta, err := fetchRepoMeta(repo)
+		return repoMetaMsg{repo: repo, meta: meta, err: err}
+	}
+}
+
+// checkVisibleRepos looks up the GitHub repositories of the visible rows,
+// once per repository and session, unless turned off or rate limited.
+func (m *model) checkVisibleRepos() tea.Cmd {
+	if !m.config.GitHubMetadata || m.githubLimited {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for _, item := range m.list.Visible() {
+		repo, ok := githubRepo(item.Package.Path)
+		if !ok {
+			continue
+		}
+		if _, seen := m.repos[repo]; seen {
+			continue
+		}
+		m.repos[repo] = nil
+		cmds = append(cmds, fetchRepoMetaCmd(repo))
+	}
+	return tea.Batch(cmds...)
+}
+
+// finishRepoMeta keeps what GitHub answered. Once rate limited, lookups stop
+// for the session.
+func (m *model) finishRepoMeta(msg repoMetaMsg) {
+	if errors.Is(msg.err, errGitHubRateLimited) {
+		m.githubLimited = true
+		hint := "set GITHUB_TOKEN to allow more"
+		if githubToken() != "" {
+			hint = "try again later"
+		}
+		m.notice = fmt.Sprintf("%s; stars and repository status are left out (%s, or turn them off with --no-github).", errGitHubRateLimited, hint)
+		return
+	}
+	if msg.err == nil {
+		m.repos[msg.repo] = &msg.meta
+		m.list.Invalidate()
+	}
+}
+
+// repoBadge shows the stars of the GitHub repository of pkg, whether it is
+// archived, and when it was last pushed to.
+func (m model) repoBadge(pkg Package) string {
+	repo, ok := githubRepo(pkg.Path)
+	if !ok {
+		return ""
+	}
+	meta := m.repos[repo]
+	if meta == nil {
+		return ""
+	}
+	badge := " " + versionStyle.Render("★"+formatCount(meta.Stars))
+	if meta.Archived {
+		badge += versionStyle.Foreground(warningStyle.GetForeground()).Render("[archived]")
+	}
+	if !meta.PushedAt.IsZero() {
+		badge += versionStyle.Render("pushed " + meta.PushedAt.Format("2006-01-02"))
+	}
+	return badge
+}
+
+// formatCount shortens large counts, like 12300 to 12.3k.
+func formatCount(n int) string {
+	switch {
+	case n >= 1e6:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1e3:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	}
+	return fmt.Sprint(n)
+}
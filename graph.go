@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+
+	"gosearch/search"
+)
+
+// moduleGraph is the requirement graph of a module as `go mod graph` prints
+// it. Nodes are "path@version", except the main module, which is its path.
+type moduleGraph struct {
+	root  string
+	edges map[string][]string
+}
+
+// loadModuleGraph runs go mod graph for the module in dir.
+func loadModuleGraph(dir string) (*moduleGraph, error) {
+	defer track()()
+	cmd := command("go", "mod", "graph")
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go mod graph failed: %w\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	g := &moduleGraph{edges: make(map[string][]string)}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		from, to, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+		if g.root == "" {
+			g.root = from
+		}
+		// The go and toolchain requirements are not modules to browse.
+		if path, _, _ := strings.Cut(to, "@"); path == "go" || path == "toolchain" {
+			continue
+		}
+		g.edges[from] = append(g.edges[from], to)
+	}
+	return g, nil
+}
+
+// graphLine is a row of the tree drawn from a module graph.
+type graphLine struct {
+	node string
+	// prefix draws the branches leading to the node and parent is the row
+	// of the module requiring it, or -1 for the root.
+	prefix string
+	parent int
+	// repeat is set on later occurrences of a node, whose requirements
+	// are only listed under the first one.
+	repeat bool
+}
+
+// tree flattens the graph into a tree walked depth first from the root. The
+// graph shares modules between many paths, so each node is expanded once.
+func (g *moduleGraph) tree() []graphLine {
+	var lines []graphLine
+	expanded := make(map[string]bool)
+	var walk func(node, prefix, indent string, parent int)
+	walk = func(node, prefix, indent string, parent int) {
+		row := len(lines)
+		lines = append(lines, graphLine{node: node, prefix: prefix, parent: parent, repeat: expanded[node]})
+		if expanded[node] {
+			return
+		}
+		expanded[node] = true
+		children := g.edges[node]
+		for i, child := range children {
+			if i == len(children)-1 {
+				walk(child, indent+"└── ", indent+"    ", row)
+			} else {
+				walk(child, indent+"├── ", indent+"│   ", row)
+			}
+		}
+	}
+	walk(g.root, "", "", -1)
+	return lines
+}
+
+// graphView is the sub-screen drawing the module graph of the current
+// project as a tree, filtered by a query.
+type graphView struct {
+	matcher search.Matcher
+	input   searchInput
+	loading bool
+	lines   []graphLine
+	err     error
+	// visible are the rows shown for the query, with the cursor on
+	// selected, and highlights the matched offsets of their nodes.
+	visible    []int
+	highlights map[int][]int
+	selected   int
+}
+
+type graphLoadedMsg struct {
+	graph *moduleGraph
+	err   error
+}
+
+// graphChosenMsg is sent when a module is picked in the graph view.
+type graphChosenMsg struct {
+	path string
+}
+
+func newGraphView(matcher search.Matcher) graphView {
+	return graphView{matcher: matcher, loading: true}
+}
+
+func loadModuleGraphCmd() tea.Cmd {
+	return func() tea.Msg {
+		cwd, _ := os.Getwd()
+		dir := findModuleRoot(cwd)
+		if dir == "" {
+			return graphLoadedMsg{err: fmt.Errorf("not inside a Go module")}
+		}
+		g, err := loadModuleGraph(dir)
+		return graphLoadedMsg{graph: g, err: err}
+	}
+}
+
+// filter shows the rows whose node matches the query, along with the rows
+// leading to them so that each keeps its place in the tree.
+func (v *graphView) filter() {
+	v.selected = 0
+	v.highlights = nil
+	if v.input.query == "" {
+		v.visible = make([]int, len(v.lines))
+		for i := range v.lines {
+			v.visible[i] = i
+		}
+		return
+	}
+
+	candidates := make([]string, len(v.lines))
+	for i, l := range v.lines {
+		candidates[i] = l.node
+	}
+	results, err := v.matcher.Find(v.input.query, candidates)
+	if err != nil {
+		v.visible = nil
+		return
+	}
+	shown := make([]bool, len(v.lines))
+	v.highlights = make(map[int][]int, len(results))
+	for _, r := range results {
+		v.highlights[r.Index] = r.MatchedIndexes
+		if h, ok := v.matcher.(search.Highlighter); ok && len(r.MatchedIndexes) == 0 {
+			v.highlights[r.Index] = h.Highlight(v.input.query, candidates[r.Index])
+		}
+		for row := r.Index; row >= 0 && !shown[row]; row = v.lines[row].parent {
+			shown[row] = true
+		}
+	}
+	v.visible = v.visible[:0]
+	for row, ok := range shown {
+		if ok {
+			v.visible = append(v.visible, row)
+		}
+	}
+}
+
+func (v graphView) Update(msg tea.Msg) (graphView, tea.Cmd) {
+	switch msg := msg.(type) {
+	case graphLoadedMsg:
+		v.loading = false
+		v.err = msg.err
+		if msg.graph != nil {
+			v.lines = msg.graph.tree()
+		}
+		v.filter()
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			return v, closeOverlay
+		case "up":
+			if len(v.visible) > 0 {
+				v.selected = (v.selected - 1 + len(v.visible)) % len(v.visible)
+			}
+		case "down":
+			if len(v.visible) > 0 {
+				v.selected = (v.selected + 1) % len(v.visible)
+			}
+		case "enter":
+			if len(v.visible) > 0 && v.visible[v.selected] > 0 {
+				path, _, _ := strings.Cut(v.lines[v.visible[v.selected]].node, "@")
+				chosen := graphChosenMsg{path: path}
+				return v, func() tea.Msg { return chosen }
+			}
+		default:
+			query := v.input.query
+			v.input, _ = v.input.Update(msg)
+			if v.input.query != query {
+				v.filter()
+			}
+		}
+	}
+	return v, nil
+}
+
+func (v graphView) View(pageSize int) string {
+	s := strings.Builder{}
+	s.WriteString("Module graph\n\n")
+	s.WriteString(v.input.View() + "\n\n")
+
+	switch {
+	case v.loading:
+		s.WriteString(statusMessageStyle.Render("Running go mod graph...") + "\n")
+	case v.err != nil:
+		s.WriteString(errorStyle.Render(v.err.Error()) + "\n")
+	case len(v.visible) == 0:
+		s.WriteString(statusMessageStyle.Render("No modules match.") + "\n")
+	default:
+		offset := max(v.selected-pageSize+1, 0)
+		end := min(offset+pageSize, len(v.visible))
+		for i := offset; i < end; i++ {
+			l := v.lines[v.visible[i]]
+			line := l.prefix + renderPath(l.node, v.highlights[v.visible[i]])
+			if l.repeat {
+				line += statusMessageStyle.Render(" (see above)")
+			}
+			if i == v.selected {
+				s.WriteString(selectedItemStyle.Render(line) + "\n")
+			} else {
+				s.WriteString(itemStyle.Render(line) + "\n")
+			}
+		}
+		modules := 0
+		for _, l := range v.lines {
+			if !l.repeat {
+				modules++
+			}
+		}
+		s.WriteString("\n" + statusMessageStyle.Render(fmt.Sprintf("%d/%d rows, %d modules", len(v.visible), len(v.lines), modules)) + "\n")
+	}
+
+	s.WriteString("\n")
+	s.WriteString(statusMessageStyle.Render("Type to filter, use ↑↓ to navigate, Enter to search for the module, Esc to go back."))
+	return s.String()
+}
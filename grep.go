@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+// maxGrepMatches caps the matches collected by a grep.
+const maxGrepMatches = 1000
+
+// grepMatch is a line of a module file matching a grep.
+type grepMatch struct {
+	file string
+	line int
+	text string
+}
+
+type moduleGrepMsg struct {
+	path, version string
+	pattern       string
+	matches       []grepMatch
+	// files is how many files were searched, and truncated is set if
+	// matches stopped at maxGrepMatches.
+	files     int
+	truncated bool
+	err       error
+}
+
+// compileGrepPattern compiles a grep pattern as a regular expression,
+// ignoring case unless it has upper-case letters.
+func compileGrepPattern(pattern string) (*regexp.Regexp, error) {
+	if !strings.ContainsFunc(pattern, unicode.IsUpper) {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return re, nil
+}
+
+// grepModuleCmd searches the text files in the zip of path@version for re,
+// resolving the latest version if none is given.
+func grepModuleCmd(path, version, pattern string, re *regexp.Regexp) tea.Cmd {
+	return func() tea.Msg {
+		msg := moduleGrepMsg{path: path, version: version, pattern: pattern}
+		if version == "" {
+			info, err := fetchLatest(path)
+			if err != nil {
+				msg.err = fmt.Errorf("failed to look up '%s': %w", path, err)
+				return msg
+			}
+			msg.version = info.Version
+		}
+		z, err := openModuleZip(path, msg.version)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		defer z.Close()
+
+		prefix := path + "@" + msg.version + "/"
+		for _, f := range z.File {
+			name, ok := strings.CutPrefix(f.Name, prefix)
+			if !ok || name == "" {
+				continue
+			}
+			r, err := f.Open()
+			if err != nil {
+				continue
+			}
+			content, err := io.ReadAll(r)
+			r.Close()
+			// Like grep, leave out files that look binary.
+			if err != nil || bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0 {
+				continue
+			}
+			msg.files++
+			scanner := bufio.NewScanner(bytes.NewReader(content))
+			scanner.Buffer(nil, len(content)+1)
+			for line := 1; scanner.Scan(); line++ {
+				if !re.Match(scanner.Bytes()) {
+					continue
+				}
+				if len(msg.matches) == maxGrepMatches {
+					msg.truncated = true
+					return msg
+				}
+				text := strings.TrimSpace(strings.ReplaceAll(scanner.Text(), "\t", "    "))
+				msg.matches = append(msg.matches, grepMatch{file: name, line: line, text: text})
+			}
+		}
+		return msg
+	}
+}
+
+// grepView is the sub-screen prompting for a pattern and listing where it
+// matches in the files of a module, with a viewer opened at a match.
+type grepView struct {
+	path, version string
+	input         searchInput
+	// editing is set while the pattern is typed; Enter starts the search.
+	editing   bool
+	searching bool
+	re        *regexp.Regexp
+	result    moduleGrepMsg
+	err       error
+	selected  int
+	height    int
+	// width is the terminal's, which match lines are cut to.
+	width  int
+	viewer *fileViewer
+}
+
+func newGrepView(path, version string, height, width int) grepView {
+	return grepView{path: path, version: version, editing: true, height: height, width: width}
+}
+
+func (g grepView) Update(msg tea.Msg) (grepView, tea.Cmd) {
+	switch msg := msg.(type) {
+	case moduleGrepMsg:
+		if msg.path == g.path && msg.pattern == g.input.query && g.searching {
+			g.searching = false
+			g.result, g.err = msg, msg.err
+			g.version = msg.version
+			g.selected = 0
+		}
+		return g, nil
+
+	case moduleFileMsg:
+		if g.viewer != nil && msg.name == g.viewer.name {
+			g.viewer.load(msg)
+			g.viewer.offset = max(min(g.viewer.at-g.height/2, len(g.viewer.lines)-g.height), 0)
+		}
+		return g, nil
+
+	case tea.KeyMsg:
+		if g.viewer != nil {
+			if msg.String() == "esc" {
+				g.viewer = nil
+			} else {
+				g.viewer.scroll(msg, g.height)
+			}
+			return g, nil
+		}
+		if g.editing {
+			switch msg.String() {
+			case "esc":
+				if g.re == nil {
+					return g, closeOverlay
+				}
+				g.editing = false
+			case "enter":
+				if g.input.query == "" {
+					return g, nil
+				}
+				re, err := compileGrepPattern(g.input.query)
+				if err != nil {
+					g.err = err
+					return g, nil
+				}
+				g.editing, g.searching, g.re, g.err = false, true, re, nil
+				return g, grepModuleCmd(g.path, g.version, g.input.query, re)
+			default:
+				g.input, _ = g.input.Update(msg)
+			}
+			return g, nil
+		}
+
+		matches := g.result.matches
+		switch msg.String() {
+		case "esc":
+			return g, closeOverlay
+		case "/":
+			g.editing = true
+		case "up", "k":
+			if len(matches) > 0 {
+				g.selected = (g.selected - 1 + len(matches)) % len(matches)
+			}
+		case "down", "j":
+			if len(matches) > 0 {
+				g.selected = (g.selected + 1) % len(matches)
+			}
+		case "pgup":
+			g.selected = max(g.selected-g.height, 0)
+		case "pgdown":
+			g.selected = max(min(g.selected+g.height, len(matches)-1), 0)
+		case "enter":
+			if !g.searching && len(matches) > 0 {
+				m := matches[g.selected]
+				g.viewer = &fileViewer{name: m.file, loading: true, at: m.line}
+				return g, readModuleFileCmd(g.path, g.version, m.file)
+			}
+		}
+	}
+	return g, nil
+}
+
+func (g grepView) View() string {
+	s := strings.Builder{}
+	if g.viewer != nil {
+		s.WriteString(inputStyle.Render(g.path+"@"+g.version+"/"+g.viewer.name) + "\n\n")
+		s.WriteString(g.viewer.View(g.height))
+		return s.String()
+	}
+
+	s.WriteString(fmt.Sprintf("Search the files of %s\n\n", inputStyle.Render(g.path)))
+	if g.editing {
+		s.WriteString(fmt.Sprintf("Pattern: %s%s\n\n", g.input.query, inputStyle.Render("|")))
+	} else {
+		s.WriteString(fmt.Sprintf("Pattern: %s\n\n", g.input.query))
+	}
+
+	r := g.result
+	switch {
+	case g.err != nil:
+		s.WriteString(errorStyle.Render(g.err.Error()) + "\n")
+	case g.searching:
+		s.WriteString(statusMessageStyle.Render("Downloading the module zip and searching it...") + "\n")
+	case g.editing:
+	case len(r.matches) == 0:
+		s.WriteString(statusMessageStyle.Render(fmt.Sprintf("No matches in %d files.", r.files)) + "\n")
+	default:
+		offset := max(g.selected-g.height+1, 0)
+		end := min(offset+g.height, len(r.matches))
+		for i := offset; i < end; i++ {
+			m := r.matches[i]
+			line := fmt.Sprintf("%s:%d: %s", m.file, m.line, m.text)
+			if g.width > 0 {
+				// Less the padding of the item styles.
+				line = clipWidth(line, g.width-2)
+			}
+			if i == g.selected {
+				s.WriteString(selectedItemStyle.Render(line) + "\n")
+			} else {
+				s.WriteString(itemStyle.Render(line) + "\n")
+			}
+		}
+		status := fmt.Sprintf("%d %s in %d files searched", len(r.matches), plural(len(r.matches), "match", "matches"), r.files)
+		if r.truncated {
+			status = fmt.Sprintf("First %d matches, %d files searched", maxGrepMatches, r.files)
+		}
+		s.WriteString("\n" + statusMessageStyle.Render(status) + "\n")
+	}
+
+	s.WriteString("\n")
+	if g.editing {
+		s.WriteString(statusMessageStyle.Render("A regular expression, matched ignoring case unless it has capitals. Enter to search, Esc to go back."))
+	} else {
+		s.WriteString(statusMessageStyle.Render("Use ↑↓ to navigate, Enter to open the file at the match, / to change the pattern, Esc to go back."))
+	}
+	return s.String()
+}
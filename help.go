@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+// helpOverlay lists the key bindings of the results screen.
+type helpOverlay struct {
+	keys keyMap
+}
+
+// helpKeys describes the commands of the results screen, by command name.
+var helpKeys = []struct {
+	commands []string
+	desc     string
+}{
+	{[]string{"up", "down"}, "Move the cursor"},
+	{[]string{"enter"}, "Run the configured Enter action"},
+	{[]string{"enter-cycle"}, "Cycle what Enter copies: path, path@version, go get, require, go install"},
+	{[]string{"get"}, "Run go get path@version in the current module"},
+	{[]string{"undo"}, "Restore go.mod and go.sum to before the last change"},
+	{[]string{"actions"}, "Open the actions menu"},
+	{[]string{"versions"}, "Pick a published version to copy path@version"},
+	{[]string{"vulns"}, "Look up known vulnerabilities of the version on osv.dev"},
+	{[]string{"star"}, "Star or unstar the module; starred ones come first"},
+	{[]string{"favorites"}, "Show only starred modules, or all of them again"},
+	{[]string{"preview"}, "Toggle the preview pane of the selected module"},
+	{[]string{"expand"}, "Show or hide the other versions of the module in the index"},
+	{[]string{"categories"}, "Browse modules by category"},
+	{[]string{"history"}, "Browse the modules picked before, by frecency"},
+	{[]string{"matcher"}, "Cycle the search mode"},
+	{[]string{"refresh"}, "Refresh the index"},
+	{[]string{"archive"}, "Also search entries kept on disk by memory_budget"},
+	{[]string{"graph"}, "Browse the module graph of the current module"},
+	{[]string{"upgrade"}, "Upgrade outdated dependencies of the current module"},
+	{[]string{"moved"}, "Search for the new path of a module that moved"},
+	{[]string{"stay-open"}, "Toggle staying open after copying"},
+	{[]string{"help"}, "Toggle this help"},
+	{[]string{"telemetry"}, "Toggle the performance overlay"},
+	{[]string{"quit"}, "Quit (Ctrl+C always does)"},
+}
+
+// Update closes the overlay on any key.
+func (h helpOverlay) Update(msg tea.Msg) (helpOverlay, tea.Cmd) {
+	if _, ok := msg.(tea.KeyMsg); ok {
+		return h, closeOverlay
+	}
+	return h, nil
+}
+
+func (h helpOverlay) View() string {
+	var s strings.Builder
+	s.WriteString("Key bindings\n\n")
+	for _, k := range helpKeys {
+		s.WriteString(itemStyle.Render(padWidth(h.keys.label(k.commands...), 12)+" "+k.desc) + "\n")
+	}
+	s.WriteString(itemStyle.Render(padWidth("Esc /", 12)+" Leave the query for the single-letter keys, or go back to it") + "\n")
+	s.WriteString("\n")
+	s.WriteString(statusMessageStyle.Render("Press any key to go back."))
+	return s.String()
+}
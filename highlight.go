@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// highlightLines renders text, the contents of the file name, with syntax
+// highlighting for a 256-color terminal, one string per line, or plain if
+// the terminal has no colors (or NO_COLOR is set). Each line is
+// formatted on its own so that it keeps its colors when the viewer starts
+// in the middle of a multi-line token such as a block comment.
+func highlightLines(name, text string) []string {
+	if lipgloss.ColorProfile() == termenv.Ascii {
+		return strings.Split(text, "\n")
+	}
+	lexer := lexers.Match(name)
+	if lexer == nil {
+		lexer = lexers.Analyse(text)
+	}
+	if lexer == nil {
+		return strings.Split(text, "\n")
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	style := styles.Get("github")
+	if lipgloss.HasDarkBackground() {
+		style = styles.Get("monokai")
+	}
+	formatter := formatters.Get("terminal256")
+
+	it, err := lexer.Tokenise(nil, text)
+	if err != nil {
+		return strings.Split(text, "\n")
+	}
+	var lines []string
+	var b strings.Builder
+	for _, tokens := range chroma.SplitTokensIntoLines(it.Tokens()) {
+		for i := range tokens {
+			tokens[i].Value = strings.TrimSuffix(tokens[i].Value, "\n")
+		}
+		b.Reset()
+		if err := formatter.Format(&b, style, chroma.Literator(tokens...)); err != nil {
+			return strings.Split(text, "\n")
+		}
+		lines = append(lines, b.String())
+	}
+	return lines
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+)
+
+// historyEntry is a picked module as the history browser lists it.
+type historyEntry struct {
+	path  string
+	count int
+	last  time.Time
+	score float64
+}
+
+// entries lists the picked modules, highest frecency first.
+func (u *usageHistory) entries() []historyEntry {
+	if u == nil {
+		return nil
+	}
+	entries := make([]historyEntry, 0, len(u.file.Packages))
+	for path, e := range u.file.Packages {
+		entries = append(entries, historyEntry{path: path, count: e.Count, last: e.Last, score: u.scores[path]})
+	}
+	slices.SortFunc(entries, func(a, b historyEntry) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), strings.Compare(a.path, b.path))
+	})
+	return entries
+}
+
+// picked describes how often and how lately the module was picked.
+func (e historyEntry) picked() string {
+	times := "once"
+	if e.count > 1 {
+		times = fmt.Sprintf("%d times", e.count)
+	}
+	if e.last.IsZero() {
+		return times + ", from the shell history"
+	}
+	return times + ", last " + formatAge(time.Since(e.last))
+}
+
+// historyBrowser is the sub-screen listing what was picked before, in the
+// order the frecency signal ranks it.
+type historyBrowser struct {
+	entries  []historyEntry
+	selected int
+	keys     keyMap
+}
+
+// historyChosenMsg is sent when a module is picked in the history browser.
+type historyChosenMsg struct {
+	path string
+}
+
+func (b historyBrowser) Update(msg tea.Msg) (historyBrowser, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return b, nil
+	}
+	if key.String() == "esc" {
+		return b, closeOverlay
+	}
+	switch b.keys.command(key) {
+	case "history":
+		return b, closeOverlay
+	case "up":
+		b.selected = (b.selected - 1 + len(b.entries)) % len(b.entries)
+	case "down":
+		b.selected = (b.selected + 1) % len(b.entries)
+	case "enter":
+		chosen := historyChosenMsg{path: b.entries[b.selected].path}
+		return b, func() tea.Msg { return chosen }
+	}
+	return b, nil
+}
+
+func (b historyBrowser) View(pageSize int) string {
+	s := strings.Builder{}
+	s.WriteString("Picked before\n\n")
+	width := 0
+	for _, e := range b.entries {
+		width = max(width, runewidth.StringWidth(e.path))
+	}
+	width = min(width, 60)
+
+	offset := 0
+	if b.selected >= pageSize {
+		offset = b.selected - pageSize + 1
+	}
+	end := min(offset+pageSize, len(b.entries))
+	for i := offset; i < end; i++ {
+		e := b.entries[i]
+		line := padWidth(clipWidth(e.path, width), width) + " " + versionStyle.Render(e.picked())
+		if i == b.selected {
+			s.WriteString(selectedItemStyle.Render(line))
+		} else {
+			s.WriteString(itemStyle.Render(line))
+		}
+		s.WriteString("\n")
+	}
+
+	s.WriteString("\n")
+	s.WriteString(statusMessageStyle.Render(joinHints(
+		b.keys.hint("to navigate", "up", "down"),
+		b.keys.hint("to search for the module", "enter"),
+		"Esc to go back")))
+	return s.String()
+}
+
+// openHistory opens the history browser, unless there is nothing in it.
+func (m *model) openHistory() tea.Cmd {
+	switch {
+	case !m.config.History:
+		return m.flashMessage("The history is turned off; set history: true in the config to keep it.", true)
+	case m.usage == nil:
+		return m.flashMessage("Picks in the tutorial are not kept.", false)
+	}
+	entries := m.usage.entries()
+	if len(entries) == 0 {
+		return m.flashMessage("Nothing was picked yet.", false)
+	}
+	if !m.setState(stateHistory) {
+		return nil
+	}
+	m.history = historyBrowser{entries: entries, keys: m.keys}
+	return nil
+}
+
+// runHistory lists the usage history by frecency, or deletes it with
+// --clear.
+func runHistory(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	clearHistory := fs.Bool("clear", false, "delete the history, including what import-history found")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	path, err := usagePath()
+	if err != nil {
+		return err
+	}
+	if *clearHistory {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete usage history: %w", err)
+		}
+		fmt.Fprintf(w, "Deleted the history in %s\n", path)
+		return nil
+	}
+
+	usage, err := loadUsage(path)
+	if err != nil {
+		return err
+	}
+	if usage.empty() {
+		fmt.Fprintln(w, "Nothing was picked yet.")
+		return nil
+	}
+	for _, e := range usage.entries() {
+		fmt.Fprintf(w, "%s\t%s\n", e.path, e.picked())
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// historyCommand is one command line found in a shell history, with the
+// time it ran if the history records it.
+type historyCommand struct {
+	line string
+	time time.Time
+}
+
+// shellHistories returns the history files of the shells gosearch knows
+// about that exist for the current user, $HISTFILE first.
+func shellHistories() []string {
+	home, _ := os.UserHomeDir()
+	candidates := []string{
+		os.Getenv("HISTFILE"),
+		filepath.Join(home, ".bash_history"),
+		filepath.Join(home, ".zsh_history"),
+		filepath.Join(home, ".zhistory"),
+		filepath.Join(home, ".local", "share", "fish", "fish_history"),
+	}
+	var files []string
+	seen := make(map[string]bool)
+	for _, path := range candidates {
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// readShellHistory parses a bash, zsh, or fish history file. Bash entries
+// carry a time only with HISTTIMEFORMAT set, as "#<unix time>" lines.
+func readShellHistory(r io.Reader) ([]historyCommand, error) {
+	var commands []historyCommand
+	var pending time.Time
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "#") && isUnixTime(line[1:]):
+			pending = parseUnixTime(line[1:])
+			continue
+		case strings.HasPrefix(line, ": "):
+			// zsh extended history: ": <start>:<elapsed>;<command>"
+			if meta, cmd, ok := strings.Cut(line[2:], ";"); ok {
+				start, _, _ := strings.Cut(meta, ":")
+				commands = append(commands, historyCommand{line: cmd, time: parseUnixTime(start)})
+				continue
+			}
+		case strings.HasPrefix(line, "- cmd: "):
+			commands = append(commands, historyCommand{line: strings.TrimPrefix(line, "- cmd: ")})
+			continue
+		case strings.HasPrefix(line, "  when: "):
+			if n := len(commands); n > 0 {
+				commands[n-1].time = parseUnixTime(strings.TrimPrefix(line, "  when: "))
+			}
+			continue
+		case strings.HasPrefix(line, "  paths:"), strings.HasPrefix(line, "    - "):
+			continue
+		}
+		commands = append(commands, historyCommand{line: line, time: pending})
+		pending = time.Time{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading shell history: %w", err)
+	}
+	return commands, nil
+}
+
+func isUnixTime(s string) bool {
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil && len(s) >= 9
+}
+
+func parseUnixTime(s string) time.Time {
+	sec, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || sec <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// goGetTargets returns the module or package paths a command line passes to
+// go get or go install, without their @version. Chained commands are split
+// on ;, &&, ||, and |; local paths and patterns are skipped.
+func goGetTargets(line string) []string {
+	var targets []string
+	for _, segment := range strings.FieldsFunc(line, func(r rune) bool { return r == ';' || r == '&' || r == '|' }) {
+		fields := strings.Fields(segment)
+		// Skip environment assignments like GOFLAGS=-mod=mod.
+		for len(fields) > 0 && strings.Contains(fields[0], "=") {
+			fields = fields[1:]
+		}
+		if len(fields) < 3 || fields[0] != "go" || (fields[1] != "get" && fields[1] != "install") {
+			continue
+		}
+		for _, arg := range fields[2:] {
+			arg = strings.Trim(arg, `"'`)
+			if strings.HasPrefix(arg, "-") {
+				continue
+			}
+			path, _, _ := strings.Cut(arg, "@")
+			path = strings.TrimSuffix(path, "/...")
+			first, _, _ := strings.Cut(path, "/")
+			if path == "" || strings.Contains(path, "...") || strings.HasPrefix(path, ".") || !strings.Contains(first, ".") {
+				continue
+			}
+			targets = append(targets, path)
+		}
+	}
+	return targets
+}
+
+// runImportHistory implements `gosearch import-history`: it seeds the usage
+// history behind the frecency ranking signal with the go get and go install
+// commands of the shell history. It runs once unless --again is given.
+func runImportHistory(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("import-history", flag.ContinueOnError)
+	again := fs.Bool("again", false, "import even if the history was imported before, counting old commands twice")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	files := fs.Args()
+	if len(files) == 0 {
+		files = shellHistories()
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no shell history found; pass the history files to read")
+	}
+
+	path, err := usagePath()
+	if err != nil {
+		return err
+	}
+	usage, err := loadUsage(path)
+	if err != nil {
+		return err
+	}
+	if !usage.file.Imported.IsZero() && !*again {
+		return fmt.Errorf("shell history was already imported on %s; pass --again to import it anyway", usage.file.Imported.Format(time.DateOnly))
+	}
+
+	imported := make(map[string]bool)
+	commands := 0
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return fmt.Errorf("failed to open shell history: %w", err)
+		}
+		history, err := readShellHistory(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		for _, c := range history {
+			targets := goGetTargets(c.line)
+			if len(targets) > 0 {
+				commands++
+			}
+			for _, target := range targets {
+				usage.record(target, c.time)
+				imported[target] = true
+			}
+		}
+	}
+	usage.file.Imported = time.Now()
+	if err := usage.save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Imported %d go get and go install commands for %d packages from %s\n", commands, len(imported), strings.Join(files, ", "))
+	return nil
+}
@@ -0,0 +1,158 @@
+package index
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheVersion is bumped whenever the layout of the cache files changes.
+const cacheVersion = 2
+
+// cacheHeader identifies the file a cache was built from. The cache is only
+// used while the file still has the same size and modification time.
+type cacheHeader struct {
+	Version int
+	Path    string
+	Size    int64
+	ModTime time.Time
+	// Count and Sum let a reader tell a damaged cache from a stale one.
+	Count int
+	Sum   [sha256.Size]byte
+}
+
+// packagesSum checksums the entries stored in a cache.
+func packagesSum(packages []Package) [sha256.Size]byte {
+	h := sha256.New()
+	var buf []byte
+	for _, p := range packages {
+		buf = append(buf[:0], p.Path...)
+		buf = append(buf, 0)
+		buf = append(buf, p.Version...)
+		buf = binary.AppendVarint(append(buf, 0), p.Timestamp.UnixNano())
+		h.Write(buf)
+	}
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// cachePath returns where the parsed entries of the file at path are cached
+// in dir.
+func cachePath(dir, path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".gob")
+}
+
+func newCacheHeader(path string) (cacheHeader, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return cacheHeader{}, err
+	}
+	fi, err := os.Stat(abs)
+	if err != nil {
+		return cacheHeader{}, err
+	}
+	return cacheHeader{Version: cacheVersion, Path: abs, Size: fi.Size(), ModTime: fi.ModTime()}, nil
+}
+
+// readCache returns the cached entries of the file at path, or false if there
+// is no cache for the file as it is now. A cache that exists but cannot be
+// read back intact is reported as an error.
+func readCache(dir, path string) ([]Package, bool, error) {
+	want, err := newCacheHeader(path)
+	if err != nil {
+		return nil, false, nil
+	}
+	return readCacheFile(dir, want)
+}
+
+// readCacheFile returns the entries of the cache in dir whose header matches
+// want, as readCache does.
+func readCacheFile(dir string, want cacheHeader) ([]Package, bool, error) {
+	f, err := os.Open(cachePath(dir, want.Path))
+	if err != nil {
+		return nil, false, nil
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(f)
+	var have cacheHeader
+	if err := dec.Decode(&have); err != nil {
+		return nil, false, fmt.Errorf("unreadable cache header: %w", err)
+	}
+	if have.Version != want.Version || have.Path != want.Path ||
+		have.Size != want.Size || !have.ModTime.Equal(want.ModTime) {
+		return nil, false, nil
+	}
+	var packages []Package
+	if err := dec.Decode(&packages); err != nil {
+		return nil, false, fmt.Errorf("unreadable cache entries: %w", err)
+	}
+	if len(packages) != have.Count || packagesSum(packages) != have.Sum {
+		return nil, false, fmt.Errorf("cache checksum mismatch")
+	}
+	return packages, true, nil
+}
+
+// quarantineCache moves the cache of the file at path aside as .corrupt so it
+// is not read again but can still be inspected.
+func quarantineCache(dir, path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return quarantineCacheFile(dir, abs)
+}
+
+// quarantineCacheFile moves the cache in dir kept under the header path key
+// aside, as quarantineCache does.
+func quarantineCacheFile(dir, key string) (string, error) {
+	cache := cachePath(dir, key)
+	if err := os.Rename(cache, cache+".corrupt"); err != nil {
+		return "", fmt.Errorf("failed to quarantine cache: %w", err)
+	}
+	return cache + ".corrupt", nil
+}
+
+// writeCache stores the parsed entries of the file at path in dir.
+func writeCache(dir, path string, packages []Package) error {
+	header, err := newCacheHeader(path)
+	if err != nil {
+		return err
+	}
+	return writeCacheFile(dir, header, packages)
+}
+
+// writeCacheFile stores packages in dir under header, which it completes
+// with their count and checksum.
+func writeCacheFile(dir string, header cacheHeader, packages []Package) error {
+	header.Count, header.Sum = len(packages), packagesSum(packages)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(dir, ".cache-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	enc := gob.NewEncoder(f)
+	if err := enc.Encode(header); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := enc.Encode(packages); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	return os.Rename(f.Name(), cachePath(dir, header.Path))
+}
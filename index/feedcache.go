@@ -0,0 +1,92 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CachedSource pages through a feed, keeping its entries in a cache in Dir
+// so that fetching the feed from the beginning again only asks it for the
+// entries published since the newest cached one.
+type CachedSource struct {
+	Source *HTTPSource
+	// Dir is where the cache is kept; if empty, nothing is cached and every
+	// fetch walks the whole feed.
+	Dir string
+	// Limit, if positive, caps how many new entries a fetch pages through;
+	// the cache lets the next one carry on from there.
+	Limit int
+	// Progress, if set, is called after every page of new entries.
+	Progress func(Progress)
+	// OnSyncFailed, if set, is told when new entries could not all be
+	// fetched and those at hand were returned on their own.
+	OnSyncFailed func(err error)
+	// OnCacheRecovered, if set, is told when a damaged cache was moved aside
+	// and the feed fetched afresh instead.
+	OnCacheRecovered func(err error)
+}
+
+// feedCacheHeader identifies the cache of the feed at url.
+func feedCacheHeader(url string) cacheHeader {
+	return cacheHeader{Version: cacheVersion, Path: "feed " + url}
+}
+
+// Fetch implements Source. Fetching from the beginning merges the new
+// entries into the cache, or fills it on the first run; later starting
+// points, as used by refreshes, go to the feed directly.
+func (s CachedSource) Fetch(ctx context.Context, since time.Time) ([]Package, error) {
+	if !since.IsZero() {
+		return s.Source.Fetch(ctx, since)
+	}
+	header := feedCacheHeader(s.Source.URL)
+	var cached []Package
+	if s.Dir != "" {
+		var err error
+		if cached, _, err = readCacheFile(s.Dir, header); err != nil {
+			if moved, qerr := quarantineCacheFile(s.Dir, header.Path); qerr == nil {
+				err = fmt.Errorf("%w; moved it to %s", err, moved)
+			}
+			if s.OnCacheRecovered != nil {
+				s.OnCacheRecovered(err)
+			}
+		}
+	}
+
+	newest, have := NewestEntries(cached)
+	packages := cached
+	syncer := Syncer{Source: s.Source, Have: have, Progress: s.Progress, Limit: s.Limit}
+	walkErr := syncer.Walk(ctx, newest, func(page []Package) error {
+		packages = append(packages, page...)
+		return nil
+	})
+	if len(packages) == 0 && walkErr != nil {
+		return nil, walkErr
+	}
+	if s.Dir != "" && len(packages) > len(cached) {
+		// The cache only saves time; the entries are fine without it.
+		_ = writeCacheFile(s.Dir, header, packages)
+	}
+	if walkErr != nil && s.OnSyncFailed != nil {
+		s.OnSyncFailed(fmt.Errorf("failed to fetch new entries: %w", walkErr))
+	}
+	return packages, nil
+}
+
+// NewestEntries returns the newest timestamp in packages and the keys of the
+// entries stamped with it.
+func NewestEntries(packages []Package) (time.Time, map[string]bool) {
+	var newest time.Time
+	for _, p := range packages {
+		if p.Timestamp.After(newest) {
+			newest = p.Timestamp
+		}
+	}
+	have := make(map[string]bool)
+	for _, p := range packages {
+		if p.Timestamp.Equal(newest) {
+			have[Key(p)] = true
+		}
+	}
+	return newest, have
+}
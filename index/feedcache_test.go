@@ -0,0 +1,87 @@
+package index_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gosearch/index"
+	"gosearch/index/indextest"
+)
+
+// recordingTransport notes the since parameter of every feed request.
+type recordingTransport struct {
+	since []string
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.since = append(rt.since, req.URL.Query().Get("since"))
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestCachedSourceFetchesOnlyNewEntries(t *testing.T) {
+	srv := indextest.NewServer(testFeed)
+	defer srv.Close()
+	rt := &recordingTransport{}
+	source := index.NewHTTPSource(indextest.URL(srv))
+	source.Client = &http.Client{Transport: rt}
+	cached := index.CachedSource{Source: source, Dir: t.TempDir()}
+
+	for range 2 {
+		got, err := cached.Fetch(context.Background(), time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(keys(got), keys(testFeed)) {
+			t.Fatalf("Fetch got %q, want %q", keys(got), keys(testFeed))
+		}
+	}
+	// The first fetch walks the feed until a page brings nothing new; the
+	// second only asks for what is newer than the cache.
+	newest := at(6).Format(time.RFC3339Nano)
+	if want := []string{"", newest, newest}; !slices.Equal(rt.since, want) {
+		t.Errorf("requested since %q, want %q", rt.since, want)
+	}
+}
+
+func TestCachedSourceQuarantinesDamagedCache(t *testing.T) {
+	srv := indextest.NewServer(testFeed)
+	defer srv.Close()
+	dir := t.TempDir()
+	var recovered error
+	cached := index.CachedSource{
+		Source:           index.NewHTTPSource(indextest.URL(srv)),
+		Dir:              dir,
+		OnCacheRecovered: func(err error) { recovered = err },
+	}
+	if _, err := cached.Fetch(context.Background(), time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	caches, _ := filepath.Glob(filepath.Join(dir, "*.gob"))
+	if len(caches) != 1 {
+		t.Fatalf("found caches %q, want one", caches)
+	}
+	if err := os.WriteFile(caches[0], []byte("damaged"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := cached.Fetch(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(keys(got), keys(testFeed)) {
+		t.Errorf("Fetch got %q, want %q", keys(got), keys(testFeed))
+	}
+	moved := caches[0] + ".corrupt"
+	if recovered == nil || !strings.Contains(recovered.Error(), moved) {
+		t.Errorf("recovered from %v, want it to name %s", recovered, moved)
+	}
+	if data, err := os.ReadFile(moved); err != nil || string(data) != "damaged" {
+		t.Errorf("quarantined cache holds %q, %v; want the damaged one", data, err)
+	}
+}
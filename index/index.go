@@ -0,0 +1,12 @@
+// Package index describes entries of the Go module index feed served by
+// index.golang.org.
+package index
+
+import "time"
+
+// Package represents a single Go package from the index.
+type Package struct {
+	Path      string    `json:"Path"`
+	Version   string    `json:"Version"`
+	Timestamp time.Time `json:"Timestamp"`
+}
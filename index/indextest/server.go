@@ -0,0 +1,66 @@
+// Package indextest provides a fake index.golang.org server for integration
+// tests of code that consumes the index feed.
+package indextest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"time"
+
+	"gosearch/index"
+)
+
+// PageSize is the default number of entries served per request, matching
+// index.golang.org.
+const PageSize = 2000
+
+// NewServer starts a server serving packages at /index with the same since
+// and limit query parameters as the real feed. The caller must Close it.
+func NewServer(packages []index.Package) *httptest.Server {
+	sorted := append([]index.Package(nil), packages...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/index", func(w http.ResponseWriter, r *http.Request) {
+		var since time.Time
+		if v := r.URL.Query().Get("since"); v != "" {
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				http.Error(w, "invalid since: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			since = t
+		}
+
+		limit := PageSize
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				http.Error(w, "invalid limit", http.StatusBadRequest)
+				return
+			}
+			limit = min(n, PageSize)
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		enc := json.NewEncoder(w)
+		for _, p := range index.Since(sorted, since) {
+			if limit == 0 {
+				break
+			}
+			enc.Encode(p)
+			limit--
+		}
+	})
+	return httptest.NewServer(mux)
+}
+
+// URL returns the feed URL of a server started by NewServer.
+func URL(srv *httptest.Server) string {
+	return srv.URL + "/index"
+}
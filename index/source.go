@@ -0,0 +1,273 @@
+package index
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultURL is the public Go module index feed.
+const DefaultURL = "https://index.golang.org/index"
+
+// Source provides index entries published at or after since. A zero since
+// requests the feed from the beginning.
+type Source interface {
+	Fetch(ctx context.Context, since time.Time) ([]Package, error)
+}
+
+// Streamer is implemented by sources that can hand over a response in chunks
+// of up to size entries as it is decoded, instead of all at once. Stream stops
+// at the first error returned by fn and returns it unchanged.
+type Streamer interface {
+	Stream(ctx context.Context, since time.Time, size int, fn func([]Package) error) error
+}
+
+// HTTPSource reads the index feed from index.golang.org or a compatible
+// server.
+type HTTPSource struct {
+	URL    string
+	Client *http.Client
+	// RateLimit, if positive, caps how many response bytes are read per
+	// second.
+	RateLimit int64
+
+	bytesRead atomic.Int64
+}
+
+// NewHTTPSource returns a source reading the feed at rawURL, or DefaultURL if
+// rawURL is empty.
+func NewHTTPSource(rawURL string) *HTTPSource {
+	if rawURL == "" {
+		rawURL = DefaultURL
+	}
+	return &HTTPSource{URL: rawURL, Client: http.DefaultClient}
+}
+
+// Fetch implements Source.
+func (s *HTTPSource) Fetch(ctx context.Context, since time.Time) ([]Package, error) {
+	var packages []Package
+	err := s.Stream(ctx, since, 4096, func(chunk []Package) error {
+		packages = append(packages, chunk...)
+		return nil
+	})
+	return packages, err
+}
+
+// Stream implements Streamer. Entries are decoded straight off the response
+// body, so only one chunk is held at a time.
+func (s *HTTPSource) Stream(ctx context.Context, since time.Time, size int, fn func([]Package) error) error {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return fmt.Errorf("invalid index URL '%s': %w", s.URL, err)
+	}
+	if !since.IsZero() {
+		q := u.Query()
+		q.Set("since", since.Format(time.RFC3339Nano))
+		u.RawQuery = q.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to build Go index request: %w", err)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch Go index: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("received non-OK status from Go index: %s", resp.Status)
+	}
+
+	var fnErr error
+	var body io.Reader = &countingReader{r: resp.Body, n: &s.bytesRead}
+	if s.RateLimit > 0 {
+		body = &throttledReader{r: body, rate: s.RateLimit, start: time.Now()}
+	}
+	err = Scan(body, size, func(chunk []Package) error {
+		fnErr = fn(chunk)
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return fmt.Errorf("error reading Go index response: %w", err)
+	}
+	return nil
+}
+
+// BytesRead reports the total number of response bytes read by Fetch.
+func (s *HTTPSource) BytesRead() int64 {
+	return s.bytesRead.Load()
+}
+
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// throttledReader reads from r at no more than rate bytes per second on
+// average, sleeping as needed.
+type throttledReader struct {
+	r     io.Reader
+	rate  int64
+	start time.Time
+	read  int64
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	// Small reads keep the pace even rather than bursting a second's worth.
+	if limit := max(t.rate/10, 1); int64(len(p)) > limit {
+		p = p[:limit]
+	}
+	n, err := t.r.Read(p)
+	t.read += int64(n)
+	due := time.Duration(float64(t.read) / float64(t.rate) * float64(time.Second))
+	if wait := due - time.Since(t.start); wait > 0 {
+		time.Sleep(wait)
+	}
+	return n, err
+}
+
+// FileSource reads newline-delimited index entries from a local file, such
+// as a saved dump of the feed.
+type FileSource struct {
+	Path string
+	// CacheDir, if set, keeps a binary copy of the parsed entries so that
+	// later reads of the unchanged file skip JSON decoding.
+	CacheDir string
+	// OnCacheRecovered, if set, is told when a damaged cache was moved aside
+	// and the file parsed afresh instead.
+	OnCacheRecovered func(err error)
+}
+
+// Fetch implements Source.
+func (s FileSource) Fetch(ctx context.Context, since time.Time) ([]Package, error) {
+	if s.CacheDir != "" {
+		packages, ok, err := readCache(s.CacheDir, s.Path)
+		if ok {
+			return Since(packages, since), nil
+		}
+		if err != nil {
+			if moved, qerr := quarantineCache(s.CacheDir, s.Path); qerr == nil {
+				err = fmt.Errorf("%w; moved it to %s", err, moved)
+			}
+			if s.OnCacheRecovered != nil {
+				s.OnCacheRecovered(err)
+			}
+		}
+	}
+
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index file: %w", err)
+	}
+	defer f.Close()
+
+	packages, err := Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading index file %s: %w", s.Path, err)
+	}
+	if s.CacheDir != "" {
+		// The cache only saves time; the entries are fine without it.
+		_ = writeCache(s.CacheDir, s.Path, packages)
+	}
+	return Since(packages, since), nil
+}
+
+// MemorySource serves a fixed set of entries, or Err if it is set. It is
+// meant for tests and for embedding gosearch.
+type MemorySource struct {
+	Packages []Package
+	Err      error
+}
+
+// Fetch implements Source.
+func (s MemorySource) Fetch(ctx context.Context, since time.Time) ([]Package, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return Since(s.Packages, since), nil
+}
+
+// Decode parses newline-delimited JSON index entries. Malformed lines are
+// logged and skipped.
+func Decode(r io.Reader) ([]Package, error) {
+	var packages []Package
+	err := Scan(r, 4096, func(chunk []Package) error {
+		packages = append(packages, chunk...)
+		return nil
+	})
+	return packages, err
+}
+
+// Scan parses newline-delimited JSON index entries like Decode, but hands
+// them to fn in chunks of up to size entries instead of collecting them, so a
+// large file can be searched without holding it in memory. Scanning stops at
+// the first error returned by fn.
+func Scan(r io.Reader, size int, fn func([]Package) error) error {
+	chunk := make([]Package, 0, size)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var pkg Package
+		if err := json.Unmarshal(line, &pkg); err != nil {
+			log.Printf("Error unmarshalling package line: %v, line: %s", err, string(line))
+			continue
+		}
+		chunk = append(chunk, pkg)
+		if len(chunk) == size {
+			if err := fn(chunk); err != nil {
+				return err
+			}
+			chunk = make([]Package, 0, size)
+		}
+	}
+
+	if len(chunk) > 0 {
+		if err := fn(chunk); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// Since returns the entries published at or after since.
+func Since(packages []Package, since time.Time) []Package {
+	if since.IsZero() {
+		return packages
+	}
+	var out []Package
+	for _, p := range packages {
+		if !p.Timestamp.Before(since) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,199 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Progress describes how far a Syncer has come.
+type Progress struct {
+	Pages   int
+	Entries int
+	// Bytes is the number of response bytes read, if the source reports it.
+	Bytes int64
+	// Through is the timestamp of the newest entry received so far.
+	Through time.Time
+	Elapsed time.Duration
+	// ETA estimates the remaining time from how much of the span between
+	// the start of the walk and now has been covered. Zero means unknown.
+	ETA time.Duration
+}
+
+// byteCounter is implemented by sources that can report bytes read.
+type byteCounter interface {
+	BytesRead() int64
+}
+
+// Syncer pages through a feed.
+type Syncer struct {
+	Source Source
+	// Progress, if set, is called after every page, or every chunk of a
+	// streamed page.
+	Progress func(Progress)
+	// Have holds the keys (see Key) of entries stamped exactly with the
+	// starting since that the caller already has, e.g. when resuming. They
+	// are skipped like repeats within the walk.
+	Have map[string]bool
+	// Until, if set, stops the walk before entries published after it.
+	Until time.Time
+	// Limit, if positive, stops the walk once it has passed on that many
+	// entries.
+	Limit int
+}
+
+// Key identifies an index entry by module path and version.
+func Key(p Package) string {
+	return p.Path + "@" + p.Version
+}
+
+// dedupeWindow is how many of the most recent entries a walk remembers to
+// drop repeats. The feed repeats entries around page boundaries, so it only
+// needs to span well over one page.
+const dedupeWindow = 8192
+
+// rollingSet remembers the last size keys added to it.
+type rollingSet struct {
+	keys  map[string]bool
+	order []string
+	next  int
+}
+
+func newRollingSet(size int) *rollingSet {
+	return &rollingSet{keys: make(map[string]bool, size), order: make([]string, 0, size)}
+}
+
+// add records key and reports whether it was new, forgetting the oldest key
+// once the set is full.
+func (r *rollingSet) add(key string) bool {
+	if r.keys[key] {
+		return false
+	}
+	if len(r.order) < cap(r.order) {
+		r.order = append(r.order, key)
+	} else {
+		delete(r.keys, r.order[r.next])
+		r.order[r.next] = key
+		r.next = (r.next + 1) % len(r.order)
+	}
+	r.keys[key] = true
+	return true
+}
+
+// streamChunk is how many entries a Syncer hands to fn at a time when the
+// source can stream, which bounds memory use for large pages.
+const streamChunk = 500
+
+// errWalkDone stops a streamed page once Until has been passed.
+var errWalkDone = errors.New("walk done")
+
+// Walk pages through the feed starting at since, calling fn with every page
+// of entries until the feed is exhausted or fn returns an error. Sources
+// implementing Streamer have their pages passed on in chunks as they are
+// decoded; Progress is then reported after every chunk.
+//
+// The feed's since parameter is inclusive, so each request repeats the
+// entries stamped with the previous page's last timestamp. Repeated entries
+// are dropped before fn sees them, using a rolling set of the most recent
+// (path, version) pairs seeded with Have.
+func (s Syncer) Walk(ctx context.Context, since time.Time, fn func(page []Package) error) error {
+	started := time.Now()
+	counter, _ := s.Source.(byteCounter)
+	var startBytes int64
+	if counter != nil {
+		startBytes = counter.BytesRead()
+	}
+
+	var progress Progress
+	var first time.Time
+	cursor := since
+	seen := newRollingSet(dedupeWindow)
+	for key := range s.Have {
+		seen.add(key)
+	}
+	for {
+		last := cursor
+		fresh := 0
+		emit := func(chunk []Package) error {
+			kept := chunk[:0:0]
+			done := false
+			for _, p := range chunk {
+				if !s.Until.IsZero() && p.Timestamp.After(s.Until) ||
+					s.Limit > 0 && progress.Entries+len(kept) == s.Limit {
+					done = true
+					break
+				}
+				if !seen.add(Key(p)) {
+					continue
+				}
+				kept = append(kept, p)
+			}
+			if len(kept) > 0 {
+				if err := fn(kept); err != nil {
+					return err
+				}
+				if fresh == 0 {
+					progress.Pages++
+				}
+				fresh += len(kept)
+				if first.IsZero() {
+					first = kept[0].Timestamp
+				}
+				last = kept[len(kept)-1].Timestamp
+				s.report(&progress, started, first, last, counter, startBytes, len(kept))
+			}
+			if done {
+				return errWalkDone
+			}
+			return nil
+		}
+
+		var err error
+		if streamer, ok := s.Source.(Streamer); ok {
+			err = streamer.Stream(ctx, cursor, streamChunk, emit)
+		} else {
+			var page []Package
+			if page, err = s.Source.Fetch(ctx, cursor); err == nil {
+				err = emit(page)
+			}
+		}
+		if errors.Is(err, errWalkDone) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if fresh == 0 {
+			return nil
+		}
+		cursor = last
+	}
+}
+
+func (s Syncer) report(progress *Progress, started, first, last time.Time, counter byteCounter, startBytes int64, entries int) {
+	progress.Entries += entries
+	progress.Through = last
+	progress.Elapsed = time.Since(started)
+	if counter != nil {
+		progress.Bytes = counter.BytesRead() - startBytes
+	}
+	end := time.Now()
+	if !s.Until.IsZero() && s.Until.Before(end) {
+		end = s.Until
+	}
+	progress.ETA = estimateETA(first, last, end, progress.Elapsed)
+	if s.Progress != nil {
+		s.Progress(*progress)
+	}
+}
+
+// estimateETA extrapolates the remaining time until the walk reaches end,
+// assuming entries are spread evenly over the feed's timeline.
+func estimateETA(first, through, end time.Time, elapsed time.Duration) time.Duration {
+	covered := through.Sub(first)
+	remaining := end.Sub(through)
+	if covered <= 0 || remaining <= 0 {
+		return 0
+	}
+	return time.Duration(float64(elapsed) * float64(remaining) / float64(covered))
+}
@@ -0,0 +1,92 @@
+package index_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"gosearch/index"
+	"gosearch/index/indextest"
+)
+
+func at(minute int) time.Time {
+	return time.Date(2024, 3, 1, 12, minute, 0, 0, time.UTC)
+}
+
+// testFeed has two entries stamped alike, which a page boundary can split.
+var testFeed = []index.Package{
+	{Path: "github.com/gorilla/mux", Version: "v1.8.0", Timestamp: at(1)},
+	{Path: "github.com/spf13/cobra", Version: "v1.8.0", Timestamp: at(2)},
+	{Path: "gopkg.in/yaml.v3", Version: "v3.0.1", Timestamp: at(3)},
+	{Path: "github.com/gorilla/mux", Version: "v1.8.1", Timestamp: at(3)},
+	{Path: "golang.org/x/mod", Version: "v0.17.0", Timestamp: at(4)},
+	{Path: "github.com/spf13/cobra", Version: "v1.8.1", Timestamp: at(5)},
+	{Path: "golang.org/x/sync", Version: "v0.7.0", Timestamp: at(6)},
+}
+
+func keys(packages []index.Package) []string {
+	keys := make([]string, len(packages))
+	for i, p := range packages {
+		keys[i] = index.Key(p)
+	}
+	return keys
+}
+
+func walk(t *testing.T, s index.Syncer, since time.Time) []index.Package {
+	t.Helper()
+	var got []index.Package
+	err := s.Walk(context.Background(), since, func(page []index.Package) error {
+		got = append(got, page...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestWalkPagesThroughServer(t *testing.T) {
+	srv := indextest.NewServer(testFeed)
+	defer srv.Close()
+	source := index.NewHTTPSource(indextest.URL(srv) + "?limit=3")
+
+	var pages int
+	s := index.Syncer{Source: source, Progress: func(p index.Progress) { pages = p.Pages }}
+	got := walk(t, s, time.Time{})
+	if !slices.Equal(keys(got), keys(testFeed)) {
+		t.Errorf("Walk got %q, want %q", keys(got), keys(testFeed))
+	}
+	if pages != 3 {
+		t.Errorf("Walk reported %d pages, want 3", pages)
+	}
+}
+
+func TestWalkSkipsWhatIsHad(t *testing.T) {
+	source := index.MemorySource{Packages: testFeed}
+	s := index.Syncer{Source: source, Have: map[string]bool{index.Key(testFeed[2]): true}}
+	got := walk(t, s, at(3))
+	if want := keys(testFeed[3:]); !slices.Equal(keys(got), want) {
+		t.Errorf("Walk got %q, want %q", keys(got), want)
+	}
+}
+
+func TestWalkStops(t *testing.T) {
+	source := index.MemorySource{Packages: testFeed}
+	tests := []struct {
+		name   string
+		syncer index.Syncer
+		want   []index.Package
+	}{
+		{"limit", index.Syncer{Source: source, Limit: 2}, testFeed[:2]},
+		{"until", index.Syncer{Source: source, Until: at(4)}, testFeed[:5]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := walk(t, tt.syncer, time.Time{})
+			if !slices.Equal(keys(got), keys(tt.want)) {
+				t.Errorf("Walk got %q, want %q", keys(got), keys(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gosearch/sumdb"
+)
+
+// runInfo implements `gosearch info <module>`: it resolves a module through
+// the proxy and prints its metadata without starting the TUI.
+func runInfo(args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: gosearch info <module>")
+	}
+
+	modPath, latest, err := resolveModule(args[0])
+	if err != nil {
+		return err
+	}
+
+	versions, err := fetchVersions(modPath)
+	if err != nil {
+		return err
+	}
+
+	checksum := "unavailable"
+	if db, err := sumdb.New(sumDBConfig()); err == nil {
+		if hash, err := db.Hash(modPath, latest.Version); err == nil {
+			checksum = fmt.Sprintf("%s (verified against %s)", hash, db.Name())
+		} else if errors.Is(err, sumdb.ErrDisabled) || errors.Is(err, sumdb.ErrExcluded) {
+			checksum = "not checked (GOSUMDB/GONOSUMDB)"
+		}
+	}
+
+	license := "unknown"
+	if licenses, err := fetchLicenses(modPath, latest.Version); err == nil && len(licenses) > 0 {
+		license = strings.Join(licenses, ", ")
+	}
+
+	fmt.Fprintf(w, "Module:    %s\n", modPath)
+	fmt.Fprintf(w, "Latest:    %s\n", latest.Version)
+	if !latest.Time.IsZero() {
+		fmt.Fprintf(w, "Published: %s\n", latest.Time.Format("2006-01-02 15:04 MST"))
+	}
+	fmt.Fprintf(w, "License:   %s\n", license)
+	fmt.Fprintf(w, "Checksum:  %s\n", checksum)
+	fmt.Fprintf(w, "Versions:  %d\n", len(versions))
+	for _, v := range versions {
+		fmt.Fprintf(w, "  %s\n", v)
+	}
+	return nil
+}
+
+// sumDBConfig is the checksum database setup of the go command, with the
+// verified tree kept in the gosearch cache directory.
+func sumDBConfig() sumdb.Config {
+	cfg := sumdb.FromEnv()
+	if dir, err := cacheDir(); err == nil {
+		cfg.CacheDir = filepath.Join(dir, "sumdb")
+	}
+	return cfg
+}
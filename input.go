@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+// searchInput is the query line at the top of the results screen.
+type searchInput struct {
+	query string
+	// blurred is set after Esc, while letters run the commands bound to
+	// them instead of being typed.
+	blurred bool
+}
+
+// Update applies editing keys to the query.
+func (in searchInput) Update(msg tea.Msg) (searchInput, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return in, nil
+	}
+
+	switch key.String() {
+	case "backspace":
+		if len(in.query) > 0 {
+			in.query = in.query[:len(in.query)-1]
+		}
+	default:
+		if len(key.String()) == 1 {
+			in.query += key.String()
+		}
+	}
+	return in, nil
+}
+
+func (in searchInput) View() string {
+	if in.blurred {
+		return fmt.Sprintf("Search: %s", in.query)
+	}
+	return fmt.Sprintf("Search: %s%s", in.query, inputStyle.Render("|"))
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbletea"
+	"gopkg.in/yaml.v3"
+)
+
+type keyCommand struct {
+	name string
+	keys []string
+}
+
+// keyCommands are the commands of the results screen that the keys option
+// can rebind, in the order the help lists them, with their default keys.
+var keyCommands = []keyCommand{
+	{"up", []string{"up", "k"}},
+	{"down", []string{"down", "j"}},
+	{"enter", []string{"enter"}},
+	{"enter-cycle", []string{"ctrl+e"}},
+	{"get", []string{"ctrl+y"}},
+	{"undo", []string{"ctrl+z"}},
+	{"actions", []string{"a"}},
+	{"versions", []string{"v"}},
+	{"vulns", []string{"ctrl+v"}},
+	{"star", []string{"ctrl+s"}},
+	{"favorites", []string{"ctrl+f"}},
+	{"preview", []string{"tab"}},
+	{"expand", []string{"ctrl+x"}},
+	{"categories", []string{"ctrl+b"}},
+	{"history", []string{"ctrl+a"}},
+	{"matcher", []string{"ctrl+t"}},
+	{"refresh", []string{"ctrl+r", "f5"}},
+	{"archive", []string{"ctrl+o"}},
+	{"graph", []string{"ctrl+g"}},
+	{"upgrade", []string{"ctrl+u"}},
+	{"moved", []string{"ctrl+n"}},
+	{"stay-open", []string{"ctrl+k"}},
+	{"help", []string{"?"}},
+	{"telemetry", []string{"f2"}},
+	{"quit", []string{"q"}},
+}
+
+// keyList is one key, or a list of keys, in the config file.
+type keyList []string
+
+func (k *keyList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*k = keyList{value.Value}
+		return nil
+	}
+	return value.Decode((*[]string)(k))
+}
+
+// keyMap binds the keys of the results screen to commands.
+type keyMap struct {
+	commands map[string]string
+	keys     map[string][]string
+}
+
+// newKeyMap binds the keys of every command, as named by tea.KeyMsg.String,
+// taking those in overrides instead of the defaults.
+func newKeyMap(overrides map[string]keyList) (keyMap, error) {
+	km := keyMap{commands: make(map[string]string), keys: make(map[string][]string)}
+	for name := range overrides {
+		if !slices.ContainsFunc(keyCommands, func(c keyCommand) bool { return c.name == name }) {
+			return km, fmt.Errorf("unknown command '%s' in keys", name)
+		}
+	}
+	for _, c := range keyCommands {
+		keys := c.keys
+		if override, ok := overrides[c.name]; ok {
+			keys = override
+		}
+		for _, key := range keys {
+			if other, ok := km.commands[key]; ok {
+				return km, fmt.Errorf("key '%s' is bound to both %s and %s", key, other, c.name)
+			}
+			km.commands[key] = c.name
+		}
+		km.keys[c.name] = keys
+	}
+	return km, nil
+}
+
+// command returns the command msg is bound to, or "" if none.
+func (km keyMap) command(msg tea.KeyMsg) string {
+	return km.commands[msg.String()]
+}
+
+// label shows the keys bound to the commands for the help.
+func (km keyMap) label(commands ...string) string {
+	var labels []string
+	for _, c := range commands {
+		var keys []string
+		for _, key := range km.keys[c] {
+			keys = append(keys, keyLabel(key))
+		}
+		labels = append(labels, strings.Join(keys, "/"))
+	}
+	return strings.Join(labels, " ")
+}
+
+// keyLabel writes a key the way the help shows it, like Ctrl+B or ↑.
+func keyLabel(key string) string {
+	switch key {
+	case "up":
+		return "↑"
+	case "down":
+		return "↓"
+	case "enter", "esc", "tab", "space", "backspace":
+		return strings.ToUpper(key[:1]) + key[1:]
+	}
+	if len(key) > 1 && key[0] == 'f' && strings.Trim(key[1:], "0123456789") == "" {
+		return strings.ToUpper(key)
+	}
+	parts := strings.Split(key, "+")
+	if len(parts) == 1 {
+		return key
+	}
+	for i, part := range parts {
+		if len(part) == 1 {
+			parts[i] = strings.ToUpper(part)
+		} else if part != "" {
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return strings.Join(parts, "+")
+}
+
+// hint tells what the keys of commands do for a footer, like "Ctrl+T to
+// change mode", giving the first key of each command. It is "" if one of the
+// commands has no key.
+func (km keyMap) hint(desc string, commands ...string) string {
+	return km.hintKeys(false, desc, commands...)
+}
+
+// typingHint is hint for while the query has the focus, where keys that are
+// letters are typed instead of running their commands.
+func (km keyMap) typingHint(desc string, commands ...string) string {
+	return km.hintKeys(true, desc, commands...)
+}
+
+// letterKey reports whether key is a printable character, which the query
+// takes while it has the focus.
+func letterKey(key string) bool {
+	return utf8.RuneCountInString(key) == 1
+}
+
+func (km keyMap) hintKeys(typing bool, desc string, commands ...string) string {
+	var labels []string
+	short := true
+	for _, c := range commands {
+		i := slices.IndexFunc(km.keys[c], func(key string) bool { return !typing || !letterKey(key) })
+		if i < 0 {
+			return ""
+		}
+		label := keyLabel(km.keys[c][i])
+		short = short && utf8.RuneCountInString(label) == 1
+		labels = append(labels, label)
+	}
+	sep := "/"
+	if short {
+		sep = ""
+	}
+	return strings.Join(labels, sep) + " " + desc
+}
+
+// joinHints lists the footer hints that are not "", as a sentence.
+func joinHints(hints ...string) string {
+	hints = slices.DeleteFunc(hints, func(h string) bool { return h == "" })
+	if len(hints) == 0 {
+		return ""
+	}
+	return strings.Join(hints, ", ") + "."
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/bubbletea"
+	"golang.org/x/mod/module"
+)
+
+// licenseEntry is a module of a license report with the licenses deps.dev
+// detected for it.
+type licenseEntry struct {
+	Path     string   `json:"path"`
+	Version  string   `json:"version"`
+	Licenses []string `json:"licenses"`
+	// Copyleft is "strong" or "weak" for copyleft licenses.
+	Copyleft string `json:"copyleft,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// licenseReport lists the licenses of a module and everything it depends on,
+// the module itself first.
+type licenseReport struct {
+	Module  string         `json:"module"`
+	Version string         `json:"version"`
+	Entries []licenseEntry `json:"entries"`
+}
+
+// copyleftLicenses classifies SPDX identifiers by prefix. Longer prefixes
+// come first so LGPL is not taken for GPL.
+var copyleftLicenses = []struct{ prefix, kind string }{
+	{"AGPL-", "strong"},
+	{"LGPL-", "weak"},
+	{"GPL-", "strong"},
+	{"SSPL-", "strong"},
+	{"OSL-", "strong"},
+	{"EUPL-", "strong"},
+	{"MPL-", "weak"},
+	{"EPL-", "weak"},
+	{"CDDL-", "weak"},
+	{"CPL-", "weak"},
+	{"CC-BY-SA-", "weak"},
+}
+
+// copyleftKind classifies an SPDX license expression. Where the expression
+// offers a choice ("MIT OR GPL-2.0"), the least restrictive option counts;
+// where it combines licenses with AND, the most restrictive one does.
+func copyleftKind(expr string) string {
+	kinds := []string{"", "weak", "strong"}
+	tokens := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr))
+
+	// The grammar is or := and {OR and}, and := atom {AND atom}, and
+	// atom := "(" or ")" | id [WITH exception], each returning a rank.
+	var or func() int
+	atom := func() int {
+		if len(tokens) == 0 {
+			return 0
+		}
+		tok := tokens[0]
+		tokens = tokens[1:]
+		if tok == "(" {
+			r := or()
+			if len(tokens) > 0 && tokens[0] == ")" {
+				tokens = tokens[1:]
+			}
+			return r
+		}
+		if len(tokens) >= 2 && strings.EqualFold(tokens[0], "WITH") {
+			tokens = tokens[2:]
+		}
+		for _, c := range copyleftLicenses {
+			if strings.HasPrefix(tok, c.prefix) {
+				return slices.Index(kinds, c.kind)
+			}
+		}
+		return 0
+	}
+	and := func() int {
+		r := atom()
+		for len(tokens) > 0 && strings.EqualFold(tokens[0], "AND") {
+			tokens = tokens[1:]
+			r = max(r, atom())
+		}
+		return r
+	}
+	or = func() int {
+		r := and()
+		for len(tokens) > 0 && strings.EqualFold(tokens[0], "OR") {
+			tokens = tokens[1:]
+			r = min(r, and())
+		}
+		return r
+	}
+	return kinds[or()]
+}
+
+// buildLicenseReport resolves the dependency closure of path@version through
+// the proxy and looks up the license of each module on deps.dev. Modules
+// deps.dev cannot tell about are kept with the error.
+func buildLicenseReport(path, version string) (licenseReport, error) {
+	report := licenseReport{Module: path, Version: version}
+	deps, err := buildList(module.Version{Path: path, Version: version})
+	if err != nil {
+		return report, err
+	}
+	mods := append([]module.Version{{Path: path, Version: version}}, deps...)
+
+	report.Entries = make([]licenseEntry, len(mods))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, goModFetchers)
+	for i, mv := range mods {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			e := licenseEntry{Path: mv.Path, Version: mv.Version, Licenses: []string{}}
+			licenses, err := fetchLicenses(mv.Path, mv.Version)
+			if err != nil {
+				e.Error = err.Error()
+			} else if licenses != nil {
+				e.Licenses = licenses
+			}
+			for _, l := range e.Licenses {
+				if kind := copyleftKind(l); kind == "strong" || e.Copyleft == "" {
+					e.Copyleft = kind
+				}
+			}
+			report.Entries[i] = e
+		}()
+	}
+	wg.Wait()
+	return report, nil
+}
+
+// copyleftCount returns how many modules of r have a copyleft license.
+func (r licenseReport) copyleftCount() int {
+	n := 0
+	for _, e := range r.Entries {
+		if e.Copyleft != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// writeLicenseReport writes r as text, json, or csv.
+func writeLicenseReport(w io.Writer, r licenseReport, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"path", "version", "licenses", "copyleft", "error"})
+		for _, e := range r.Entries {
+			cw.Write([]string{e.Path, e.Version, strings.Join(e.Licenses, ";"), e.Copyleft, e.Error})
+		}
+		cw.Flush()
+		return cw.Error()
+	case "text":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, e := range r.Entries {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", e.Path+"@"+e.Version, licenseSummary(e), e.Copyleft)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w, "\n%d modules, %d with copyleft licenses\n", len(r.Entries), r.copyleftCount())
+		return err
+	}
+	return fmt.Errorf("unknown format '%s'; use text, json, or csv", format)
+}
+
+func licenseSummary(e licenseEntry) string {
+	switch {
+	case e.Error != "":
+		return "unknown (lookup failed)"
+	case len(e.Licenses) == 0:
+		return "unknown"
+	}
+	return strings.Join(e.Licenses, ", ")
+}
+
+// runLicenses implements `gosearch licenses <module>[@version]`.
+func runLicenses(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("licenses", flag.ContinueOnError)
+	format := fs.String("format", "text", "output `format`: text, json, or csv")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: gosearch licenses [--format text|json|csv] <module>[@version]")
+	}
+
+	path, version, _ := strings.Cut(fs.Arg(0), "@")
+	if version == "" || version == "latest" {
+		info, err := fetchLatest(path)
+		if err != nil {
+			return fmt.Errorf("failed to look up '%s': %w", path, err)
+		}
+		version = info.Version
+	}
+	report, err := buildLicenseReport(path, version)
+	if err != nil {
+		return err
+	}
+	return writeLicenseReport(w, report, *format)
+}
+
+// licenseView is the sub-screen showing the license report of a module.
+type licenseView struct {
+	path    string
+	version string
+	loading bool
+	report  licenseReport
+	err     error
+	// offset is the first entry on screen, of height rows.
+	offset int
+	height int
+	// exported names the file the report was last written to, and
+	// exportErr is why the last export failed.
+	exported  string
+	exportErr error
+}
+
+type licenseReportMsg struct {
+	path   string
+	report licenseReport
+	err    error
+}
+
+type licenseExportedMsg struct {
+	file string
+	err  error
+}
+
+func buildLicenseReportCmd(path, version string) tea.Cmd {
+	return func() tea.Msg {
+		report, err := buildLicenseReport(path, version)
+		return licenseReportMsg{path: path, report: report, err: err}
+	}
+}
+
+// exportLicenseReportCmd writes r in format to a file in the current
+// directory named after the module.
+func exportLicenseReportCmd(r licenseReport, format string) tea.Cmd {
+	return func() tea.Msg {
+		file := "licenses-" + strings.NewReplacer("/", "-", ".", "-").Replace(r.Module) + "." + format
+		f, err := os.Create(file)
+		if err != nil {
+			return licenseExportedMsg{err: fmt.Errorf("failed to create %s: %w", file, err)}
+		}
+		if err := writeLicenseReport(f, r, format); err != nil {
+			f.Close()
+			return licenseExportedMsg{err: fmt.Errorf("failed to write %s: %w", file, err)}
+		}
+		return licenseExportedMsg{file: file, err: f.Close()}
+	}
+}
+
+func (v licenseView) Update(msg tea.Msg) (licenseView, tea.Cmd) {
+	switch msg := msg.(type) {
+	case licenseReportMsg:
+		if msg.path == v.path {
+			v.loading = false
+			v.report, v.err = msg.report, msg.err
+		}
+
+	case licenseExportedMsg:
+		v.exported, v.exportErr = msg.file, msg.err
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			return v, closeOverlay
+		case "up", "k":
+			v.offset = max(v.offset-1, 0)
+		case "down", "j":
+			v.offset = max(min(v.offset+1, len(v.report.Entries)-v.height), 0)
+		case "J":
+			if !v.loading && v.err == nil {
+				return v, exportLicenseReportCmd(v.report, "json")
+			}
+		case "C":
+			if !v.loading && v.err == nil {
+				return v, exportLicenseReportCmd(v.report, "csv")
+			}
+		}
+	}
+	return v, nil
+}
+
+func (v licenseView) View() string {
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf("Licenses of %s and its dependencies\n\n", inputStyle.Render(v.path+"@"+v.version)))
+
+	switch {
+	case v.loading:
+		s.WriteString(statusMessageStyle.Render("Resolving the dependency graph through proxy.golang.org and looking up licenses...") + "\n")
+	case v.err != nil:
+		s.WriteString(errorStyle.Render(v.err.Error()) + "\n")
+	default:
+		end := min(v.offset+v.height, len(v.report.Entries))
+		for _, e := range v.report.Entries[v.offset:end] {
+			line := padWidth(e.Path+"@"+e.Version, 60) + " " + licenseSummary(e)
+			switch e.Copyleft {
+			case "strong":
+				s.WriteString(errorStyle.Render(line+" (copyleft)") + "\n")
+			case "weak":
+				s.WriteString(warningStyle.Render(line+" (weak copyleft)") + "\n")
+			default:
+				s.WriteString(itemStyle.Render(line) + "\n")
+			}
+		}
+		s.WriteString("\n" + statusMessageStyle.Render(fmt.Sprintf("%d modules, %d with copyleft licenses", len(v.report.Entries), v.report.copyleftCount())) + "\n")
+		if v.exportErr != nil {
+			s.WriteString(errorStyle.Render(v.exportErr.Error()) + "\n")
+		} else if v.exported != "" {
+			s.WriteString(successMessageStyle.Render("Saved to "+v.exported) + "\n")
+		}
+	}
+
+	s.WriteString("\n")
+	s.WriteString(statusMessageStyle.Render("Use ↑↓ to scroll, Shift+J or Shift+C to save as JSON or CSV, Esc to go back."))
+	return s.String()
+}
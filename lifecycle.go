@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// appCtx lives as long as gosearch runs. Requests, index fetches and
+// commands started for background work are bound to it, so that quitting
+// stops them instead of leaving them running.
+var appCtx, stopApp = context.WithCancel(context.Background())
+
+// running counts the commands still running, which shutdown waits for.
+var running sync.WaitGroup
+
+// shutdownGrace bounds how long shutdown waits for killed commands to exit.
+const shutdownGrace = 2 * time.Second
+
+// command is exec.Command bound to appCtx: it is killed on shutdown.
+func command(name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(appCtx, name, args...)
+	cmd.WaitDelay = shutdownGrace
+	return cmd
+}
+
+// track marks a command from command as running until the returned func is
+// called, so that gosearch does not exit before it is killed.
+func track() func() {
+	running.Add(1)
+	return running.Done
+}
+
+// shutdown cancels appCtx and waits, for a little while, for the commands
+// still running to exit.
+func shutdown() {
+	stopApp()
+	done := make(chan struct{})
+	go func() {
+		running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(shutdownGrace):
+	}
+}
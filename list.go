@@ -0,0 +1,141 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+
+	"gosearch/search"
+)
+
+// resultsList is the scrollable list of matches below the search input.
+type resultsList struct {
+	matches        []search.Match
+	selectedIndex  int
+	viewportOffset int
+	pageSize       int
+	// maxPageSize, if positive, caps pageSize whatever the terminal height.
+	maxPageSize int
+
+	// rows caches rendered rows between frames. It is keyed by position in
+	// matches and dropped whenever what a row shows may have changed.
+	rows map[rowKey]string
+}
+
+type rowKey struct {
+	pos      int
+	selected bool
+}
+
+// SetMatches replaces the listed matches, keeping the cursor in range.
+func (l *resultsList) SetMatches(matches []search.Match) {
+	l.matches = matches
+	l.rows = make(map[rowKey]string)
+	if l.selectedIndex >= len(l.matches) {
+		l.selectedIndex = len(l.matches) - 1
+	}
+	if l.selectedIndex < 0 && len(l.matches) > 0 {
+		l.selectedIndex = 0
+	}
+	l.updateViewportOffset()
+}
+
+// SetHeight sizes the list for a terminal of the given height.
+func (l *resultsList) SetHeight(height int) {
+	l.pageSize = max(height-10, 1)
+	if l.maxPageSize > 0 {
+		l.pageSize = min(l.pageSize, l.maxPageSize)
+	}
+	l.updateViewportOffset()
+}
+
+// Invalidate drops the rendered rows, for when their contents change without
+// the matches changing, such as a newly resolved version.
+func (l resultsList) Invalidate() {
+	clear(l.rows)
+}
+
+// Select moves the cursor to the match at i.
+func (l *resultsList) Select(i int) {
+	if i >= 0 && i < len(l.matches) {
+		l.selectedIndex = i
+		l.updateViewportOffset()
+	}
+}
+
+// Selected returns the match under the cursor, if any.
+func (l resultsList) Selected() (search.Match, bool) {
+	if l.selectedIndex >= 0 && l.selectedIndex < len(l.matches) {
+		return l.matches[l.selectedIndex], true
+	}
+	return search.Match{}, false
+}
+
+// Visible returns the matches currently on screen.
+func (l resultsList) Visible() []search.Match {
+	end := min(l.viewportOffset+l.pageSize, len(l.matches))
+	if l.viewportOffset >= end {
+		return nil
+	}
+	return l.matches[l.viewportOffset:end]
+}
+
+// Update moves the cursor.
+func (l resultsList) Update(msg tea.Msg) (resultsList, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok || len(l.matches) == 0 {
+		return l, nil
+	}
+
+	switch key.String() {
+	case "up", "k":
+		l.selectedIndex--
+		if l.selectedIndex < 0 {
+			l.selectedIndex = len(l.matches) - 1
+		}
+	case "down", "j":
+		l.selectedIndex++
+		if l.selectedIndex >= len(l.matches) {
+			l.selectedIndex = 0
+		}
+	}
+	l.updateViewportOffset()
+	return l, nil
+}
+
+func (l *resultsList) updateViewportOffset() {
+	if l.selectedIndex < l.viewportOffset {
+		l.viewportOffset = max(l.selectedIndex, 0)
+	} else if l.selectedIndex >= l.viewportOffset+l.pageSize {
+		l.viewportOffset = l.selectedIndex - l.pageSize + 1
+	}
+}
+
+// View renders the visible rows, formatting each one with renderRow. Rows
+// already rendered in an earlier frame are reused, so scrolling through a
+// large result set only pays for the rows that come into view.
+func (l resultsList) View(renderRow func(item search.Match) string) string {
+	// Keep the cache to a few screens' worth while scrolling far.
+	if len(l.rows) > 4*l.pageSize {
+		clear(l.rows)
+	}
+
+	var s strings.Builder
+	for i, item := range l.Visible() {
+		key := rowKey{pos: l.viewportOffset + i, selected: l.viewportOffset+i == l.selectedIndex}
+		line, ok := l.rows[key]
+		if !ok {
+			if key.selected {
+				line = selectedItemStyle.Render(renderRow(item))
+			} else {
+				line = itemStyle.Render(renderRow(item))
+			}
+			if l.rows != nil {
+				l.rows[key] = line
+			}
+		}
+		s.WriteString(line)
+		s.WriteString("\n")
+	}
+	return s.String()
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+
+	"gosearch/index"
+)
+
+// localCacheSource builds the index from the download cache of the go
+// command, for --local: every version of every module that has an .info
+// file on disk, without touching the network.
+type localCacheSource struct {
+	// dir is $GOMODCACHE/cache/download.
+	dir string
+}
+
+// newLocalCacheSource finds the download cache through `go env`, so the go
+// command's own config file is honored.
+func newLocalCacheSource() (localCacheSource, error) {
+	dir := os.Getenv("GOMODCACHE")
+	if out, err := command("go", "env", "GOMODCACHE").Output(); err == nil {
+		dir = strings.TrimSpace(string(out))
+	}
+	if dir == "" {
+		return localCacheSource{}, fmt.Errorf("failed to find the module cache: GOMODCACHE is not set and go env failed")
+	}
+	dir = filepath.Join(dir, "cache", "download")
+	if _, err := os.Stat(dir); err != nil {
+		return localCacheSource{}, fmt.Errorf("failed to open the module cache: %w", err)
+	}
+	return localCacheSource{dir: dir}, nil
+}
+
+func (s localCacheSource) Fetch(ctx context.Context, since time.Time) ([]Package, error) {
+	var packages []Package
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		// The checksum database tiles are cached next to the modules.
+		if path == filepath.Join(s.dir, "sumdb") {
+			return filepath.SkipDir
+		}
+		if d.Name() != "@v" {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		modPath, err := module.UnescapePath(filepath.ToSlash(rel))
+		if err != nil {
+			// Not something the go command wrote.
+			return filepath.SkipDir
+		}
+		packages = append(packages, readCachedVersions(path, modPath)...)
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the module cache: %w", err)
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Timestamp.Before(packages[j].Timestamp) })
+	return index.Since(packages, since), nil
+}
+
+// readCachedVersions lists the versions in the @v directory of a module.
+// Versions are dated by their .info file, or by when it was written if it
+// has no time.
+func readCachedVersions(dir, modPath string) []Package {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var packages []Package
+	for _, e := range entries {
+		escaped, ok := strings.CutSuffix(e.Name(), ".info")
+		if !ok || e.IsDir() {
+			continue
+		}
+		version, err := module.UnescapeVersion(escaped)
+		if err != nil {
+			continue
+		}
+		p := Package{Path: modPath, Version: version}
+		var info VersionInfo
+		if data, err := os.ReadFile(filepath.Join(dir, e.Name())); err == nil && json.Unmarshal(data, &info) == nil {
+			p.Timestamp = info.Time
+		}
+		if p.Timestamp.IsZero() {
+			if fi, err := e.Info(); err == nil {
+				p.Timestamp = fi.ModTime()
+			}
+		}
+		packages = append(packages, p)
+	}
+	return packages
+}
@@ -1,44 +1,23 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
-	"encoding/json"
+	"cmp"
+	"flag"
 	"fmt"
-	"io"
-	"log"
-	"net/http"
 	"os"
-	"os/exec"
-	"runtime"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/sahilm/fuzzy"
+
+	"gosearch/index"
+	"gosearch/search"
 )
 
 // Package represents a single Go package from the index.
-type Package struct {
-	Path      string    `json:"Path"`
-	Version   string    `json:"Version"`
-	Timestamp time.Time `json:"Timestamp"`
-}
-
-// Model represents the state of our terminal UI application.
-type model struct {
-	packages       []Package
-	filtered       []fuzzy.Match
-	searchQuery    string
-	selectedIndex  int
-	loading        bool
-	err            error
-	quitting       bool
-	viewportOffset int
-	pageSize       int
-	finalMessage   string
-}
+type Package = index.Package
 
 // Styles for the UI elements.
 var (
@@ -63,6 +42,10 @@ var (
 			Foreground(lipgloss.Color("#ff0000")).
 			Padding(0, 1)
 
+	warningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#ffaa00")).
+			Padding(0, 1)
+
 	successMessageStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.Color("#00ff00")).
 				Padding(0, 1).
@@ -74,284 +57,538 @@ var (
 			MarginLeft(1)                          // Small space from the path
 )
 
-func (m model) Init() tea.Cmd {
-	return fetchPackagesCmd()
+type packagesLoadedMsg struct {
+	packages []Package
+	syncedAt time.Time
+	archive  *archive
+	// notice reports something that went wrong but was recovered from.
+	notice string
 }
+type errMsg error
 
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
-			m.quitting = true
-			m.finalMessage = "Exiting Go Package Search CLI."
-			return m, tea.Quit
-
-		case "up", "k":
-			if len(m.filtered) > 0 {
-				m.selectedIndex--
-				if m.selectedIndex < 0 {
-					m.selectedIndex = len(m.filtered) - 1
-				}
-				m.updateViewportOffset()
-			}
+type latestResolvedMsg struct {
+	path    string
+	version string
+	err     error
+}
 
-		case "down", "j":
-			if len(m.filtered) > 0 {
-				m.selectedIndex++
-				if m.selectedIndex >= len(m.filtered) {
-					m.selectedIndex = 0
-				}
-				m.updateViewportOffset()
-			}
+func fetchLatestCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		info, err := fetchLatest(path)
+		return latestResolvedMsg{path: path, version: info.Version, err: err}
+	}
+}
 
-		case "enter":
-			if len(m.filtered) > 0 && m.selectedIndex >= 0 && m.selectedIndex < len(m.filtered) {
-				matchedPackage := m.filtered[m.selectedIndex]
-				if matchedPackage.Index >= 0 && matchedPackage.Index < len(m.packages) {
-					packagePath := m.packages[matchedPackage.Index].Path
-					m.quitting = true
-					m.finalMessage = fmt.Sprintf("'%s' copied to clipboard!", packagePath)
-
-					return m, tea.Sequence(
-						copyToClipboardCmd(packagePath),
-						tea.Quit,
-					)
-				}
-			}
+// feedSource pages through the index feed at index_url, up to max_entries at a time,
+// keeping it in the gosearch cache directory so that each run only fetches
+// what was published since the last one.
+func feedSource(cfg Config) index.CachedSource {
+	dir, _ := cacheDir()
+	return index.CachedSource{Source: index.NewHTTPSource(cfg.IndexURL), Dir: dir, Limit: cfg.MaxEntries}
+}
 
-		case "backspace":
-			if len(m.searchQuery) > 0 {
-				m.searchQuery = m.searchQuery[:len(m.searchQuery)-1]
-				m.filterPackages()
-			}
+// loadProgressMsg reports how far loading the index has come; wait
+// receives the next message of the load.
+type loadProgressMsg struct {
+	progress index.Progress
+	wait     tea.Cmd
+}
 
-		default:
-			if len(msg.String()) == 1 {
-				m.searchQuery += msg.String()
-				m.filterPackages()
+// fetchPackagesCmd loads the packages from source, reporting progress as
+// pages of the feed arrive.
+func fetchPackagesCmd(source index.Source, cfg Config, dumpPath string) tea.Cmd {
+	updates := make(chan tea.Msg, 1)
+	wait := func() tea.Msg { return <-updates }
+	go func() {
+		updates <- loadPackages(source, cfg, dumpPath, func(p index.Progress) {
+			// Progress is only shown, so an update the model has not
+			// picked up yet is simply dropped.
+			select {
+			case updates <- loadProgressMsg{progress: p, wait: wait}:
+			default:
 			}
-		}
+		})
+	}()
+	return wait
+}
 
-	case packagesLoadedMsg:
-		m.packages = msg
-		m.loading = false
-		m.filterPackages()
-		return m, nil
-
-	case errMsg:
-		m.err = msg
-		m.loading = false
-		m.quitting = true
-		m.finalMessage = fmt.Sprintf("Error: %v", msg)
-		return m, tea.Quit
-
-	case tea.WindowSizeMsg:
-		m.pageSize = msg.Height - 10
-		if m.pageSize < 1 {
-			m.pageSize = 1
+func loadPackages(source index.Source, cfg Config, dumpPath string, progress func(index.Progress)) tea.Msg {
+	syncedAt := clock()
+	var notice string
+	switch s := source.(type) {
+	case index.FileSource:
+		s.OnCacheRecovered = func(err error) {
+			notice = fmt.Sprintf("Ignored a damaged index cache (%v) and re-read %s.", err, s.Path)
+		}
+		source = s
+	case index.CachedSource:
+		s.Progress = progress
+		s.OnCacheRecovered = func(err error) {
+			notice = fmt.Sprintf("Ignored a damaged index cache (%v) and fetched the index afresh.", err)
+		}
+		s.OnSyncFailed = func(err error) {
+			notice = fmt.Sprintf("Loaded the index only in part: %v. Press Ctrl+R to fetch the rest.", err)
 		}
-		m.updateViewportOffset()
+		source = s
 	}
+	packages, err := source.Fetch(appCtx, time.Time{})
+	if err != nil {
+		return errMsg(err)
+	}
+	// A dump on disk is as old as the sync that wrote it.
+	if fs, ok := source.(index.FileSource); ok {
+		if fi, err := os.Stat(fs.Path); err == nil {
+			syncedAt = fi.ModTime()
+		}
+	}
+	packages, archive, err := applyMemoryBudget(packages, cfg, dumpPath)
+	if err != nil {
+		return errMsg(err)
+	}
+	// Added after the budget, which would count them as the oldest entries.
+	if cfg.StandardLibrary {
+		packages = append(packages, listStdPackages()...)
+	}
+	return packagesLoadedMsg{packages: packages, syncedAt: syncedAt, archive: archive, notice: notice}
+}
 
-	return m, nil
+type dumpResumedMsg struct {
+	packages []Package
 }
 
-func (m *model) updateViewportOffset() {
-	if m.selectedIndex < m.viewportOffset {
-		m.viewportOffset = m.selectedIndex
-	} else if m.selectedIndex >= m.viewportOffset+m.pageSize {
-		m.viewportOffset = m.selectedIndex - m.pageSize + 1
+// resumeDumpCmd continues an incomplete sync of the dump at path and returns
+// whatever new entries it managed to fetch.
+func resumeDumpCmd(path string, marker partialMarker) tea.Cmd {
+	return func() tea.Msg {
+		var packages []Package
+		// Failures are recorded in the dump's marker, which Update re-reads.
+		syncDump(index.NewHTTPSource(marker.URL), path, marker.Through, marker.Until, true, nil, func(page []index.Package) {
+			packages = append(packages, page...)
+		})
+		return dumpResumedMsg{packages: packages}
 	}
 }
 
-func (m *model) filterPackages() {
-	if m.searchQuery == "" {
-		m.filtered = make([]fuzzy.Match, len(m.packages))
-		for i, p := range m.packages {
-			m.filtered[i] = fuzzy.Match{Str: p.Path, Index: i, MatchedIndexes: nil}
+func main() {
+	// --dry-run holds for subcommands too, so it is taken out before they
+	// parse their own flags.
+	for i := 1; i < len(os.Args); i++ {
+		if os.Args[i] == "--dry-run" || os.Args[i] == "-dry-run" {
+			dryRun = &commandLog{}
+			os.Args = slices.Delete(os.Args, i, i+1)
+			break
 		}
-	} else {
-		targets := make([]string, len(m.packages))
-		for i, p := range m.packages {
-			targets[i] = p.Path
+	}
+
+	// `gosearch open <module>` is the TUI started on the module's details,
+	// and `gosearch tutorial` the TUI on a sample index with prompts.
+	var openPath string
+	var guided bool
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "tutorial":
+			guided = true
+			os.Args = append(os.Args[:1], os.Args[2:]...)
+		case "open":
+			if len(os.Args) < 3 {
+				fmt.Fprintln(os.Stderr, "Error: usage: gosearch open <module> [flags]")
+				os.Exit(1)
+			}
+			openPath = os.Args[2]
+			os.Args = append(os.Args[:1], os.Args[3:]...)
+		case "info":
+			if err := runInfo(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		case "licenses":
+			if err := runLicenses(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		case "outdated":
+			if err := runOutdated(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		case "fix":
+			if err := runFix(os.Args[2:], os.Stdin, os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		case "watch":
+			if err := runWatch(os.Args[2:], os.Stderr); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		case "audit":
+			if err := runAudit(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		case "editor-server":
+			if err := runEditorServer(os.Args[2:], os.Stdin, os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		case "serve":
+			if err := runServe(os.Args[2:], os.Stderr); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		case "popup":
+			if err := runPopup(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		case "team":
+			if err := runTeam(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		case "history":
+			if err := runHistory(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		case "import-history":
+			if err := runImportHistory(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		case "undo":
+			if err := runUndo(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		case "settings":
+			if err := runSettings(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		case "sync":
+			if err := runSync(os.Args[2:], os.Stderr); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			return
 		}
-		m.filtered = fuzzy.Find(m.searchQuery, targets)
 	}
 
-	if m.selectedIndex >= len(m.filtered) {
-		m.selectedIndex = len(m.filtered) - 1
+	indexFile := flag.String("index-file", "", "load packages from a newline-delimited JSON `file` instead of the network")
+	remote := flag.String("remote", "", "search through the `gosearch serve` instance at host:port instead of loading an index")
+	local := flag.Bool("local", false, "search the modules in the local module cache (GOMODCACHE), with every cached version, instead of the network index")
+	depsMode := flag.Bool("deps", false, "search only the modules the project in the current directory depends on, from its go.mod and go.sum")
+	token := flag.String("token", "", "with --remote, the user `token` to send to the server")
+	stayOpen := flag.Bool("stay-open", false, "keep running after copying, to copy several packages")
+	noGitHub := flag.Bool("no-github", false, "do not look up the stars, archive status, and last push of GitHub repositories, as set by github_metadata")
+	clipboard := flag.String("clipboard", "", "copy with the clipboard `backend` instead of the configured clipboard")
+	enterAction := flag.String("enter-action", "", "run `action` on Enter instead of the configured enter_action")
+	query := flag.String("query", "", "start with `query` in the search box; arguments after the flags do the same")
+	selection := flag.Int("select", 0, "select the `n`th result (from 1) once packages are loaded")
+	action := flag.String("action", "", "run `action` on the selected result once packages are loaded")
+	picker := flag.Bool("picker", false, "print the results for the query given as arguments as JSON quick-pick items and exit")
+	limit := flag.Int("limit", 50, "with --picker or --format, print at most `n` items (0 for all)")
+	format := flag.String("format", "", "print the results for the query as `json` or plain text and exit, without the TUI")
+	eventsFD := flag.Int("events-fd", 0, "write session events as NDJSON to file descriptor `n`")
+	eventsFile := flag.String("events-file", "", "write session events as NDJSON to `file`")
+	// Only listed here; main has already taken it out of the arguments.
+	flag.Bool("dry-run", false, "print the commands actions would run, like go get, go install, git clone, and the hook, instead of running them")
+	flag.Parse()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
 	}
-	if m.selectedIndex < 0 && len(m.filtered) > 0 {
-		m.selectedIndex = 0
+	keys, err := newKeyMap(cfg.Keys)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
 	}
-	m.updateViewportOffset()
-}
-
-func (m model) View() string {
-	if m.quitting {
-		if m.err != nil {
-			return errorStyle.Render(m.finalMessage) + "\n"
+	applyColors(cfg.Colors)
+	if *enterAction != "" {
+		if _, ok := actions[*enterAction]; !ok {
+			fmt.Fprintf(os.Stderr, "Error: unknown --enter-action '%s'\n", *enterAction)
+			os.Exit(1)
 		}
-		return successMessageStyle.Render(m.finalMessage) + "\n"
+		cfg.EnterAction = *enterAction
 	}
-
-	if m.err != nil {
-		return errorStyle.Render(fmt.Sprintf("Error: %v\n", m.err))
+	if *format != "" && *format != "json" && *format != "plain" {
+		fmt.Fprintf(os.Stderr, "Error: unknown --format '%s' (want json or plain)\n", *format)
+		os.Exit(1)
 	}
-
-	if m.loading {
-		return statusMessageStyle.Render("Loading Go packages from index.golang.org/index... Please wait.")
+	if *noGitHub {
+		cfg.GitHubMetadata = false
 	}
-
-	s := strings.Builder{}
-	s.WriteString(fmt.Sprintf("Search: %s%s\n\n", m.searchQuery, inputStyle.Render("|")))
-
-	if len(m.filtered) == 0 && m.searchQuery != "" {
-		s.WriteString("No packages found matching your query.\n")
-	} else if len(m.filtered) == 0 && m.searchQuery == "" && !m.loading {
-		s.WriteString("No packages loaded.\n")
-	} else {
-		endIndex := m.viewportOffset + m.pageSize
-		if endIndex > len(m.filtered) {
-			endIndex = len(m.filtered)
+	if *clipboard != "" {
+		if !slices.Contains(clipboardNames(), *clipboard) {
+			fmt.Fprintf(os.Stderr, "Error: unknown --clipboard '%s' (want one of %s)\n", *clipboard, strings.Join(clipboardNames(), ", "))
+			os.Exit(1)
 		}
+		cfg.Clipboard = *clipboard
+	}
+	if _, ok := actions[*action]; *action != "" && !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown --action '%s'\n", *action)
+		os.Exit(1)
+	}
+	if *query == "" {
+		*query = strings.Join(flag.Args(), " ")
+	}
 
-		for i := m.viewportOffset; i < endIndex; i++ {
-			item := m.filtered[i]
-			pkg := m.packages[item.Index] // Retrieve the full Package struct
+	annotationsFile, err := annotationsPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	annotations, err := loadAnnotations(annotationsFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	usageFile, err := usagePath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	usage, err := loadUsage(usageFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	if !cfg.History {
+		usage = nil
+	}
 
-			line := item.Str       // This is the package path that fuzzy matched
-			version := pkg.Version // Get the version
+	favoritesFile, err := favoritesPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	favorites, err := loadFavorites(favoritesFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	watchlistFile, err := watchlistPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	watchlist, err := loadWatchlist(watchlistFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
 
-			var highlightedLine []rune
-			lastIndex := 0
-			for _, idx := range item.MatchedIndexes {
-				highlightedLine = append(highlightedLine, []rune(line[lastIndex:idx])...)
-				highlightedLine = append(highlightedLine, []rune(lipgloss.NewStyle().Foreground(lipgloss.Color("#ff00ff")).Render(string(line[idx])))...)
-				lastIndex = idx + 1
-			}
-			highlightedLine = append(highlightedLine, []rune(line[lastIndex:])...)
-			displayLine := string(highlightedLine)
+	team, err := newTeamBackend(cfg.Team)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
 
-			// Append version, styled, if available
-			if version != "" {
-				displayLine += versionStyle.Render(fmt.Sprintf("(%s)", version))
-			}
+	events, err := openEventStream(*eventsFD, *eventsFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
 
-			if i == m.selectedIndex {
-				s.WriteString(selectedItemStyle.Render(displayLine))
-			} else {
-				s.WriteString(itemStyle.Render(displayLine))
-			}
-			s.WriteString("\n")
+	var policy *Policy
+	if cfg.Policy != "" {
+		if policy, err = loadPolicy(cfg.Policy); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitCode(err))
 		}
 	}
 
-	s.WriteString("\n")
-	s.WriteString(statusMessageStyle.Render(fmt.Sprintf("Found %d packages (filtered from %d). Use ↑↓ to navigate, Enter to copy path and quit, Q or Ctrl+C to quit.", len(m.filtered), len(m.packages))))
-	return s.String()
-}
+	var source index.Source = feedSource(cfg)
+	sourceLabel := "index.golang.org/index"
+	var partial *partialMarker
+	if *indexFile != "" {
+		// Without a cache directory the dump is simply parsed every time.
+		dir, _ := cacheDir()
+		source = index.FileSource{Path: *indexFile, CacheDir: dir}
+		sourceLabel = *indexFile
+		if partial, err = readPartialMarker(*indexFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+	}
 
-type packagesLoadedMsg []Package
-type errMsg error
+	var client *remoteClient
+	if *remote != "" {
+		if *indexFile != "" || *picker || *format != "" {
+			fmt.Fprintln(os.Stderr, "Error: --remote cannot be used with --index-file, --picker, or --format")
+			os.Exit(1)
+		}
+		if client, err = newRemoteClient(*remote, *token); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		sourceLabel = client.base
+	}
 
-func fetchPackagesCmd() tea.Cmd {
-	return func() tea.Msg {
-		resp, err := http.Get("https://index.golang.org/index")
+	if *local {
+		if *remote != "" || *indexFile != "" || *depsMode || guided {
+			fmt.Fprintln(os.Stderr, "Error: --local cannot be used with --remote, --index-file, --deps, or the tutorial")
+			os.Exit(1)
+		}
+		cache, err := newLocalCacheSource()
 		if err != nil {
-			return errMsg(fmt.Errorf("failed to fetch Go index: %w", err))
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitCode(err))
 		}
-		defer resp.Body.Close()
+		source, sourceLabel = cache, cache.dir
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			return errMsg(fmt.Errorf("received non-OK status from Go index: %s", resp.Status))
+	var deps *projectDeps
+	if *depsMode {
+		if *remote != "" || *indexFile != "" || guided {
+			fmt.Fprintln(os.Stderr, "Error: --deps cannot be used with --remote, --index-file, or the tutorial")
+			os.Exit(1)
 		}
-
-		var packages []Package
-		scanner := bufio.NewScanner(resp.Body)
-		for scanner.Scan() {
-			line := scanner.Bytes()
-			var pkg Package
-			if err := json.Unmarshal(line, &pkg); err != nil {
-				log.Printf("Error unmarshalling package line: %v, line: %s", err, string(line))
-				continue
-			}
-			packages = append(packages, pkg)
+		cwd, _ := os.Getwd()
+		if deps, err = loadProjectDeps(cwd); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitCode(err))
 		}
+		source, sourceLabel = depsSource{deps: deps}, "go.mod of "+cmp.Or(deps.module, "the current module")
+		cfg.StandardLibrary = false
+	}
 
-		if err := scanner.Err(); err != nil && err != io.EOF {
-			return errMsg(fmt.Errorf("error reading Go index response: %w", err))
+	if guided {
+		if *remote != "" || *indexFile != "" {
+			fmt.Fprintln(os.Stderr, "Error: the tutorial brings its own index; drop --remote and --index-file")
+			os.Exit(1)
 		}
-
-		return packagesLoadedMsg(packages)
+		source, sourceLabel = tutorialSource{}, "the tutorial index"
+		// Picks in the tutorial say nothing about what the user needs.
+		usage = nil
+		cfg.StandardLibrary = false
 	}
-}
 
-func copyToClipboardCmd(text string) tea.Cmd {
-	return func() tea.Msg {
-		var cmd *exec.Cmd
-		var cmdName string
-
-		switch runtime.GOOS {
-		case "darwin": // macOS
-			cmdName = "pbcopy"
-			cmd = exec.Command(cmdName)
-		case "linux": // Linux
-			cmdName = "xclip"
-			cmd = exec.Command(cmdName, "-selection", "clipboard", "-i")
-		case "windows": // Windows
-			cmdName = "clip"
-			cmd = exec.Command("cmd", "/c", cmdName)
-		default:
-			return errMsg(fmt.Errorf("unsupported operating system for clipboard: %s", runtime.GOOS))
+	cwd, _ := os.Getwd()
+	m := model{
+		source:         source,
+		sourceLabel:    sourceLabel,
+		remote:         client,
+		dumpPath:       *indexFile,
+		partial:        partial,
+		state:          stateLoading,
+		list:           resultsList{pageSize: cmp.Or(cfg.PageSize, 20), maxPageSize: cfg.PageSize},
+		keys:           keys,
+		stayOpen:       cfg.StayOpen || *stayOpen,
+		latestVersions: make(map[string]string),
+		dependents:     make(dependentCounts),
+		repos:          make(map[string]*repoMeta),
+		engine:         search.NewEngine(nil),
+		annotations:    annotations,
+		favorites:      favorites,
+		watchlist:      watchlist,
+		team:           team,
+		policy:         policy,
+		violations:     make(map[string]string),
+		scans:          make(map[string]moduleScan),
+		config:         cfg,
+		telemetry:      &telemetry{},
+		events:         events,
+		tools:          findProjectTools(cwd),
+		usage:          usage,
+		vendored:       findVendoredModules(cwd),
+		deps:           deps,
+		migrations:     make(map[string]*moduleMigration),
+		previews:       make(map[string]modulePreview),
+		categories:     newCategoryBrowser(cfg.Categories),
+		input:          searchInput{query: *query},
+		deepLink:       deepLink{selection: *selection, action: *action, open: openPath},
+	}
+	if guided {
+		m.tutorial = &tutorial{}
+	}
+	if err := m.engine.SetMatcher(cfg.Matcher); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	ranker, err := newRanker(cfg.Ranking, m.tools, m.usage, cfg.PreferredHosts, m.dependents)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	m.engine.SetRanker(ranker)
+	m.engine.SetFilter("tag", annotations.tagFilter)
+	m.engine.SetFilter("is", favorites.filter)
+	m.sortLabel = rankingLabel(ranker)
+	// The TUI adds the standard library as it loads; these fetch once.
+	headless := source
+	if cfg.StandardLibrary {
+		headless = stdlibSource{source}
+	}
+	if *picker {
+		if err := runQuickPick(headless, m.engine, annotations, *query, *limit, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitCode(err))
 		}
-
-		var stderr bytes.Buffer
-		cmd.Stderr = &stderr
-
-		stdin, err := cmd.StdinPipe()
-		if err != nil {
-			return errMsg(fmt.Errorf("failed to get stdin pipe for %s: %w", cmdName, err))
+		return
+	}
+	if *format != "" {
+		if err := runHeadless(headless, m.engine, *query, *limit, *format, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitCode(err))
 		}
+		return
+	}
+	if m.statusTemplate, err = parseStatusLine(cfg.StatusLine); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	if cfg.Icons {
+		m.privatePatterns = goPrivatePatterns()
+	}
 
-		go func() {
-			defer stdin.Close()
-			_, writeErr := io.WriteString(stdin, text)
-			if writeErr != nil {
-				log.Printf("Error writing to %s stdin: %v", cmdName, writeErr)
-			}
-		}()
-
-		if err := cmd.Run(); err != nil {
-			errorOutput := strings.TrimSpace(stderr.String())
-			detailedErr := fmt.Errorf("failed to copy to clipboard using '%s': %w", cmdName, err)
-
-			if exitErr, ok := err.(*exec.ExitError); ok {
-				if exitErr.ExitCode() == 127 {
-					detailedErr = fmt.Errorf("clipboard command '%s' not found. Please ensure it's installed and in your PATH. (Stderr: %s)", cmdName, errorOutput)
-				} else {
-					detailedErr = fmt.Errorf("clipboard command '%s' exited with error %d: %w (Stderr: %s)", cmdName, exitErr.ExitCode(), err, errorOutput)
-				}
-			} else {
-				detailedErr = fmt.Errorf("clipboard command '%s' failed: %w (Stderr: %s)", cmdName, err, errorOutput)
-			}
-			return errMsg(detailedErr)
-		}
-		return nil
+	// The launch history lets `gosearch sync --daemon` sync before the
+	// usual launch times; without it, syncs are just less well timed.
+	if history, err := launchesPath(); err == nil {
+		recordLaunch(history, time.Now())
 	}
-}
 
-func main() {
-	m := model{
-		loading:  true,
-		pageSize: 20,
+	// Keep stdout clean for the print action when it is piped somewhere.
+	var opts []tea.ProgramOption
+	if fi, err := os.Stdout.Stat(); err == nil && fi.Mode()&os.ModeCharDevice == 0 {
+		opts = append(opts, tea.WithOutput(os.Stderr))
 	}
 
-	p := tea.NewProgram(m)
+	p := tea.NewProgram(m, append(opts, tea.WithContext(appCtx))...)
 
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	// Whatever the program left running in the background is of no use now.
+	shutdown()
+	if err != nil {
 		fmt.Printf("Alas, there's been an error: %v\n", err)
 		os.Exit(1)
 	}
+	fm, _ := final.(model)
+	events.close(fm.finalMessage)
+	if fm.archive != nil && fm.archive.spilled {
+		os.Remove(fm.archive.path)
+	}
+	if fm.output != "" {
+		fmt.Println(fm.output)
+	}
+	if fm.err != nil {
+		os.Exit(exitCode(fm.err))
+	}
 }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+// actionMenu is the popup listing every action for the highlighted package.
+type actionMenu struct {
+	pkg      Package
+	items    []string
+	selected int
+	keys     keyMap
+}
+
+// actionChosenMsg is sent when an entry of the actions menu is picked.
+type actionChosenMsg struct {
+	name string
+	pkg  Package
+}
+
+func newActionMenu(pkg Package, cfg Config, keys keyMap, vendored bool) actionMenu {
+	menu := actionMenu{pkg: pkg, keys: keys}
+	for _, name := range menuActions {
+		if name == "hook" && cfg.Hook == "" || name == "vendor" && !vendored || isStdPath(pkg.Path) && !slices.Contains(stdActions, name) {
+			continue
+		}
+		menu.items = append(menu.items, name)
+	}
+	return menu
+}
+
+func (menu actionMenu) Update(msg tea.Msg) (actionMenu, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return menu, nil
+	}
+
+	if key.String() == "esc" {
+		return menu, closeOverlay
+	}
+	switch menu.keys.command(key) {
+	case "actions":
+		return menu, closeOverlay
+
+	case "up":
+		menu.selected = (menu.selected - 1 + len(menu.items)) % len(menu.items)
+
+	case "down":
+		menu.selected = (menu.selected + 1) % len(menu.items)
+
+	case "enter":
+		chosen := actionChosenMsg{name: menu.items[menu.selected], pkg: menu.pkg}
+		return menu, func() tea.Msg { return chosen }
+	}
+	return menu, nil
+}
+
+func (menu actionMenu) View() string {
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf("Actions for %s\n\n", inputStyle.Render(menu.pkg.Path)))
+
+	for i, name := range menu.items {
+		line := padWidth(name, 12) + " " + actions[name].description
+		if i == menu.selected {
+			s.WriteString(selectedItemStyle.Render(line))
+		} else {
+			s.WriteString(itemStyle.Render(line))
+		}
+		s.WriteString("\n")
+	}
+
+	s.WriteString("\n")
+	s.WriteString(statusMessageStyle.Render(joinHints(
+		menu.keys.hint("to navigate", "up", "down"),
+		menu.keys.hint("to run the action", "enter"),
+		"Esc to go back")))
+	return s.String()
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+	"golang.org/x/mod/module"
+)
+
+// modulePathPattern finds module paths in free text, such as the message
+// of a Deprecated comment.
+var modulePathPattern = regexp.MustCompile(`[a-z0-9][a-z0-9.-]*\.[a-z]{2,}(/[A-Za-z0-9_.~-]+)+`)
+
+// moduleMigration tells that a module has moved to another path, and why
+// that is thought.
+type moduleMigration struct {
+	to     string
+	reason string
+}
+
+type migrationCheckedMsg struct {
+	path      string
+	migration *moduleMigration
+	err       error
+}
+
+func checkMigrationCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		migration, err := findMigration(path)
+		return migrationCheckedMsg{path: path, migration: migration, err: err}
+	}
+}
+
+// findMigration looks for signs that path was replaced by another module
+// path: a go.mod declaring a different path at the latest version, a
+// Deprecated comment naming one, or a GitHub repository that redirects
+// elsewhere. Only paths the proxy serves are suggested. It returns nil if
+// the module has not moved.
+func findMigration(path string) (*moduleMigration, error) {
+	latest, err := fetchLatest(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up '%s': %w", path, err)
+	}
+	f, err := fetchGoMod(path, latest.Version)
+	if err != nil {
+		return nil, err
+	}
+	if f.Module != nil {
+		if declared := f.Module.Mod.Path; declared != path && declared != "" && published(declared) {
+			return &moduleMigration{to: declared, reason: "its go.mod declares its path as " + declared}, nil
+		}
+		if msg := f.Module.Deprecated; msg != "" {
+			for _, candidate := range modulePathPattern.FindAllString(msg, -1) {
+				candidate = strings.TrimRight(candidate, ".")
+				if candidate != path && module.CheckPath(candidate) == nil && published(candidate) {
+					return &moduleMigration{to: candidate, reason: "deprecated: " + msg}, nil
+				}
+			}
+		}
+	}
+	// A failed redirect check only means none was found.
+	if to, ok := githubRedirect(path); ok && published(to) {
+		return &moduleMigration{to: to, reason: "its repository now redirects to " + to}, nil
+	}
+	return nil, nil
+}
+
+// published reports whether the proxy has a version of the module path.
+func published(path string) bool {
+	_, err := fetchLatest(path)
+	return err == nil
+}
+
+// noRedirectClient stops at redirects so that they can be read.
+var noRedirectClient = &http.Client{
+	Timeout: httpClient.Timeout,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// githubRedirect returns the module path at which GitHub now serves the
+// repository of path, if it was renamed or transferred.
+func githubRedirect(path string) (string, bool) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "github.com" || len(parts) < 3 {
+		return "", false
+	}
+	resp, err := httpDo(noRedirectClient, http.MethodHead, "https://"+strings.Join(parts[:3], "/"))
+	if err != nil {
+		return "", false
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMovedPermanently {
+		return "", false
+	}
+	loc, err := resp.Location()
+	if err != nil || loc.Host != "github.com" {
+		return "", false
+	}
+	moved := strings.Split(strings.Trim(loc.Path, "/"), "/")
+	if len(moved) != 2 {
+		return "", false
+	}
+	to := strings.Join(append([]string{"github.com", moved[0], moved[1]}, parts[3:]...), "/")
+	if to == path {
+		return "", false
+	}
+	return to, true
+}
+
+// checkSelectedMigration starts looking for a new path of the selected
+// module if that has not been done yet.
+func (m *model) checkSelectedMigration() tea.Cmd {
+	pkg, ok := m.selectedPackage()
+	if !ok || isStdPath(pkg.Path) {
+		return nil
+	}
+	return m.checkMigration(pkg.Path)
+}
+
+func (m *model) checkMigration(path string) tea.Cmd {
+	if _, seen := m.migrations[path]; seen {
+		return nil
+	}
+	m.migrations[path] = nil
+	return checkMigrationCmd(path)
+}
+
+// migrationBadge marks rows of modules known to have moved.
+func (m model) migrationBadge(pkg Package) string {
+	if m.migrations[pkg.Path] == nil {
+		return ""
+	}
+	return warningStyle.Render("↪") + " "
+}
+
+// migrationLine points from the selected module to its new path.
+func (m model) migrationLine() string {
+	pkg, ok := m.selectedPackage()
+	if !ok || m.migrations[pkg.Path] == nil {
+		return ""
+	}
+	mig := m.migrations[pkg.Path]
+	return fmt.Sprintf("Moved to %s (%s). Press Ctrl+N to search for it.", mig.to, firstLine(mig.reason))
+}
+
+// firstLine cuts text at its first line break.
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	return line
+}
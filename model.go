@@ -0,0 +1,957 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+
+	"gosearch/index"
+	"gosearch/search"
+)
+
+// Model represents the state of our terminal UI application. Each screen is
+// a sub-model with its own Update and View; the model routes messages to the
+// one the current state calls for and handles everything shared between them.
+type model struct {
+	state state
+
+	input  searchInput
+	list   resultsList
+	picker versionPicker
+	menu   actionMenu
+	help   helpOverlay
+	// categories keeps its position between visits.
+	categories categoryBrowser
+	history    historyBrowser
+	editor     annotationEditor
+	detail     detailView
+	licenses   licenseView
+	upgrade    upgradeView
+	outdated   outdatedView
+	graph      graphView
+	replace    replacePrompt
+	files      fileBrowser
+	grep       grepView
+
+	// keys binds the keys of the results screen to its commands.
+	keys keyMap
+
+	source      index.Source
+	sourceLabel string
+	// remote, if set, is the `gosearch serve` instance searches are sent
+	// to instead of searching a local index. remoteTotal is the size of its
+	// index, and remoteMatches its results for remoteQuery, which was sent
+	// if remoteSent.
+	remote        *remoteClient
+	remoteTotal   int
+	remoteQuery   string
+	remoteSent    bool
+	remoteMatches []search.Match
+	// loadProgress is how far the first load of the feed has come.
+	loadProgress index.Progress
+	// dumpPath is the --index-file being searched, if any. partial is set
+	// when that dump was left incomplete by a failed sync.
+	dumpPath   string
+	partial    *partialMarker
+	resuming   bool
+	syncedAt   time.Time
+	refreshing bool
+	refreshErr error
+	// notice is a warning about something recovered from while loading.
+	notice string
+	// stayOpen keeps the TUI running after a copy, flashing the outcome.
+	stayOpen bool
+	// flash is a transient status message; flashID tells its expiry apart
+	// from that of the messages it replaced.
+	flash    string
+	flashErr bool
+	flashID  int
+	// backgroundRefresh is set while an auto-refresh runs; newCount counts
+	// the entries such refreshes have merged in.
+	backgroundRefresh bool
+	newCount          int
+
+	packages    []Package
+	engine      *search.Engine
+	annotations *annotationStore
+	// favorites are the starred modules, shown first.
+	favorites *moduleList
+	// watchlist are the modules whose new versions refreshes announce.
+	watchlist *moduleList
+	// team is where shared annotations come from, if configured.
+	team teamBackend
+	// policy, if set, restricts the packages that may be used. violations
+	// holds the outcome of its deps.dev checks by path; an empty value means
+	// compliant or still being checked.
+	policy     *Policy
+	violations map[string]string
+	// tools are the tool dependencies of the project in the working
+	// directory, badged and boosted in the results.
+	tools *projectTools
+	// usage is what was picked before, for the frecency ranking signal.
+	usage *usageHistory
+	// vendored lists the modules in the project's vendor directory, if it
+	// has one.
+	vendored *vendoredModules
+	// deps is set in --deps mode, where the results are the project's own
+	// dependencies.
+	deps *projectDeps
+	// expansion lists other versions of a module under its result.
+	expansion versionExpansion
+	// migrations holds the new path of modules found to have moved, by
+	// path; a nil value means not moved or still being checked.
+	migrations map[string]*moduleMigration
+	// preview shows the preview pane next to the results; previews caches
+	// what it shows, by path.
+	preview  bool
+	previews map[string]modulePreview
+	width    int
+	// confirmation asks before running what the confirm option names.
+	confirmation confirmDialog
+	// tutorial, if set, prompts through `gosearch tutorial`.
+	tutorial *tutorial
+	// scans holds what was found in the zips of modules whose details were
+	// shown, by path.
+	scans    map[string]moduleScan
+	queryErr error
+	// archive is set when the memory budget left older entries on disk.
+	// archiveMatches are its matches for archiveQuery.
+	archive          *archive
+	searchingArchive bool
+	archiveQuery     string
+	archiveMatches   []search.Match
+
+	// deepLink is followed once the first packages are loaded.
+	deepLink deepLink
+
+	err          error
+	finalMessage string
+	// output is printed to stdout after the TUI exits.
+	output string
+
+	telemetry *telemetry
+	// events, if set, is the --events-fd/--events-file stream.
+	events *eventStream
+
+	// latestVersions caches @latest lookups for rows that have been on screen;
+	// an empty value means the lookup is in flight or failed.
+	latestVersions map[string]string
+	// dependents feeds the popularity ranking signal.
+	dependents dependentCounts
+	// repos are the GitHub repositories of rows that have been on screen,
+	// nil while the lookup runs or if it failed.
+	repos         map[string]*repoMeta
+	githubLimited bool
+
+	config         Config
+	statusTemplate *template.Template
+	sortLabel      string
+	// privatePatterns is GOPRIVATE, used to badge private modules.
+	privatePatterns string
+}
+
+// closeOverlayMsg is sent by a sub-screen that wants to return to the
+// results list.
+type closeOverlayMsg struct{}
+
+func closeOverlay() tea.Msg { return closeOverlayMsg{} }
+
+func (m model) Init() tea.Cmd {
+	load := fetchPackagesCmd(m.source, m.config, m.dumpPath)
+	if m.remote != nil {
+		load = remoteStatusCmd(m.remote)
+	}
+	cmds := []tea.Cmd{load, scheduleAutoRefresh(m.config.RefreshInterval)}
+	if m.team != nil {
+		cmds = append(cmds, pullTeamCmd(m.team))
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	if m, ok := next.(model); ok {
+		m.events.observe(m)
+		m.tutorial.advance(m)
+		if search := m.searchRemote(); search != nil {
+			return m, tea.Batch(cmd, search)
+		}
+	}
+	return next, cmd
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		return m.updateKey(msg)
+
+	case closeOverlayMsg:
+		m.setState(stateBrowsing)
+		return m, nil
+
+	case actionChosenMsg:
+		if m.state != stateMenu || !m.setState(stateBrowsing) {
+			return m, nil
+		}
+		cmd := m.runAction(msg.name, msg.pkg)
+		return m, cmd
+
+	case versionChosenMsg:
+		if m.state != statePicking {
+			return m, nil
+		}
+		m.events.emit(event{Type: "action", Action: "versions", Path: msg.path, Version: msg.version})
+		return m, m.audited("versions", Package{Path: msg.path}, msg.version, copyAndQuit(&m, msg.path+"@"+msg.version))
+
+	case categoryChosenMsg:
+		if m.state != stateCategories || !m.setState(stateBrowsing) {
+			return m, nil
+		}
+		return m, m.searchFor(msg.path)
+
+	case historyChosenMsg:
+		if m.state != stateHistory || !m.setState(stateBrowsing) {
+			return m, nil
+		}
+		return m, m.searchFor(msg.path)
+
+	case replaceChosenMsg:
+		if m.state != stateReplacing {
+			return m, nil
+		}
+		m.events.emit(event{Type: "action", Action: "replace", Path: msg.path})
+		done := fmt.Sprintf("Replaced '%s' with '%s'.", msg.path, msg.target)
+		if msg.target == "" {
+			done = fmt.Sprintf("Dropped the replace directive of '%s'.", msg.path)
+		}
+		return m, m.audited("replace", Package{Path: msg.path}, msg.target,
+			tea.Sequence(replaceCmd(msg.path, msg.target), m.quit(done)))
+
+	case moduleFilesMsg, moduleFileMsg, moduleGrepMsg:
+		switch m.state {
+		case stateFiles:
+			m.files, _ = m.files.Update(msg)
+		case stateGrepping:
+			m.grep, _ = m.grep.Update(msg)
+		}
+		return m, nil
+
+	case graphLoadedMsg:
+		if m.state == stateGraph {
+			m.graph, _ = m.graph.Update(msg)
+		}
+		return m, nil
+
+	case graphChosenMsg:
+		if m.state != stateGraph || !m.setState(stateBrowsing) {
+			return m, nil
+		}
+		return m, m.searchFor(msg.path)
+
+	case annotationEditedMsg:
+		if m.state != stateAnnotating || !m.setState(stateBrowsing) {
+			return m, nil
+		}
+		return m, m.applyAnnotationEdit(msg)
+
+	case policyCheckedMsg:
+		if msg.err == nil && msg.reason != "" {
+			m.violations[msg.path] = msg.reason
+			m.list.Invalidate()
+			if m.policy.Mode == "hide" {
+				m.filterPackages()
+			}
+		}
+		return m, m.resolveVisibleLatest()
+
+	case teamPulledMsg:
+		if msg.entries != nil {
+			m.annotations.setShared(msg.entries)
+			m.list.Invalidate()
+			m.filterPackages()
+		}
+		if msg.err != nil {
+			m.notice = msg.err.Error()
+		}
+		return m, nil
+
+	case detailLoadedMsg:
+		if m.state != stateDetail {
+			return m, nil
+		}
+		m.detail, _ = m.detail.Update(msg)
+		if msg.err != nil {
+			return m, nil
+		}
+		if msg.insights.dependents >= 0 {
+			m.dependents[msg.path] = msg.insights.dependents
+		}
+		m.detail.weighing, m.detail.vulnsLoading = true, true
+		cmds := []tea.Cmd{estimateWeightCmd(msg.path, msg.latest.Version), lookupVulnsCmd(msg.path, msg.latest.Version, false)}
+		if m.scans[msg.path].version != msg.latest.Version {
+			m.detail.scanning = true
+			cmds = append(cmds, scanModuleCmd(msg.path, msg.latest.Version))
+		}
+		return m, tea.Batch(cmds...)
+
+	case weightEstimatedMsg:
+		if m.state == stateDetail {
+			m.detail, _ = m.detail.Update(msg)
+		}
+		return m, nil
+
+	case repoMetaMsg:
+		m.finishRepoMeta(msg)
+		return m, nil
+
+	case dependentsCountedMsg:
+		return m, m.finishDependentsCount(msg)
+
+	case vulnsLoadedMsg:
+		if msg.flash {
+			return m, m.vulnsFlash(msg)
+		}
+		if m.state == stateDetail {
+			m.detail, _ = m.detail.Update(msg)
+		}
+		return m, nil
+
+	case moduleScannedMsg:
+		if msg.err == nil {
+			m.scans[msg.path] = msg.scan
+			m.list.Invalidate()
+		}
+		if m.state == stateDetail {
+			m.detail, _ = m.detail.Update(msg)
+		}
+		return m, nil
+
+	case detailActionsMsg:
+		if m.state == stateDetail && m.setState(stateMenu) {
+			m.menu = newActionMenu(Package{Path: msg.path, Version: m.detail.latest.Version}, m.config, m.keys, m.vendored != nil)
+		}
+		return m, nil
+
+	case licenseReportMsg, licenseExportedMsg:
+		if m.state == stateLicenses {
+			m.licenses, _ = m.licenses.Update(msg)
+		}
+		return m, nil
+
+	case upgradePreviewedMsg:
+		if m.state == stateUpgrading {
+			m.upgrade, _ = m.upgrade.Update(msg)
+		}
+		return m, nil
+
+	case upgradeConfirmedMsg:
+		if m.state != stateUpgrading {
+			return m, nil
+		}
+		m.events.emit(event{Type: "action", Action: "upgrade", Path: msg.path, Version: msg.version})
+		target := msg.path + "@" + msg.version
+		gated := policyGateCmd(m.policy, msg.path, msg.version, goGetCmd("-u", target))
+		return m, m.audited("upgrade", Package{Path: msg.path}, msg.version,
+			tea.Sequence(gated, m.quit(fmt.Sprintf("Upgraded to '%s'.", target))))
+
+	case outdatedListedMsg, upgradeStepMsg:
+		if m.state == stateOutdated {
+			var cmd tea.Cmd
+			m.outdated, cmd = m.outdated.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+
+	case bulkUpgradeMsg:
+		if m.state != stateOutdated {
+			return m, nil
+		}
+		var details []string
+		for _, t := range msg.targets {
+			details = append(details, fmt.Sprintf("%s %s => %s", t.Path, t.Version, t.Update))
+		}
+		title := fmt.Sprintf("Upgrade %d dependencies of %s and run go mod tidy?", len(msg.targets), m.outdated.dir)
+		return m, m.confirm("bulk-upgrade", title, details, func(m *model) tea.Cmd {
+			var audits []tea.Cmd
+			for _, t := range msg.targets {
+				m.events.emit(event{Type: "action", Action: "upgrade", Path: t.Path, Version: t.Update})
+				audits = append(audits, m.audited("upgrade", Package{Path: t.Path}, t.Update, nil))
+			}
+			var start tea.Cmd
+			m.outdated, start = m.outdated.start(msg.targets)
+			return tea.Sequence(append(audits, start)...)
+		})
+
+	case versionsLoadedMsg:
+		if m.state == statePicking {
+			m.picker, _ = m.picker.Update(msg)
+		}
+		return m, nil
+
+	case loadProgressMsg:
+		m.loadProgress = msg.progress
+		return m, msg.wait
+
+	case packagesLoadedMsg:
+		if m.state != stateLoading || !m.setState(stateBrowsing) {
+			return m, nil
+		}
+		m.packages = msg.packages
+		m.syncedAt = msg.syncedAt
+		m.archive = msg.archive
+		if msg.notice != "" {
+			m.notice = msg.notice
+		}
+		m.setCorpus()
+		m.filterPackages()
+		loaded := len(m.packages)
+		m.events.emit(event{Type: "ready", Results: &loaded})
+		return m, tea.Batch(m.resolveVisibleLatest(), m.followDeepLink())
+
+	case refreshedMsg:
+		m.mergeRefreshed(msg)
+		return m, tea.Batch(m.announceWatched(msg.packages), m.resolveVisibleLatest())
+
+	case autoRefreshMsg:
+		var cmd tea.Cmd
+		if !m.syncedAt.IsZero() && clock().Sub(m.syncedAt) >= m.config.RefreshInterval {
+			cmd = m.startRefresh(true)
+		}
+		return m, tea.Batch(cmd, scheduleAutoRefresh(m.config.RefreshInterval))
+
+	case dumpResumedMsg:
+		m.resuming = false
+		if len(msg.packages) > 0 {
+			m.packages = append(m.packages, msg.packages...)
+			m.setCorpus()
+			m.filterPackages()
+		}
+		if partial, err := readPartialMarker(m.dumpPath); err == nil {
+			m.partial = partial
+		}
+		return m, m.resolveVisibleLatest()
+
+	case archiveSearchedMsg:
+		m.mergeArchiveSearch(msg)
+		return m, m.resolveVisibleLatest()
+
+	case latestResolvedMsg:
+		if msg.err == nil {
+			m.latestVersions[msg.path] = msg.version
+			m.list.Invalidate()
+		}
+		return m, nil
+
+	case migrationCheckedMsg:
+		if msg.err == nil && msg.migration != nil {
+			m.migrations[msg.path] = msg.migration
+			m.list.Invalidate()
+		}
+		return m, nil
+
+	case remoteStatusMsg:
+		return m, m.applyRemoteStatus(msg)
+
+	case remoteResultsMsg:
+		return m, m.mergeRemoteResults(msg)
+
+	case confirmAnsweredMsg:
+		return m, m.answerConfirm(msg)
+
+	case undoneMsg:
+		return m, m.finishUndo(msg)
+
+	case goGetDoneMsg:
+		return m, m.finishGet(msg)
+
+	case copiedMsg:
+		if msg.quit {
+			return m, m.finishCopy(msg)
+		}
+		if msg.err != nil {
+			return m, m.flashMessage(msg.err.Error(), true)
+		}
+		return m, m.flashMessage(fmt.Sprintf("'%s' copied to clipboard!", msg.text), false)
+
+	case flashExpiredMsg:
+		if msg.id == m.flashID {
+			m.flash = ""
+		}
+		return m, nil
+
+	case errMsg:
+		return m, m.fail(msg)
+
+	case previewLoadedMsg:
+		m.previews[msg.path] = msg.preview
+		return m, nil
+
+	case tea.WindowSizeMsg:
+		m.list.SetHeight(msg.Height)
+		m.width = msg.Width
+		// The grep view lays out its matches to the terminal too.
+		m.grep.height, m.grep.width = m.list.pageSize, msg.Width
+	}
+
+	return m, m.resolveVisibleLatest()
+}
+
+// typing reports whether the current screen takes text, where a letter bound
+// to quit is typed instead. The help overlay closes on any key instead.
+func (m model) typing() bool {
+	switch m.state {
+	case stateHelp, stateAnnotating, stateGraph, stateReplacing, stateFiles, stateGrepping, stateConfirming:
+		return true
+	case stateBrowsing:
+		return !m.input.blurred
+	}
+	return false
+}
+
+// updateKey routes a key press to the sub-model of the current state after
+// handling the keys that mean the same thing everywhere.
+func (m model) updateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.done() {
+		return m, nil
+	}
+	if msg.String() == "ctrl+c" || (m.keys.command(msg) == "quit" && (msg.Type != tea.KeyRunes || !m.typing())) {
+		return m, m.quit("Exiting Go Package Search CLI.")
+	}
+
+	var cmd tea.Cmd
+	switch m.state {
+	case statePicking:
+		m.picker, cmd = m.picker.Update(msg)
+		return m, cmd
+	case stateMenu:
+		m.menu, cmd = m.menu.Update(msg)
+		return m, cmd
+	case stateHelp:
+		m.help, cmd = m.help.Update(msg)
+		return m, cmd
+	case stateCategories:
+		m.categories, cmd = m.categories.Update(msg)
+		return m, cmd
+	case stateHistory:
+		m.history, cmd = m.history.Update(msg)
+		return m, cmd
+	case stateAnnotating:
+		m.editor, cmd = m.editor.Update(msg)
+		return m, cmd
+	case stateDetail:
+		m.detail, cmd = m.detail.Update(msg)
+		return m, cmd
+	case stateLicenses:
+		m.licenses, cmd = m.licenses.Update(msg)
+		return m, cmd
+	case stateUpgrading:
+		m.upgrade, cmd = m.upgrade.Update(msg)
+		return m, cmd
+	case stateOutdated:
+		m.outdated, cmd = m.outdated.Update(msg)
+		return m, cmd
+	case stateGraph:
+		m.graph, cmd = m.graph.Update(msg)
+		return m, cmd
+	case stateReplacing:
+		m.replace, cmd = m.replace.Update(msg)
+		return m, cmd
+	case stateFiles:
+		m.files, cmd = m.files.Update(msg)
+		return m, cmd
+	case stateGrepping:
+		m.grep, cmd = m.grep.Update(msg)
+		return m, cmd
+	case stateConfirming:
+		m.confirmation, cmd = m.confirmation.Update(msg)
+		return m, cmd
+	case stateBrowsing:
+		return m.updateBrowsing(msg)
+	}
+	return m, nil
+}
+
+// updateBrowsing handles keys on the results screen: commands first, then
+// cursor movement for the list, and anything else edits the query.
+func (m model) updateBrowsing(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Letters are typed into the query while it has the focus, even those
+	// bound to commands; after Esc they run the commands, and / gives the
+	// focus back.
+	switch {
+	case msg.Type == tea.KeyEsc && !m.input.blurred:
+		m.input.blurred = true
+		return m, nil
+	case msg.String() == "/" && m.input.blurred:
+		m.input.blurred = false
+		return m, nil
+	}
+	command := m.keys.command(msg)
+	if msg.Type == tea.KeyRunes && !m.input.blurred {
+		command = ""
+	}
+
+	switch command {
+	case "enter":
+		if pkg, ok := m.selectedPackage(); ok {
+			cmd := m.runAction(m.config.EnterAction, pkg)
+			return m, cmd
+		}
+		return m, nil
+
+	case "enter-cycle":
+		return m, m.cycleEnterAction()
+
+	case "get":
+		if pkg, ok := m.selectedPackage(); ok {
+			return m, m.getIntoProject(pkg)
+		}
+		return m, nil
+
+	case "undo":
+		return m, m.undo()
+
+	case "expand":
+		return m, m.toggleVersions()
+
+	case "vulns":
+		return m, m.checkSelectedVulns()
+
+	case "star":
+		pkg, ok := m.selectedPackage()
+		if !ok {
+			return m, nil
+		}
+		return m, tea.Batch(m.toggleFavorite(pkg), m.resolveVisibleLatest())
+
+	case "favorites":
+		m.toggleFavoritesOnly()
+
+	case "versions":
+		pkg, ok := m.selectedPackage()
+		if !ok {
+			return m, nil
+		}
+		if isStdPath(pkg.Path) {
+			return m, m.flashMessage(fmt.Sprintf("'%s' is in the standard library; it has no versions of its own.", pkg.Path), true)
+		}
+		return m, m.runAction("versions", pkg)
+
+	case "actions":
+		if pkg, ok := m.selectedPackage(); ok && m.setState(stateMenu) {
+			m.menu = newActionMenu(pkg, m.config, m.keys, m.vendored != nil)
+		}
+		return m, nil
+
+	case "help":
+		m.help = helpOverlay{keys: m.keys}
+		m.setState(stateHelp)
+		return m, nil
+
+	case "categories":
+		m.setState(stateCategories)
+		return m, nil
+
+	case "history":
+		return m, m.openHistory()
+
+	case "stay-open":
+		m.stayOpen = !m.stayOpen
+		if m.stayOpen {
+			return m, m.flashMessage("Staying open after copying.", false)
+		}
+		return m, m.flashMessage("Quitting after copying.", false)
+
+	case "telemetry":
+		m.telemetry.toggle()
+		return m, nil
+
+	case "preview":
+		m.preview = !m.preview
+		return m, m.checkSelectedPreview()
+
+	case "matcher":
+		m.cycleMatcher()
+
+	case "refresh":
+		details := []string{"Fetches the entries published since the last load from " + m.sourceLabel + "."}
+		return m, m.confirm("refresh", "Refresh the index?", details, func(m *model) tea.Cmd { return m.startRefresh(false) })
+
+	case "archive":
+		return m, m.startArchiveSearch()
+
+	case "graph":
+		matcher, err := search.Lookup(m.engine.Matcher())
+		if err == nil && m.setState(stateGraph) {
+			m.graph = newGraphView(matcher)
+			return m, loadModuleGraphCmd()
+		}
+		return m, nil
+
+	case "moved":
+		if pkg, ok := m.selectedPackage(); ok && m.migrations[pkg.Path] != nil {
+			return m, m.searchFor(m.migrations[pkg.Path].to)
+		}
+		return m, nil
+
+	case "upgrade":
+		if m.setState(stateOutdated) {
+			m.outdated = newOutdatedView(m.policy)
+			return m, listOutdatedCmd(m.outdated.dir)
+		}
+		return m, nil
+
+	case "up":
+		m.list, _ = m.list.Update(tea.KeyMsg{Type: tea.KeyUp})
+	case "down":
+		m.list, _ = m.list.Update(tea.KeyMsg{Type: tea.KeyDown})
+
+	default:
+		if msg.Type == tea.KeyRunes {
+			m.input.blurred = false
+		}
+		query := m.input.query
+		m.input, _ = m.input.Update(msg)
+		if m.input.query != query {
+			m.filterPackages()
+		}
+	}
+	return m, m.resolveVisibleLatest()
+}
+
+// searchFor replaces the query with one finding the module path.
+func (m *model) searchFor(path string) tea.Cmd {
+	if m.engine.Matcher() == "regex" {
+		m.input.query = regexp.QuoteMeta(path)
+	} else {
+		m.input.query = "+" + path
+	}
+	m.filterPackages()
+	return m.resolveVisibleLatest()
+}
+
+// cycleMatcher switches to the next registered matcher and re-runs the query.
+func (m *model) cycleMatcher() {
+	if m.remote != nil {
+		// The server searches with its own matcher.
+		return
+	}
+	names := search.Matchers()
+	for i, name := range names {
+		if name == m.engine.Matcher() {
+			m.engine.SetMatcher(names[(i+1)%len(names)])
+			break
+		}
+	}
+	m.archiveQuery, m.archiveMatches = "", nil
+	m.filterPackages()
+}
+
+// selectedPackage returns the package under the cursor, if any.
+func (m model) selectedPackage() (Package, bool) {
+	match, ok := m.list.Selected()
+	if !ok {
+		return Package{}, false
+	}
+	return match.Package, true
+}
+
+// packageVersion returns the newest known version of pkg.
+func (m model) packageVersion(pkg Package) string {
+	if m.expansion.contains(pkg) {
+		return pkg.Version
+	}
+	if latest := m.latestVersions[pkg.Path]; latest != "" {
+		return latest
+	}
+	return pkg.Version
+}
+
+// resolveVisibleLatest starts @latest lookups for visible rows that have not
+// been resolved yet. The index feed lists whichever version was published,
+// which is not necessarily the newest one. Policy checks of the rows, and the
+// check of the selected module for a new path and its preview, start along
+// with them.
+func (m *model) resolveVisibleLatest() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, item := range m.list.Visible() {
+		path := item.Package.Path
+		if _, seen := m.latestVersions[path]; seen || isStdPath(path) {
+			continue
+		}
+		m.latestVersions[path] = ""
+		cmds = append(cmds, fetchLatestCmd(path))
+	}
+	cmds = append(cmds, m.checkVisiblePolicy(), m.checkVisiblePopularity(), m.checkVisibleRepos(), m.checkSelectedMigration(), m.checkSelectedPreview())
+	return tea.Batch(cmds...)
+}
+
+func (m *model) filterPackages() {
+	start := time.Now()
+	matches, err := m.engine.Search(m.input.query)
+	if m.remote != nil {
+		matches, err = nil, nil
+		if m.remoteQuery == m.input.query {
+			matches = m.remoteMatches
+		}
+	}
+	if m.archiveQuery != "" && m.archiveQuery == m.input.query {
+		matches = append(matches, m.archiveMatches...)
+	}
+	m.queryErr = err
+	m.list.SetMatches(m.expandVersions(m.floatFavorites(m.applyPolicy(matches))))
+	m.telemetry.timeFilter(start, len(matches))
+}
+
+func (m model) View() string {
+	defer m.telemetry.timeRender(time.Now())
+	return m.view()
+}
+
+func (m model) view() string {
+	switch m.state {
+	case stateError:
+		if hint := recoveryHint(m.err); hint != "" {
+			return errorStyle.Render(m.finalMessage) + "\n" + statusMessageStyle.Render(hint) + "\n"
+		}
+		return errorStyle.Render(m.finalMessage) + "\n"
+	case stateQuitting:
+		if dryRun != nil {
+			return warningStyle.Render(dryRun.summary()) + "\n"
+		}
+		if m.finalMessage == "" {
+			return ""
+		}
+		return successMessageStyle.Render(m.finalMessage) + "\n"
+	case statePicking:
+		return m.picker.View(m.list.pageSize)
+	case stateMenu:
+		return m.menu.View()
+	case stateHelp:
+		return m.help.View()
+	case stateCategories:
+		return m.categories.View(m.list.pageSize)
+	case stateHistory:
+		return m.history.View(m.list.pageSize)
+	case stateAnnotating:
+		return m.editor.View()
+	case stateLicenses:
+		return m.licenses.View()
+	case stateUpgrading:
+		return m.upgrade.View()
+	case stateOutdated:
+		return m.outdated.View(m.list.pageSize)
+	case stateGraph:
+		return m.graph.View(m.list.pageSize)
+	case stateReplacing:
+		return m.replace.View()
+	case stateFiles:
+		return m.files.View()
+	case stateGrepping:
+		return m.grep.View()
+	case stateConfirming:
+		return m.confirmation.View()
+	case stateDetail:
+		return m.detail.View(m.scans[m.detail.path], m.detailLines(m.detail.path))
+	case stateLoading:
+		s := statusMessageStyle.Render(fmt.Sprintf("Loading Go packages from %s... Please wait.", m.sourceLabel))
+		if m.loadProgress.Pages > 0 {
+			s += "\n" + statusMessageStyle.Render(formatProgress(m.loadProgress))
+		}
+		return s
+	}
+
+	s := strings.Builder{}
+	if m.resuming {
+		s.WriteString(warningStyle.Render("Retrying the incomplete index sync...") + "\n")
+	} else if m.refreshing && !m.backgroundRefresh {
+		s.WriteString(statusMessageStyle.Render("Refreshing the index...") + "\n")
+	} else if m.refreshErr != nil {
+		s.WriteString(errorStyle.Render(fmt.Sprintf("%v. Press Ctrl+R to try again.", m.refreshErr)) + "\n")
+	} else if m.partial != nil {
+		s.WriteString(warningStyle.Render(fmt.Sprintf("Index data incomplete through %s (%s). Press Ctrl+R to retry completion.",
+			m.partial.Through.Format(time.RFC3339), m.partial.Error)) + "\n")
+	} else if m.notice != "" {
+		s.WriteString(warningStyle.Render(m.notice) + "\n")
+	}
+	if prompt := m.tutorialView(); prompt != "" {
+		s.WriteString(prompt + "\n")
+	}
+	s.WriteString(m.input.View() + "\n\n")
+
+	if m.queryErr != nil {
+		s.WriteString(errorStyle.Render(m.queryErr.Error()) + "\n")
+	} else if len(m.list.matches) == 0 && m.input.query != "" {
+		s.WriteString("No packages found matching your query.\n")
+	} else if len(m.list.matches) == 0 {
+		s.WriteString("No packages loaded.\n")
+	} else if m.showsPreview() {
+		s.WriteString(m.withPreview(m.list.View(m.renderRow)))
+	} else {
+		s.WriteString(m.list.View(m.renderRow))
+	}
+
+	s.WriteString("\n")
+	if pkg, ok := m.selectedPackage(); ok {
+		if reason := m.violation(pkg); reason != "" {
+			s.WriteString(errorStyle.Render("Policy: "+reason) + "\n")
+		}
+	}
+	if line := m.migrationLine(); line != "" {
+		s.WriteString(warningStyle.Render(line) + "\n")
+	}
+	if line := m.toolLine(); line != "" {
+		s.WriteString(versionStyle.Render(line) + "\n")
+	}
+	if line := m.vendorLine(); line != "" {
+		s.WriteString(versionStyle.Render(line) + "\n")
+	}
+	if line := m.annotationLine(); line != "" {
+		s.WriteString(versionStyle.Render(line) + "\n")
+	}
+	if line := m.archiveLine(); line != "" {
+		s.WriteString(statusMessageStyle.Render(line) + "\n")
+	}
+	if flash := m.flashView(); flash != "" {
+		s.WriteString(flash + "\n")
+	}
+	s.WriteString(statusMessageStyle.Render(m.statusLine()))
+	if debug := m.telemetry.View(); debug != "" {
+		s.WriteString("\n" + warningStyle.Render(debug))
+	}
+	return s.String()
+}
+
+// renderRow formats one result: icons, the highlighted path, and the version.
+func (m model) renderRow(item search.Match) string {
+	pkg := item.Package
+	if m.expansion.contains(pkg) {
+		return m.renderVersionRow(pkg)
+	}
+	badge := m.policyBadge(pkg)
+	if badge == "" {
+		badge = m.teamBadge(pkg)
+	}
+	badge += m.favoriteBadge(pkg) + m.migrationBadge(pkg) + m.toolBadge(pkg)
+	displayLine := badge + renderPath(pkg.Path, m.engine.Highlight(m.input.query, item))
+	if m.config.Icons {
+		displayLine = m.rowIcons(pkg) + " " + displayLine
+	}
+
+	// Append version, styled, if available
+	version := m.packageVersion(pkg)
+	if label := m.depsVersionLabel(pkg); label != "" {
+		version = label
+	}
+	if version != "" {
+		displayLine += versionStyle.Render(fmt.Sprintf("(%s)", version))
+	}
+	displayLine += m.depsBadge(pkg) + m.stdBadge(pkg)
+	displayLine += m.cgoBadge(pkg)
+	displayLine += m.vendorBadge(pkg)
+	displayLine += m.repoBadge(pkg)
+	return displayLine
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/exp/golden"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/muesli/termenv"
+
+	"gosearch/index"
+	"gosearch/search"
+)
+
+// offlineTransport fails every request, so that lookups of latest versions
+// and the like neither reach the network nor change the frames.
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("offline in tests")
+}
+
+func TestMain(m *testing.M) {
+	// Colors are kept, as the selection shows only in them, but to the 16
+	// ANSI ones so that frames do not depend on the terminal.
+	lipgloss.SetColorProfile(termenv.ANSI)
+	httpClient.Transport = offlineTransport{}
+	os.Exit(m.Run())
+}
+
+var testPackages = []index.Package{
+	{Path: "github.com/gorilla/mux", Version: "v1.8.1", Timestamp: time.Date(2023, 10, 18, 3, 11, 0, 0, time.UTC)},
+	{Path: "github.com/spf13/cobra", Version: "v1.8.0", Timestamp: time.Date(2023, 11, 4, 22, 0, 0, 0, time.UTC)},
+	{Path: "gopkg.in/yaml.v3", Version: "v3.0.1", Timestamp: time.Date(2022, 5, 27, 8, 36, 0, 0, time.UTC)},
+}
+
+// testNow is the time the tests run at, as far as the model can tell.
+var testNow = time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)
+
+// testModel is the model main builds, over source and without anything
+// read from the user's config.
+func testModel(t *testing.T, source index.Source) model {
+	t.Helper()
+	clock = func() time.Time { return testNow }
+	t.Cleanup(func() { clock = time.Now })
+	cfg := defaultConfig()
+	cfg.StandardLibrary = false
+	cfg.GitHubMetadata = false
+	cfg.RefreshInterval = 0
+	keys, err := newKeyMap(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations, err := loadAnnotations(t.TempDir() + "/annotations.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	favorites, err := loadFavorites(t.TempDir() + "/favorites.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	watchlist, err := loadWatchlist(t.TempDir() + "/watchlist.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := model{
+		source:         source,
+		sourceLabel:    "the test index",
+		state:          stateLoading,
+		list:           resultsList{pageSize: 10},
+		keys:           keys,
+		latestVersions: make(map[string]string),
+		dependents:     make(dependentCounts),
+		repos:          make(map[string]*repoMeta),
+		engine:         search.NewEngine(nil),
+		annotations:    annotations,
+		favorites:      favorites,
+		watchlist:      watchlist,
+		config:         cfg,
+		migrations:     make(map[string]*moduleMigration),
+		categories:     newCategoryBrowser(nil),
+	}
+	if err := m.engine.SetMatcher(cfg.Matcher); err != nil {
+		t.Fatal(err)
+	}
+	ranker, err := newRanker(cfg.Ranking, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.engine.SetRanker(ranker)
+	m.engine.SetFilter("tag", annotations.tagFilter)
+	m.engine.SetFilter("is", favorites.filter)
+	if m.statusTemplate, err = parseStatusLine(cfg.StatusLine); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func startModel(t *testing.T, m model) *teatest.TestModel {
+	t.Helper()
+	return teatest.NewTestModel(t, m, teatest.WithInitialTermSize(100, 24))
+}
+
+func waitForOutput(t *testing.T, tm *teatest.TestModel, text string) {
+	t.Helper()
+	teatest.WaitFor(t, tm.Output(), func(b []byte) bool {
+		return bytes.Contains(b, []byte(text))
+	}, teatest.WithDuration(5*time.Second))
+}
+
+// finalFrame quits the program and returns the last screen it drew.
+func finalFrame(t *testing.T, tm *teatest.TestModel) []byte {
+	t.Helper()
+	if err := tm.Quit(); err != nil {
+		t.Fatal(err)
+	}
+	final := tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second))
+	return []byte(final.View())
+}
+
+// blockingSource holds back its entries until release is closed.
+type blockingSource struct {
+	release chan struct{}
+}
+
+func (s blockingSource) Fetch(ctx context.Context, since time.Time) ([]index.Package, error) {
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return testPackages, nil
+}
+
+func TestLoadingFrame(t *testing.T) {
+	source := blockingSource{release: make(chan struct{})}
+	defer close(source.release)
+	tm := startModel(t, testModel(t, source))
+	waitForOutput(t, tm, "Loading")
+	golden.RequireEqual(t, finalFrame(t, tm))
+}
+
+func TestSearchingFrame(t *testing.T) {
+	tm := startModel(t, testModel(t, index.MemorySource{Packages: testPackages}))
+	waitForOutput(t, tm, "Found 3 packages")
+	tm.Type("mux")
+	waitForOutput(t, tm, "Found 1 packages")
+	golden.RequireEqual(t, finalFrame(t, tm))
+}
+
+func TestSelectingFrame(t *testing.T) {
+	tm := startModel(t, testModel(t, index.MemorySource{Packages: testPackages}))
+	waitForOutput(t, tm, "Found 3 packages")
+	// Keys and the quit are handled in the order sent.
+	tm.Send(tea.KeyMsg{Type: tea.KeyDown})
+	frame := finalFrame(t, tm)
+	if got := tm.FinalModel(t).(model).list.selectedIndex; got != 1 {
+		t.Fatalf("selected row %d, want 1", got)
+	}
+	golden.RequireEqual(t, frame)
+}
+
+func TestErrorFrame(t *testing.T) {
+	m := testModel(t, index.MemorySource{Err: &NetworkError{Op: "failed to fetch Go index", URL: "https://index.example/index", Err: errors.New("connection refused")}})
+	tm := startModel(t, m)
+	final := tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second))
+	if final.(model).state != stateError {
+		t.Fatalf("state = %v, want error", final.(model).state)
+	}
+	golden.RequireEqual(t, []byte(final.View()))
+}
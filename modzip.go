@@ -0,0 +1,121 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb/dirhash"
+
+	"gosearch/sumdb"
+)
+
+// maxModuleZipSize is the largest module zip the go command accepts.
+const maxModuleZipSize = 500 << 20
+
+// moduleZipPath is where the zip of path@version is cached, laid out like
+// the module cache's download directory.
+func moduleZipPath(path, version string) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	escapedPath, err := module.EscapePath(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid module path '%s': %w", path, err)
+	}
+	escapedVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return "", fmt.Errorf("invalid version '%s': %w", version, err)
+	}
+	return filepath.Join(dir, "zips", escapedPath, "@v", escapedVersion+".zip"), nil
+}
+
+// openModuleZip opens the zip of path@version, downloading it from the proxy
+// into the cache first if needed. Downloads are checked against the checksum
+// database unless GOSUMDB or GONOSUMDB leave the module out.
+func openModuleZip(path, version string) (*zip.ReadCloser, error) {
+	file, err := moduleZipPath(path, version)
+	if err != nil {
+		return nil, err
+	}
+	if z, err := zip.OpenReader(file); err == nil {
+		return z, nil
+	}
+	if err := downloadModuleZip(path, version, file); err != nil {
+		return nil, err
+	}
+	z, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip of %s@%s: %w", path, version, err)
+	}
+	return z, nil
+}
+
+func downloadModuleZip(path, version, file string) error {
+	escaped, err := module.EscapeVersion(version)
+	if err != nil {
+		return fmt.Errorf("invalid version '%s': %w", version, err)
+	}
+	resp, err := proxyGet(path, "@v/"+escaped+".zip")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), "download-*.zip")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, io.LimitReader(resp.Body, maxModuleZipSize+1))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download zip of %s@%s: %w", path, version, err)
+	}
+	if n > maxModuleZipSize {
+		return fmt.Errorf("zip of %s@%s is larger than %s", path, version, formatBytes(maxModuleZipSize))
+	}
+
+	if err := verifyModuleZip(path, version, tmp.Name()); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return fmt.Errorf("failed to save zip of %s@%s: %w", path, version, err)
+	}
+	return nil
+}
+
+// verifyModuleZip checks the zip at file against the checksum database's
+// hash of path@version.
+func verifyModuleZip(path, version, file string) error {
+	db, err := sumdb.New(sumDBConfig())
+	if err != nil {
+		return err
+	}
+	want, err := db.Hash(path, version)
+	if errors.Is(err, sumdb.ErrDisabled) || errors.Is(err, sumdb.ErrExcluded) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	have, err := dirhash.HashZip(file, dirhash.Hash1)
+	if err != nil {
+		return fmt.Errorf("failed to hash zip of %s@%s: %w", path, version, err)
+	}
+	if have != want {
+		return fmt.Errorf("checksum mismatch for zip of %s@%s: downloaded %s, %s has %s", path, version, have, db.Name(), want)
+	}
+	return nil
+}
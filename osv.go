@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+	"golang.org/x/mod/semver"
+)
+
+const osvQueryURL = "https://api.osv.dev/v1/query"
+
+// osvInterval is the least time between two requests to osv.dev, so that
+// looking up one result after another does not flood it.
+const osvInterval = 500 * time.Millisecond
+
+// vulnerability is a known vulnerability of a module version.
+type vulnerability struct {
+	ID      string
+	Summary string
+	// Severity is low, medium, high or critical, or empty if the database
+	// does not rate it.
+	Severity string
+	// Fixed lists the versions that fix it, oldest first.
+	Fixed []string
+}
+
+// osvRecord is the part of an OSV entry gosearch reads.
+type osvRecord struct {
+	ID       string `json:"id"`
+	Summary  string `json:"summary"`
+	Affected []struct {
+		Package struct {
+			Name string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string `json:"type"`
+			Events []struct {
+				Fixed string `json:"fixed"`
+			} `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+// vulnerability keeps what the record says about the module name.
+func (r osvRecord) vulnerability(name string) vulnerability {
+	v := vulnerability{ID: r.ID, Summary: r.Summary}
+	switch s := strings.ToLower(r.DatabaseSpecific.Severity); s {
+	case "moderate":
+		v.Severity = "medium"
+	case "low", "medium", "high", "critical":
+		v.Severity = s
+	}
+	for _, a := range r.Affected {
+		if a.Package.Name != name {
+			continue
+		}
+		for _, rng := range a.Ranges {
+			for _, e := range rng.Events {
+				// OSV leaves the v off Go versions.
+				if fixed := "v" + e.Fixed; e.Fixed != "" && !slices.Contains(v.Fixed, fixed) {
+					v.Fixed = append(v.Fixed, fixed)
+				}
+			}
+		}
+	}
+	semver.Sort(v.Fixed)
+	return v
+}
+
+// osvClient queries osv.dev, at most once per osvInterval, and remembers
+// the answers for the session.
+type osvClient struct {
+	mu    sync.Mutex
+	next  time.Time
+	cache map[string][]vulnerability
+}
+
+var osv = &osvClient{cache: make(map[string][]vulnerability)}
+
+// lookup lists the known vulnerabilities of the module path at version.
+func (c *osvClient) lookup(path, version string) ([]vulnerability, error) {
+	name, version := osvName(path, version)
+	key := name + "@" + version
+	c.mu.Lock()
+	if vulns, ok := c.cache[key]; ok {
+		c.mu.Unlock()
+		return vulns, nil
+	}
+	wait := time.Until(c.next)
+	c.next = time.Now().Add(max(wait, 0) + osvInterval)
+	c.mu.Unlock()
+
+	select {
+	case <-time.After(wait):
+	case <-appCtx.Done():
+		return nil, appCtx.Err()
+	}
+	vulns, err := queryOSV(name, strings.TrimPrefix(version, "v"))
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.cache[key] = vulns
+	c.mu.Unlock()
+	return vulns, nil
+}
+
+// cached returns the vulnerabilities of the module path at version if they
+// were looked up already, without asking osv.dev.
+func (c *osvClient) cached(path, version string) ([]vulnerability, bool) {
+	name, version := osvName(path, version)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	vulns, ok := c.cache[name+"@"+version]
+	return vulns, ok
+}
+
+// osvName returns the name and version osv.dev knows a module version by.
+// The standard library, whose version is a Go release, is stdlib.
+func osvName(path, version string) (string, string) {
+	if isStdPath(path) {
+		return "stdlib", strings.TrimPrefix(version, "go")
+	}
+	return path, version
+}
+
+// queryOSV asks osv.dev for the vulnerabilities of the Go module name at
+// version, given without the v.
+func queryOSV(name, version string) ([]vulnerability, error) {
+	body, err := json.Marshal(map[string]any{
+		"package": map[string]string{"name": name, "ecosystem": "Go"},
+		"version": version,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode osv.dev query: %w", err)
+	}
+	req, err := http.NewRequestWithContext(appCtx, http.MethodPost, osvQueryURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create osv.dev request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, &NetworkError{Op: "failed to query osv.dev", URL: osvQueryURL, Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &NetworkError{Op: "received non-OK status from osv.dev", URL: osvQueryURL, Err: errors.New(resp.Status)}
+	}
+
+	var result struct {
+		Vulns []osvRecord `json:"vulns"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, &ParseError{What: "osv.dev response", Err: err}
+	}
+	vulns := make([]vulnerability, len(result.Vulns))
+	for i, r := range result.Vulns {
+		vulns[i] = r.vulnerability(name)
+		if name == "stdlib" {
+			for j, fixed := range vulns[i].Fixed {
+				vulns[i].Fixed[j] = "go" + strings.TrimPrefix(fixed, "v")
+			}
+		}
+	}
+	slices.SortFunc(vulns, func(a, b vulnerability) int { return strings.Compare(a.ID, b.ID) })
+	return vulns, nil
+}
+
+// line describes v in a few words: its ID, severity and first fix.
+func (v vulnerability) line() string {
+	var notes []string
+	if v.Severity != "" {
+		notes = append(notes, v.Severity)
+	}
+	if len(v.Fixed) > 0 {
+		notes = append(notes, "fixed in "+v.Fixed[0])
+	} else {
+		notes = append(notes, "no fix")
+	}
+	return v.ID + " (" + strings.Join(notes, ", ") + ")"
+}
+
+type vulnsLoadedMsg struct {
+	path    string
+	version string
+	vulns   []vulnerability
+	err     error
+	// flash is set for the vulns key, which flashes the outcome.
+	flash bool
+}
+
+func lookupVulnsCmd(path, version string, flash bool) tea.Cmd {
+	return func() tea.Msg {
+		vulns, err := osv.lookup(path, version)
+		return vulnsLoadedMsg{path: path, version: version, vulns: vulns, err: err, flash: flash}
+	}
+}
+
+// checkSelectedVulns looks up the known vulnerabilities of the selected
+// result at the version the project requires, in --deps mode, or the newest
+// known one.
+func (m *model) checkSelectedVulns() tea.Cmd {
+	pkg, ok := m.selectedPackage()
+	if !ok {
+		return nil
+	}
+	version, ok := m.requiredVersion(pkg)
+	if !ok {
+		version = m.packageVersion(pkg)
+	}
+	return tea.Batch(
+		m.flashMessage(fmt.Sprintf("Looking up vulnerabilities of %s@%s on osv.dev...", pkg.Path, version), false),
+		lookupVulnsCmd(pkg.Path, version, true),
+	)
+}
+
+// vulnsFlash flashes the vulnerabilities the vulns key found.
+func (m *model) vulnsFlash(msg vulnsLoadedMsg) tea.Cmd {
+	if msg.err != nil {
+		return m.flashMessage(msg.err.Error(), true)
+	}
+	target := msg.path + "@" + msg.version
+	if len(msg.vulns) == 0 {
+		return m.flashMessage(fmt.Sprintf("osv.dev knows of no vulnerabilities in %s.", target), false)
+	}
+	lines := make([]string, len(msg.vulns))
+	for i, v := range msg.vulns {
+		lines[i] = v.line()
+	}
+	noun := "vulnerability"
+	if len(msg.vulns) > 1 {
+		noun = "vulnerabilities"
+	}
+	return m.flashMessage(fmt.Sprintf("%s has %d known %s: %s.", target, len(msg.vulns), noun, strings.Join(lines, ", ")), true)
+}
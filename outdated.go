@@ -0,0 +1,391 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/bubbletea"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+// outdatedModule is a direct dependency of the current module with a newer
+// version available.
+type outdatedModule struct {
+	Path    string
+	Version string
+	Update  string
+}
+
+// listOutdated asks the go command which direct dependencies of the module
+// in dir have newer versions. Only those are listed, as looking up updates
+// for the whole build list is slow and fails on any module the proxy
+// no longer serves.
+func listOutdated(dir string) ([]outdatedModule, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	f, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go.mod: %w", err)
+	}
+	args := []string{"list", "-m", "-u", "-json"}
+	for _, r := range f.Require {
+		if !r.Indirect {
+			args = append(args, r.Mod.Path)
+		}
+	}
+	if len(args) == 4 {
+		return nil, nil
+	}
+
+	defer track()()
+	cmd := command("go", args...)
+	cmd.Dir = dir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list -m -u failed: %w\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var outdated []outdatedModule
+	dec := json.NewDecoder(strings.NewReader(string(out)))
+	for {
+		var m struct {
+			Path    string
+			Version string
+			Update  *struct{ Version string }
+		}
+		if err := dec.Decode(&m); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse go list output: %w", err)
+		}
+		if m.Update != nil {
+			outdated = append(outdated, outdatedModule{Path: m.Path, Version: m.Version, Update: m.Update.Version})
+		}
+	}
+	return outdated, nil
+}
+
+// runOutdated implements `gosearch outdated`: it prints the direct
+// dependencies of the current module that have newer versions.
+func runOutdated(args []string, w io.Writer) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: gosearch outdated")
+	}
+	cwd, _ := os.Getwd()
+	dir := findModuleRoot(cwd)
+	if dir == "" {
+		return fmt.Errorf("not inside a Go module")
+	}
+	outdated, err := listOutdated(dir)
+	if err != nil {
+		return err
+	}
+	if len(outdated) == 0 {
+		fmt.Fprintln(w, "All direct dependencies are up to date.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, m := range outdated {
+		fmt.Fprintf(tw, "%s\t%s\t→ %s\n", m.Path, m.Version, m.Update)
+	}
+	return tw.Flush()
+}
+
+// outdatedView is the sub-screen listing outdated dependencies, where some
+// can be chosen and upgraded one after the other.
+type outdatedView struct {
+	dir      string
+	policy   *Policy
+	loading  bool
+	modules  []outdatedModule
+	chosen   map[string]bool
+	selected int
+	err      error
+
+	// targets are the upgrades being applied, and step the one running,
+	// as numbered by stepName. before is the module graph they started
+	// from, and summary what changed in it once all steps ran.
+	targets []outdatedModule
+	step    int
+	before  map[string]string
+	summary []string
+	done    bool
+}
+
+type outdatedListedMsg struct {
+	modules []outdatedModule
+	err     error
+}
+
+// bulkUpgradeMsg asks for the chosen upgrades to be applied.
+type bulkUpgradeMsg struct {
+	targets []outdatedModule
+}
+
+// upgradeStepMsg reports the outcome of a step of applying upgrades.
+type upgradeStepMsg struct {
+	step    int
+	err     error
+	before  map[string]string
+	summary []string
+}
+
+func newOutdatedView(policy *Policy) outdatedView {
+	cwd, _ := os.Getwd()
+	return outdatedView{dir: findModuleRoot(cwd), policy: policy, loading: true, chosen: make(map[string]bool)}
+}
+
+func listOutdatedCmd(dir string) tea.Cmd {
+	return func() tea.Msg {
+		if dir == "" {
+			return outdatedListedMsg{err: fmt.Errorf("not inside a Go module")}
+		}
+		modules, err := listOutdated(dir)
+		return outdatedListedMsg{modules: modules, err: err}
+	}
+}
+
+// start begins applying targets.
+func (o outdatedView) start(targets []outdatedModule) (outdatedView, tea.Cmd) {
+	o.targets, o.step = targets, 0
+	return o, o.stepCmd(0)
+}
+
+// stepName describes step i: reading the module graph, a go get for each
+// target, go mod tidy, and comparing the module graph.
+func (o outdatedView) stepName(i int) string {
+	switch {
+	case i == 0:
+		return "Read the module graph"
+	case i <= len(o.targets):
+		t := o.targets[i-1]
+		return "go get " + t.Path + "@" + t.Update
+	case i == len(o.targets)+1:
+		return "go mod tidy"
+	}
+	return "Compare the module graph"
+}
+
+func (o outdatedView) lastStep() int {
+	return len(o.targets) + 2
+}
+
+func (o outdatedView) stepCmd(i int) tea.Cmd {
+	dir, before := o.dir, o.before
+	run := func(args ...string) error {
+		if out, err := runMutating(dir, "go", args...); err != nil {
+			return fmt.Errorf("go %s failed: %w\n%s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+
+	switch {
+	case i == 0:
+		return func() tea.Msg {
+			graph, err := projectModuleGraph(dir)
+			return upgradeStepMsg{step: i, err: err, before: graph}
+		}
+	case i <= len(o.targets):
+		t, p := o.targets[i-1], o.policy
+		return func() tea.Msg {
+			if p != nil {
+				reason, err := p.check(t.Path, t.Update)
+				if err != nil {
+					return upgradeStepMsg{step: i, err: fmt.Errorf("failed to check %s@%s against the policy: %w", t.Path, t.Update, err)}
+				}
+				if reason != "" {
+					return upgradeStepMsg{step: i, err: fmt.Errorf("policy forbids %s@%s: %s", t.Path, t.Update, reason)}
+				}
+			}
+			// The first upgrade keeps go.mod as it was before all of them.
+			if i == 1 {
+				if err := backupModFiles(dir, fmt.Sprintf("the bulk upgrade (%d modules)", len(o.targets))); err != nil {
+					return upgradeStepMsg{step: i, err: err}
+				}
+			}
+			return upgradeStepMsg{step: i, err: run("get", t.Path+"@"+t.Update)}
+		}
+	case i == len(o.targets)+1:
+		return func() tea.Msg {
+			return upgradeStepMsg{step: i, err: run("mod", "tidy")}
+		}
+	}
+	return func() tea.Msg {
+		after, err := projectModuleGraph(dir)
+		if err != nil {
+			return upgradeStepMsg{step: i, err: err}
+		}
+		return upgradeStepMsg{step: i, summary: graphChanges(before, after)}
+	}
+}
+
+// graphChanges describes how the module graph went from before to after,
+// sorted by module path.
+func graphChanges(before, after map[string]string) []string {
+	var paths []string
+	for path := range after {
+		paths = append(paths, path)
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var changes []string
+	for _, path := range paths {
+		old, hadOld := before[path]
+		v, hasNew := after[path]
+		switch {
+		case !hadOld:
+			changes = append(changes, fmt.Sprintf("+ %s %s", path, v))
+		case !hasNew:
+			changes = append(changes, fmt.Sprintf("- %s %s", path, old))
+		case semver.Compare(v, old) > 0:
+			changes = append(changes, fmt.Sprintf("↑ %s %s → %s", path, old, v))
+		case v != old:
+			changes = append(changes, fmt.Sprintf("↓ %s %s → %s", path, old, v))
+		}
+	}
+	return changes
+}
+
+func (o outdatedView) Update(msg tea.Msg) (outdatedView, tea.Cmd) {
+	switch msg := msg.(type) {
+	case outdatedListedMsg:
+		o.loading = false
+		o.modules, o.err = msg.modules, msg.err
+
+	case upgradeStepMsg:
+		if msg.step != o.step || o.done {
+			return o, nil
+		}
+		if msg.err != nil {
+			o.err, o.done = msg.err, true
+			return o, nil
+		}
+		if msg.step == 0 {
+			o.before = msg.before
+		}
+		if msg.step == o.lastStep() {
+			o.summary, o.done = msg.summary, true
+			return o, nil
+		}
+		o.step++
+		return o, o.stepCmd(o.step)
+
+	case tea.KeyMsg:
+		if o.targets != nil && !o.done {
+			return o, nil
+		}
+		switch msg.String() {
+		case "esc":
+			return o, closeOverlay
+		}
+		if o.targets != nil || len(o.modules) == 0 {
+			return o, nil
+		}
+		switch msg.String() {
+		case "up", "k":
+			o.selected = (o.selected - 1 + len(o.modules)) % len(o.modules)
+		case "down", "j":
+			o.selected = (o.selected + 1) % len(o.modules)
+		case " ":
+			path := o.modules[o.selected].Path
+			o.chosen[path] = !o.chosen[path]
+		case "A":
+			// Choose all, or none if all are chosen already.
+			all := slices.ContainsFunc(o.modules, func(m outdatedModule) bool { return !o.chosen[m.Path] })
+			for _, m := range o.modules {
+				o.chosen[m.Path] = all
+			}
+		case "enter":
+			var targets []outdatedModule
+			for _, m := range o.modules {
+				if o.chosen[m.Path] {
+					targets = append(targets, m)
+				}
+			}
+			if len(targets) > 0 {
+				return o, func() tea.Msg { return bulkUpgradeMsg{targets: targets} }
+			}
+		}
+	}
+	return o, nil
+}
+
+func (o outdatedView) View(pageSize int) string {
+	s := strings.Builder{}
+	s.WriteString("Outdated direct dependencies\n\n")
+
+	switch {
+	case o.loading:
+		s.WriteString(statusMessageStyle.Render("Checking for newer versions with go list -m -u...") + "\n")
+	case o.targets != nil:
+		for i := 0; i < o.step; i++ {
+			s.WriteString(successMessageStyle.Render("✓ "+o.stepName(i)) + "\n")
+		}
+		switch {
+		case o.err != nil:
+			s.WriteString(errorStyle.Render("✗ "+o.stepName(o.step)) + "\n")
+			s.WriteString(errorStyle.Render(o.err.Error()) + "\n")
+		case o.done:
+			s.WriteString(successMessageStyle.Render("✓ "+o.stepName(o.step)) + "\n\n")
+			if len(o.summary) == 0 {
+				s.WriteString(itemStyle.Render("The module graph did not change.") + "\n")
+			} else {
+				s.WriteString(itemStyle.Render(fmt.Sprintf("%d %s changed in the module graph:", len(o.summary), plural(len(o.summary), "module", "modules"))) + "\n")
+			}
+			for _, line := range o.summary {
+				s.WriteString(itemStyle.Render("  "+line) + "\n")
+			}
+		default:
+			s.WriteString(statusMessageStyle.Render("  "+o.stepName(o.step)+"...") + "\n")
+		}
+	case o.err != nil:
+		s.WriteString(errorStyle.Render(o.err.Error()) + "\n")
+	case len(o.modules) == 0:
+		s.WriteString(successMessageStyle.Render("All direct dependencies are up to date.") + "\n")
+	default:
+		offset := max(o.selected-pageSize+1, 0)
+		end := min(offset+pageSize, len(o.modules))
+		for i := offset; i < end; i++ {
+			m := o.modules[i]
+			box := "[ ]"
+			if o.chosen[m.Path] {
+				box = "[x]"
+			}
+			line := fmt.Sprintf("%s %s %s → %s", box, padWidth(m.Path, 50), m.Version, m.Update)
+			if i == o.selected {
+				s.WriteString(selectedItemStyle.Render(line) + "\n")
+			} else {
+				s.WriteString(itemStyle.Render(line) + "\n")
+			}
+		}
+	}
+
+	s.WriteString("\n")
+	switch {
+	case o.targets != nil && !o.done:
+		s.WriteString(statusMessageStyle.Render("Applying the chosen upgrades..."))
+	case o.targets != nil || len(o.modules) == 0:
+		s.WriteString(statusMessageStyle.Render("Press Esc to go back."))
+	default:
+		s.WriteString(statusMessageStyle.Render("Use ↑↓ to navigate, Space to choose, Shift+A to choose all, Enter to upgrade, Esc to go back."))
+	}
+	return s.String()
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+// versionPicker is the sub-screen listing every published version of a module.
+type versionPicker struct {
+	path     string
+	versions []string
+	selected int
+	loading  bool
+	keys     keyMap
+}
+
+type versionsLoadedMsg struct {
+	path     string
+	versions []string
+}
+
+// versionChosenMsg is sent when a version is picked.
+type versionChosenMsg struct {
+	path    string
+	version string
+}
+
+func fetchVersionsCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		versions, err := fetchVersions(path)
+		if err != nil {
+			return errMsg(fmt.Errorf("failed to list versions of '%s': %w", path, err))
+		}
+		return versionsLoadedMsg{path: path, versions: versions}
+	}
+}
+
+func (p versionPicker) Update(msg tea.Msg) (versionPicker, tea.Cmd) {
+	switch msg := msg.(type) {
+	case versionsLoadedMsg:
+		if msg.path == p.path {
+			p.versions = msg.versions
+			p.loading = false
+		}
+
+	case tea.KeyMsg:
+		if msg.String() == "esc" {
+			return p, closeOverlay
+		}
+		switch p.keys.command(msg) {
+		case "up":
+			if len(p.versions) > 0 {
+				p.selected = (p.selected - 1 + len(p.versions)) % len(p.versions)
+			}
+
+		case "down":
+			if len(p.versions) > 0 {
+				p.selected = (p.selected + 1) % len(p.versions)
+			}
+
+		case "enter":
+			if len(p.versions) > 0 {
+				chosen := versionChosenMsg{path: p.path, version: p.versions[p.selected]}
+				return p, func() tea.Msg { return chosen }
+			}
+		}
+	}
+	return p, nil
+}
+
+func (p versionPicker) View(pageSize int) string {
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf("Versions of %s\n\n", inputStyle.Render(p.path)))
+
+	switch {
+	case p.loading:
+		s.WriteString(statusMessageStyle.Render("Loading versions from proxy.golang.org..."))
+		return s.String()
+	case len(p.versions) == 0:
+		s.WriteString("No tagged versions published.\n")
+	default:
+		offset := 0
+		if p.selected >= pageSize {
+			offset = p.selected - pageSize + 1
+		}
+		end := min(offset+pageSize, len(p.versions))
+		for i := offset; i < end; i++ {
+			if i == p.selected {
+				s.WriteString(selectedItemStyle.Render(p.versions[i]))
+			} else {
+				s.WriteString(itemStyle.Render(p.versions[i]))
+			}
+			s.WriteString("\n")
+		}
+	}
+
+	s.WriteString("\n")
+	s.WriteString(statusMessageStyle.Render(joinHints(
+		p.keys.hint("to navigate", "up", "down"),
+		p.keys.hint("to copy the pinned path and quit", "enter"),
+		"Esc to go back")))
+	return s.String()
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+	"golang.org/x/mod/module"
+	"gopkg.in/yaml.v3"
+
+	"gosearch/search"
+)
+
+// Policy is an organization's rules for which packages may be used. Path
+// patterns are globs matched against path prefixes, as in GOPRIVATE.
+type Policy struct {
+	// Mode is "badge" to mark non-compliant packages, the default, or "hide"
+	// to leave them out of the results.
+	Mode string `yaml:"mode"`
+	// Allow, if set, lists the only paths that may be used; Deny lists paths
+	// that may not.
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+	// Licenses, if set, lists the SPDX identifiers a package must be
+	// licensed under one of.
+	Licenses []string `yaml:"licenses"`
+	// MaxSeverity is the highest advisory severity tolerated: low, medium,
+	// high, or critical.
+	MaxSeverity string `yaml:"max_severity"`
+}
+
+// severity is an advisory severity with the lowest CVSS v3 score it covers.
+type severity struct {
+	name  string
+	floor float64
+}
+
+// severities are in increasing order.
+var severities = []severity{{"low", 0.1}, {"medium", 4}, {"high", 7}, {"critical", 9}}
+
+// severityRank returns the position of the named severity in severities.
+func severityRank(name string) int {
+	return slices.IndexFunc(severities, func(s severity) bool { return s.name == name })
+}
+
+// scoreRank returns the position in severities of the severity of a CVSS v3
+// score, or -1 for a score of zero.
+func scoreRank(score float64) int {
+	rank := -1
+	for i, s := range severities {
+		if score >= s.floor {
+			rank = i
+		}
+	}
+	return rank
+}
+
+// loadPolicy reads the policy file at path, where a leading ~/ stands for
+// the home directory.
+func loadPolicy(path string) (*Policy, error) {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate home directory: %w", err)
+		}
+		path = filepath.Join(home, rest)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, &ParseError{What: "policy file", Path: path, Err: err}
+	}
+	switch p.Mode {
+	case "":
+		p.Mode = "badge"
+	case "badge", "hide":
+	default:
+		return nil, fmt.Errorf("unknown policy mode '%s' in %s", p.Mode, path)
+	}
+	p.MaxSeverity = strings.ToLower(p.MaxSeverity)
+	if p.MaxSeverity != "" && severityRank(p.MaxSeverity) < 0 {
+		return nil, fmt.Errorf("unknown max_severity '%s' in %s", p.MaxSeverity, path)
+	}
+	return &p, nil
+}
+
+// checkPath returns why path breaks the path rules, or "" if it does not.
+func (p *Policy) checkPath(path string) string {
+	if len(p.Deny) > 0 && module.MatchPrefixPatterns(strings.Join(p.Deny, ","), path) {
+		return "denied by policy"
+	}
+	if len(p.Allow) > 0 && !module.MatchPrefixPatterns(strings.Join(p.Allow, ","), path) {
+		return "not on the allow list"
+	}
+	return ""
+}
+
+// needsLookup reports whether checking a package takes a deps.dev lookup.
+func (p *Policy) needsLookup() bool {
+	return len(p.Licenses) > 0 || p.MaxSeverity != ""
+}
+
+// check returns why path@version breaks the policy, or "" if it complies.
+// License and advisory rules are checked against deps.dev.
+func (p *Policy) check(path, version string) (string, error) {
+	if reason := p.checkPath(path); reason != "" || !p.needsLookup() {
+		return reason, nil
+	}
+
+	info, err := fetchDepsDevVersion(path, version)
+	if err != nil {
+		return "", err
+	}
+	if len(p.Licenses) > 0 {
+		allowed := slices.ContainsFunc(info.Licenses, func(l string) bool {
+			return slices.ContainsFunc(p.Licenses, func(a string) bool { return strings.EqualFold(a, l) })
+		})
+		if !allowed {
+			license := "no known license"
+			if len(info.Licenses) > 0 {
+				license = "license " + strings.Join(info.Licenses, ", ")
+			}
+			return license + " not allowed", nil
+		}
+	}
+	if p.MaxSeverity != "" && severityRank(p.MaxSeverity) < len(severities)-1 {
+		for _, key := range info.AdvisoryKeys {
+			a, err := fetchAdvisory(key.ID)
+			if err != nil {
+				return "", err
+			}
+			if scoreRank(a.CVSS3) > severityRank(p.MaxSeverity) {
+				return fmt.Sprintf("%s (CVSS %.1f) is above %s severity", a.ID, a.CVSS3, p.MaxSeverity), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// policyCheckedMsg carries the outcome of checking a visible package.
+type policyCheckedMsg struct {
+	path   string
+	reason string
+	err    error
+}
+
+func checkPolicyCmd(p *Policy, path, version string) tea.Cmd {
+	return func() tea.Msg {
+		reason, err := p.check(path, version)
+		return policyCheckedMsg{path: path, reason: reason, err: err}
+	}
+}
+
+// violation returns why pkg breaks the policy, as far as it is known yet.
+func (m model) violation(pkg Package) string {
+	if m.policy == nil {
+		return ""
+	}
+	if reason := m.policy.checkPath(pkg.Path); reason != "" {
+		return reason
+	}
+	return m.violations[pkg.Path]
+}
+
+// checkVisiblePolicy starts deps.dev checks for visible rows that have not
+// been checked yet.
+func (m *model) checkVisiblePolicy() tea.Cmd {
+	if m.policy == nil || !m.policy.needsLookup() {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for _, item := range m.list.Visible() {
+		path := item.Package.Path
+		if _, seen := m.violations[path]; seen || isStdPath(path) || m.policy.checkPath(path) != "" {
+			continue
+		}
+		m.violations[path] = ""
+		cmds = append(cmds, checkPolicyCmd(m.policy, path, m.packageVersion(item.Package)))
+	}
+	return tea.Batch(cmds...)
+}
+
+// applyPolicy drops the matches known to break the policy in hide mode.
+func (m model) applyPolicy(matches []search.Match) []search.Match {
+	if m.policy == nil || m.policy.Mode != "hide" {
+		return matches
+	}
+	return slices.DeleteFunc(matches, func(match search.Match) bool { return m.violation(match.Package) != "" })
+}
+
+// policyBadge marks results that break the policy.
+func (m model) policyBadge(pkg Package) string {
+	if m.violation(pkg) == "" {
+		return ""
+	}
+	return errorStyle.Render("⊘") + " "
+}
+
+// policyGateCmd checks path@version against the policy before next runs,
+// failing instead if it does not comply.
+func policyGateCmd(p *Policy, path, version string, next tea.Cmd) tea.Cmd {
+	if p == nil {
+		return next
+	}
+	return func() tea.Msg {
+		reason, err := p.check(path, version)
+		if err != nil {
+			return errMsg(fmt.Errorf("failed to check %s@%s against the policy: %w", path, version, err))
+		}
+		if reason != "" {
+			return errMsg(fmt.Errorf("policy forbids %s@%s: %s", path, version, reason))
+		}
+		return next()
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// runPopup implements `gosearch popup`: it relaunches gosearch in a tmux
+// popup, or a kitty overlay outside tmux, and types the selected import path
+// into the pane it was started from. Arguments after the flags are passed on
+// to the relaunched gosearch.
+func runPopup(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("popup", flag.ContinueOnError)
+	width := fs.String("width", "80%", "popup `width`, in cells or percent of the window (tmux only)")
+	height := fs.String("height", "60%", "popup `height`, in cells or percent of the window (tmux only)")
+	printOnly := fs.Bool("print", false, "write the selection to stdout instead of typing it into the pane")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate gosearch: %w", err)
+	}
+	dir, err := os.MkdirTemp("", "gosearch-popup-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	out, done := filepath.Join(dir, "selection"), filepath.Join(dir, "done")
+
+	// The inner gosearch prints its selection, and the TUI goes to stderr
+	// because stdout is redirected.
+	inner := []string{exe, "--enter-action", "print"}
+	inner = append(inner, fs.Args()...)
+	script := fmt.Sprintf("%s > %s; touch %s", shellJoin(inner), shellQuote(out), shellQuote(done))
+	cwd, _ := os.Getwd()
+
+	var emit func(text string) error
+	wait := false
+	switch {
+	case os.Getenv("TMUX") != "":
+		pane, err := exec.Command("tmux", "display-message", "-p", "#{pane_id}").Output()
+		if err != nil {
+			return fmt.Errorf("failed to find the tmux pane: %w", err)
+		}
+		target := strings.TrimSpace(string(pane))
+		popup := exec.Command("tmux", "display-popup", "-E", "-d", cwd, "-w", *width, "-h", *height, script)
+		if out, err := popup.CombinedOutput(); err != nil {
+			return fmt.Errorf("tmux display-popup failed: %w\n%s", err, strings.TrimSpace(string(out)))
+		}
+		emit = func(text string) error {
+			return exec.Command("tmux", "send-keys", "-t", target, "-l", text).Run()
+		}
+	case os.Getenv("KITTY_WINDOW_ID") != "":
+		window := "id:" + os.Getenv("KITTY_WINDOW_ID")
+		launch := exec.Command("kitty", "@", "launch", "--type=overlay", "--cwd", cwd, "sh", "-c", script)
+		if out, err := launch.CombinedOutput(); err != nil {
+			return fmt.Errorf("kitty @ launch failed: %w\n%s", err, strings.TrimSpace(string(out)))
+		}
+		emit = func(text string) error {
+			return exec.Command("kitty", "@", "send-text", "--match", window, text).Run()
+		}
+		wait = true
+	default:
+		return fmt.Errorf("gosearch popup needs to run inside tmux or kitty")
+	}
+
+	// Unlike a tmux popup, a kitty overlay runs on its own, so wait for it
+	// to finish.
+	for wait {
+		if _, err := os.Stat(done); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		return fmt.Errorf("failed to read the selection: %w", err)
+	}
+	selection := strings.TrimSpace(string(data))
+	if selection == "" {
+		return nil
+	}
+	if *printOnly {
+		fmt.Fprintln(w, selection)
+		return nil
+	}
+	if err := emit(selection); err != nil {
+		return fmt.Errorf("failed to type the selection into the pane: %w", err)
+	}
+	return nil
+}
+
+// shellQuote quotes s for sh, unless it only holds characters that stand
+// for themselves.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./@=:+,%") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = shellQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
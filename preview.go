@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// previewMinWidth is the narrowest terminal the preview pane is shown in;
+// below it the results keep the whole width.
+const previewMinWidth = 80
+
+// modulePreview is what the preview pane shows of a module: its @latest
+// info and what its go.mod at that version declares.
+type modulePreview struct {
+	loading   bool
+	latest    VersionInfo
+	goVersion string
+	// direct and indirect count the requirements of the go.mod.
+	direct, indirect int
+	err              error
+}
+
+type previewLoadedMsg struct {
+	path    string
+	preview modulePreview
+}
+
+func fetchPreviewCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		latest, err := fetchLatest(path)
+		if err != nil {
+			return previewLoadedMsg{path: path, preview: modulePreview{err: fmt.Errorf("failed to look up '%s': %w", path, err)}}
+		}
+		p := modulePreview{latest: latest}
+		f, err := fetchGoMod(path, latest.Version)
+		if err != nil {
+			p.err = err
+			return previewLoadedMsg{path: path, preview: p}
+		}
+		if f.Go != nil {
+			p.goVersion = f.Go.Version
+		}
+		for _, r := range f.Require {
+			if r.Indirect {
+				p.indirect++
+			} else {
+				p.direct++
+			}
+		}
+		return previewLoadedMsg{path: path, preview: p}
+	}
+}
+
+// checkSelectedPreview starts fetching the preview of the selected module
+// while the pane is open, once per module and session.
+func (m *model) checkSelectedPreview() tea.Cmd {
+	if !m.preview {
+		return nil
+	}
+	pkg, ok := m.selectedPackage()
+	if !ok || isStdPath(pkg.Path) {
+		return nil
+	}
+	if _, seen := m.previews[pkg.Path]; seen {
+		return nil
+	}
+	m.previews[pkg.Path] = modulePreview{loading: true}
+	return fetchPreviewCmd(pkg.Path)
+}
+
+// showsPreview reports whether the results share the screen with the
+// preview pane.
+func (m model) showsPreview() bool {
+	return m.preview && m.width >= previewMinWidth
+}
+
+// previewView renders the pane for the selected module, width columns wide.
+func (m model) previewView(width int) string {
+	pkg, ok := m.selectedPackage()
+	if !ok {
+		return ""
+	}
+	p := m.previews[pkg.Path]
+
+	// Lines are cut to the pane, less the padding of the item styles.
+	item := func(text string) string { return itemStyle.Render(clipWidth(text, width-2)) + "\n" }
+	s := strings.Builder{}
+	s.WriteString(inputStyle.Render(clipWidth(pkg.Path, width)) + "\n\n")
+	switch {
+	case isStdPath(pkg.Path):
+		s.WriteString(item("Standard library of " + pkg.Version))
+	case p.loading:
+		s.WriteString(statusMessageStyle.Render(clipWidth("Loading from proxy.golang.org...", width)) + "\n")
+	case p.err != nil:
+		s.WriteString(errorStyle.Render(clipWidth(p.err.Error(), width)) + "\n")
+	default:
+		s.WriteString(item("Latest:    " + p.latest.Version))
+		if !p.latest.Time.IsZero() {
+			s.WriteString(item("Published: " + p.latest.Time.Format("2006-01-02 15:04 MST")))
+		}
+		goVersion := "not declared"
+		if p.goVersion != "" {
+			goVersion = p.goVersion
+		}
+		s.WriteString(item("Go:        " + goVersion))
+		s.WriteString(item(fmt.Sprintf("Requires:  %d direct, %d indirect", p.direct, p.indirect)))
+	}
+	return s.String()
+}
+
+// withPreview puts the preview pane to the right of results, which are cut
+// to the space left.
+func (m model) withPreview(results string) string {
+	paneWidth := m.width * 2 / 5
+	listWidth := m.width - paneWidth - 3
+	lines := strings.Split(strings.TrimSuffix(results, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = padStyledWidth(clipStyledWidth(line, listWidth), listWidth)
+	}
+	left := strings.Join(lines, "\n")
+	pane := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(versionStyle.GetForeground()).
+		PaddingLeft(1).
+		Render(m.previewView(paneWidth))
+	return lipgloss.JoinHorizontal(lipgloss.Top, left, " ", pane) + "\n"
+}
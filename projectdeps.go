@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+// projectDeps are the modules the current project depends on, as --deps
+// searches them: the requirements of its go.mod, and the modules go.sum
+// has checksums for beyond those.
+type projectDeps struct {
+	module string
+	// required is the version go.mod requires, or for go.sum-only modules
+	// the newest one summed.
+	required map[string]string
+	kinds    map[string]string
+	modTime  time.Time
+}
+
+// Dependency kinds, shown as row badges.
+const (
+	depDirect   = "direct"
+	depIndirect = "indirect"
+	depSum      = "go.sum"
+)
+
+// loadProjectDeps reads go.mod and go.sum of the module containing dir.
+func loadProjectDeps(dir string) (*projectDeps, error) {
+	root := findModuleRoot(dir)
+	if root == "" {
+		return nil, fmt.Errorf("no go.mod found in %s or any parent directory", dir)
+	}
+	path := filepath.Join(root, "go.mod")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	f, err := modfile.Parse(path, data, nil)
+	if err != nil {
+		return nil, &ParseError{What: "go.mod", Path: path, Err: err}
+	}
+	d := &projectDeps{required: make(map[string]string), kinds: make(map[string]string)}
+	if f.Module != nil {
+		d.module = f.Module.Mod.Path
+	}
+	if fi, err := os.Stat(path); err == nil {
+		d.modTime = fi.ModTime()
+	}
+	for _, r := range f.Require {
+		kind := depDirect
+		if r.Indirect {
+			kind = depIndirect
+		}
+		d.required[r.Mod.Path], d.kinds[r.Mod.Path] = r.Mod.Version, kind
+	}
+	if err := d.readSum(filepath.Join(root, "go.sum")); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// readSum adds the modules go.sum has source checksums for that go.mod does
+// not require. Lines for only a go.mod file are skipped: those modules took
+// part in version selection but their code is not used.
+func (d *projectDeps) readSum(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read go.sum: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		path, version := fields[0], fields[1]
+		if kind, ok := d.kinds[path]; ok && kind != depSum {
+			continue
+		}
+		if semver.Compare(version, d.required[path]) > 0 {
+			d.required[path], d.kinds[path] = version, depSum
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read go.sum: %w", err)
+	}
+	return nil
+}
+
+// depsSource serves the dependencies of the project as the index --deps
+// searches. Every entry carries the modification time of go.mod, so a
+// refresh finds nothing new.
+type depsSource struct {
+	deps *projectDeps
+}
+
+func (s depsSource) Fetch(ctx context.Context, since time.Time) ([]Package, error) {
+	if !since.IsZero() && s.deps.modTime.Before(since) {
+		return nil, nil
+	}
+	packages := make([]Package, 0, len(s.deps.required))
+	for path, version := range s.deps.required {
+		packages = append(packages, Package{Path: path, Version: version, Timestamp: s.deps.modTime})
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Path < packages[j].Path })
+	return packages, nil
+}
+
+// requiredVersion returns the version of pkg the project uses, in --deps
+// mode.
+func (m model) requiredVersion(pkg Package) (string, bool) {
+	if m.deps == nil {
+		return "", false
+	}
+	version, ok := m.deps.required[pkg.Path]
+	return version, ok
+}
+
+// depsVersionLabel shows the version the project requires and, once known
+// and different, the latest one.
+func (m model) depsVersionLabel(pkg Package) string {
+	required, ok := m.requiredVersion(pkg)
+	if !ok {
+		return ""
+	}
+	label := required
+	if latest := m.latestVersions[pkg.Path]; latest != "" && latest != required {
+		label += " → " + latest
+	}
+	return label
+}
+
+// depsBadge marks the rows of --deps that are not direct requirements.
+func (m model) depsBadge(pkg Package) string {
+	if m.deps == nil {
+		return ""
+	}
+	kind := m.deps.kinds[pkg.Path]
+	if kind == "" || kind == depDirect {
+		return ""
+	}
+	return " " + versionStyle.Render("["+kind+"]")
+}
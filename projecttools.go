@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// projectTools are the tool dependencies the current project declares:
+// blank imports in a tools.go file or a file built only with the tools tag,
+// and tool directives in go.mod.
+type projectTools struct {
+	// imports maps each tool's package path to the file declaring it,
+	// relative to the module root.
+	imports map[string]string
+}
+
+// findModuleRoot returns the directory of the go.mod governing dir, if any.
+func findModuleRoot(dir string) string {
+	for dir != "" {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return ""
+}
+
+// findProjectTools scans the module containing dir for tool dependencies.
+// Outside a module, or when it declares none, it returns nil.
+func findProjectTools(dir string) *projectTools {
+	root := findModuleRoot(dir)
+	if root == "" {
+		return nil
+	}
+	t := &projectTools{imports: make(map[string]string)}
+
+	if data, err := os.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+		if f, err := modfile.Parse("go.mod", data, nil); err == nil {
+			for _, tool := range f.Tool {
+				t.imports[tool.Path] = "go.mod"
+			}
+		}
+	}
+
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			// Nested modules declare their own tools.
+			if path != root {
+				if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ImportsOnly|parser.ParseComments)
+		if err != nil || (d.Name() != "tools.go" && !toolsOnly(f)) {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		for _, spec := range f.Imports {
+			if p, err := strconv.Unquote(spec.Path.Value); err == nil {
+				t.imports[p] = filepath.ToSlash(rel)
+			}
+		}
+		return nil
+	})
+
+	if len(t.imports) == 0 {
+		return nil
+	}
+	return t
+}
+
+// toolsOnly reports whether the build constraint of f only holds with the
+// tools tag.
+func toolsOnly(f *ast.File) bool {
+	expr := buildConstraint(f)
+	if expr == nil {
+		return false
+	}
+	with := expr.Eval(func(tag string) bool { return tag == "tools" })
+	without := expr.Eval(func(string) bool { return false })
+	return with && !without
+}
+
+// toolsOf returns the declared tool packages that the module at modulePath
+// may provide. Module boundaries are unknown here, so a tool in a nested
+// module also counts for the modules above it.
+func (t *projectTools) toolsOf(modulePath string) []string {
+	if t == nil {
+		return nil
+	}
+	var tools []string
+	for p := range t.imports {
+		if p == modulePath || strings.HasPrefix(p, modulePath+"/") {
+			tools = append(tools, p)
+		}
+	}
+	slices.Sort(tools)
+	return tools
+}
+
+// declares reports whether the module at modulePath provides a tool.
+func (t *projectTools) declares(modulePath string) bool {
+	if t == nil {
+		return false
+	}
+	for p := range t.imports {
+		if p == modulePath || strings.HasPrefix(p, modulePath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// toolBadge marks a module providing one of the project's tools.
+func (m model) toolBadge(pkg Package) string {
+	if !m.tools.declares(pkg.Path) {
+		return ""
+	}
+	return warningStyle.Render("⚙") + " "
+}
+
+// toolLine names the project's tools the selected module provides and
+// where they are declared.
+func (m model) toolLine() string {
+	pkg, ok := m.selectedPackage()
+	if !ok {
+		return ""
+	}
+	tools := m.tools.toolsOf(pkg.Path)
+	if len(tools) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Tool: %s, declared in %s. The get action updates it.", strings.Join(tools, ", "), m.tools.imports[tools[0]])
+}
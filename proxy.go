@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+const (
+	proxyBaseURL          = "https://proxy.golang.org"
+	depsDevAPIURL         = "https://api.deps.dev/v3/systems/go/packages"
+	depsDevAdvisoryAPIURL = "https://api.deps.dev/v3/advisories"
+	depsDevProjectAPIURL  = "https://api.deps.dev/v3/projects"
+	// The dependents endpoint is only in the alpha API so far.
+	depsDevDependentsAPIURL = "https://api.deps.dev/v3alpha/systems/go/packages"
+)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// httpDo sends a request without a body with client, bound to appCtx so
+// that quitting aborts it.
+func httpDo(client *http.Client, method, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(appCtx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
+
+// errModuleNotFound is returned when the proxy has no record of a module path.
+var errModuleNotFound = errors.New("module not found")
+
+// VersionInfo is the proxy's response for a single module version.
+type VersionInfo struct {
+	Version string    `json:"Version"`
+	Time    time.Time `json:"Time"`
+}
+
+// proxyGet fetches a proxy endpoint for the given module path, e.g. "@latest".
+func proxyGet(modPath, endpoint string) (*http.Response, error) {
+	return proxyDo(http.MethodGet, modPath, endpoint, nil)
+}
+
+// proxyDo sends a request with the given method and headers to a proxy
+// endpoint for the module path.
+func proxyDo(method, modPath, endpoint string, header http.Header) (*http.Response, error) {
+	escaped, err := module.EscapePath(modPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid module path '%s': %w", modPath, err)
+	}
+
+	req, err := http.NewRequestWithContext(appCtx, method, fmt.Sprintf("%s/%s/%s", proxyBaseURL, escaped, endpoint), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create proxy request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, &NetworkError{Op: "failed to query module proxy", URL: req.URL.String(), Err: err}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent:
+		return resp, nil
+	case http.StatusNotFound, http.StatusGone:
+		resp.Body.Close()
+		return nil, errModuleNotFound
+	default:
+		resp.Body.Close()
+		return nil, &NetworkError{Op: "received non-OK status from module proxy", URL: req.URL.String(), Err: errors.New(resp.Status)}
+	}
+}
+
+// fetchLatest resolves the latest version of a module.
+func fetchLatest(modPath string) (VersionInfo, error) {
+	var info VersionInfo
+	resp, err := proxyGet(modPath, "@latest")
+	if err != nil {
+		return info, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return info, fmt.Errorf("failed to decode latest version of '%s': %w", modPath, err)
+	}
+	return info, nil
+}
+
+// fetchVersions lists all published versions of a module, newest first.
+func fetchVersions(modPath string) ([]string, error) {
+	resp, err := proxyGet(modPath, "@v/list")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var versions []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if v := strings.TrimSpace(scanner.Text()); v != "" {
+			versions = append(versions, v)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading version list of '%s': %w", modPath, err)
+	}
+
+	semver.Sort(versions)
+	for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+		versions[i], versions[j] = versions[j], versions[i]
+	}
+	return versions, nil
+}
+
+// zipInfo describes the zip the proxy serves for a module version.
+type zipInfo struct {
+	Size int64
+	// Files is the number of entries in the zip, or -1 if the proxy would
+	// not serve its end.
+	Files int
+}
+
+// zipTailSize covers the zip's end of central directory record along with
+// the longest possible archive comment and a zip64 record before it.
+const zipTailSize = 22 + 65535 + 76
+
+// fetchZipInfo looks up the size of a module version's zip with a HEAD
+// request, then counts its files from the central directory at its end, so
+// the zip itself is never downloaded.
+func fetchZipInfo(modPath, version string) (zipInfo, error) {
+	info := zipInfo{Files: -1}
+	escaped, err := module.EscapeVersion(version)
+	if err != nil {
+		return info, fmt.Errorf("invalid version '%s': %w", version, err)
+	}
+	endpoint := "@v/" + escaped + ".zip"
+
+	resp, err := proxyDo(http.MethodHead, modPath, endpoint, nil)
+	if err != nil {
+		return info, err
+	}
+	resp.Body.Close()
+	info.Size = resp.ContentLength
+	if info.Size < 0 {
+		return info, fmt.Errorf("module proxy did not report the size of %s@%s", modPath, version)
+	}
+
+	resp, err = proxyDo(http.MethodGet, modPath, endpoint, http.Header{"Range": {fmt.Sprintf("bytes=-%d", zipTailSize)}})
+	if err != nil {
+		return info, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return info, nil
+	}
+	tail, err := io.ReadAll(io.LimitReader(resp.Body, zipTailSize))
+	if err == nil {
+		info.Files = zipEntryCount(tail)
+	}
+	return info, nil
+}
+
+// zipEntryCount reads the number of entries from the end of a zip file, or
+// returns -1 if tail does not hold its end of central directory record.
+func zipEntryCount(tail []byte) int {
+	i := bytes.LastIndex(tail, []byte("PK\x05\x06"))
+	if i < 0 || len(tail) < i+22 {
+		return -1
+	}
+	if n := binary.LittleEndian.Uint16(tail[i+10:]); n != 0xffff {
+		return int(n)
+	}
+	// Too many entries for the classic record: use the zip64 one.
+	j := bytes.LastIndex(tail[:i], []byte("PK\x06\x06"))
+	if j < 0 || len(tail) < j+40 {
+		return -1
+	}
+	return int(binary.LittleEndian.Uint64(tail[j+32:]))
+}
+
+// resolveModule finds the module that provides path. Package paths inside a
+// module are walked up one element at a time until the proxy knows the prefix.
+func resolveModule(path string) (string, VersionInfo, error) {
+	candidate := strings.TrimSuffix(path, "/")
+	for {
+		info, err := fetchLatest(candidate)
+		if err == nil {
+			return candidate, info, nil
+		}
+		if !errors.Is(err, errModuleNotFound) {
+			return "", VersionInfo{}, err
+		}
+
+		i := strings.LastIndex(candidate, "/")
+		if i < 0 {
+			return "", VersionInfo{}, fmt.Errorf("no module found providing '%s'", path)
+		}
+		candidate = candidate[:i]
+	}
+}
+
+// depsDevVersion is what deps.dev knows about a module version.
+type depsDevVersion struct {
+	Licenses     []string `json:"licenses"`
+	AdvisoryKeys []struct {
+		ID string `json:"id"`
+	} `json:"advisoryKeys"`
+	RelatedProjects []struct {
+		ProjectKey struct {
+			ID string `json:"id"`
+		} `json:"projectKey"`
+		RelationType string `json:"relationType"`
+	} `json:"relatedProjects"`
+}
+
+// advisory is a security advisory as described by deps.dev.
+type advisory struct {
+	ID    string  `json:"-"`
+	Title string  `json:"title"`
+	CVSS3 float64 `json:"cvss3Score"`
+}
+
+// depsDevGet decodes the deps.dev response for endpoint into v.
+func depsDevGet(endpoint string, v any) error {
+	resp, err := httpDo(httpClient, http.MethodGet, endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to query deps.dev: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("received non-OK status from deps.dev: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode deps.dev response: %w", err)
+	}
+	return nil
+}
+
+func fetchDepsDevVersion(modPath, version string) (depsDevVersion, error) {
+	var info depsDevVersion
+	endpoint := fmt.Sprintf("%s/%s/versions/%s", depsDevAPIURL, url.PathEscape(modPath), url.PathEscape(version))
+	err := depsDevGet(endpoint, &info)
+	return info, err
+}
+
+// fetchLicenses looks up the licenses deps.dev detected for a module version.
+func fetchLicenses(modPath, version string) ([]string, error) {
+	info, err := fetchDepsDevVersion(modPath, version)
+	return info.Licenses, err
+}
+
+// fetchAdvisory looks up an advisory listed by deps.dev, e.g. for a version.
+func fetchAdvisory(id string) (advisory, error) {
+	a := advisory{ID: id}
+	err := depsDevGet(depsDevAdvisoryAPIURL+"/"+url.PathEscape(id), &a)
+	return a, err
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gosearch/index"
+	"gosearch/search"
+)
+
+// quickPickItem is a result in the shape editor quick-pick UIs take, such as
+// VS Code's QuickPickItem or a Telescope entry. Path and Version repeat the
+// raw values so plugins need not parse the label.
+type quickPickItem struct {
+	Label       string `json:"label"`
+	Description string `json:"description,omitempty"`
+	Detail      string `json:"detail,omitempty"`
+	Path        string `json:"path"`
+	Version     string `json:"version,omitempty"`
+}
+
+// runQuickPick prints the ranked results for query as a JSON array of
+// quick-pick items instead of starting the TUI, one item per module.
+func runQuickPick(source index.Source, engine *search.Engine, annotations *annotationStore, query string, limit int, w io.Writer) error {
+	packages, err := source.Fetch(appCtx, time.Time{})
+	if err != nil {
+		return err
+	}
+	engine.SetPackages(packages)
+	matches, err := queryMatches(appCtx, engine, query)
+	if err != nil {
+		return err
+	}
+
+	items := []quickPickItem{}
+	for _, m := range newestPerPath(matches, matchPackage) {
+		if limit > 0 && len(items) == limit {
+			break
+		}
+		items = append(items, newQuickPickItem(m.Package, annotations.get(m.Package.Path)))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(items)
+}
+
+// runHeadless prints the ranked results for query, one per module, for
+// scripts and pipes: as a JSON array of search results, or as plain lines of
+// path and version separated by a tab.
+func runHeadless(source index.Source, engine *search.Engine, query string, limit int, format string, w io.Writer) error {
+	packages, err := source.Fetch(appCtx, time.Time{})
+	if err != nil {
+		return err
+	}
+	engine.SetPackages(packages)
+	matches, err := queryMatches(appCtx, engine, query)
+	if err != nil {
+		return err
+	}
+
+	results := []searchResult{}
+	for _, m := range newestPerPath(matches, matchPackage) {
+		if limit > 0 && len(results) == limit {
+			break
+		}
+		results = append(results, searchResult{Path: m.Package.Path, Version: m.Package.Version, Time: m.Package.Timestamp})
+	}
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	bw := bufio.NewWriter(w)
+	for _, r := range results {
+		fmt.Fprintf(bw, "%s\t%s\n", r.Path, r.Version)
+	}
+	return bw.Flush()
+}
+
+func newQuickPickItem(pkg Package, a annotation) quickPickItem {
+	item := quickPickItem{Label: pkg.Path, Description: pkg.Version, Path: pkg.Path, Version: pkg.Version}
+	var detail []string
+	if !pkg.Timestamp.IsZero() {
+		detail = append(detail, "Published "+pkg.Timestamp.Format("2006-01-02"))
+	}
+	if len(a.Tags) > 0 {
+		detail = append(detail, "Tags: "+strings.Join(a.Tags, ", "))
+	}
+	if a.Note != "" {
+		detail = append(detail, a.Note)
+	}
+	item.Detail = strings.Join(detail, " · ")
+	return item
+}
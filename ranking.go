@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gosearch/search"
+)
+
+// rankingScorers returns the signals that can be weighted under the ranking
+// setting.
+func rankingScorers(tools *projectTools, usage *usageHistory, hosts []string, dependents dependentCounts) map[string]search.Scorer {
+	return map[string]search.Scorer{
+		"match":   search.MatchScorer,
+		"recency": search.RecencyScorer(365 * 24 * time.Hour),
+		"tools": search.ScorerFunc(func(m search.Match, rc search.RankContext) float64 {
+			if tools.declares(m.Package.Path) {
+				return 1
+			}
+			return 0
+		}),
+		"frecency": search.ScorerFunc(func(m search.Match, rc search.RankContext) float64 {
+			return usage.frecency(m.Package.Path)
+		}),
+		"hosts": search.ScorerFunc(func(m search.Match, rc search.RankContext) float64 {
+			if preferredHost(m.Package.Path, hosts) {
+				return 1
+			}
+			return 0
+		}),
+		"popularity": search.ScorerFunc(func(m search.Match, rc search.RankContext) float64 {
+			return dependents.popularityScore(m.Package.Path)
+		}),
+	}
+}
+
+// preferredHost reports whether path is under one of the preferred_hosts
+// prefixes, such as "github.com" or "github.com/myorg".
+func preferredHost(path string, hosts []string) bool {
+	for _, h := range hosts {
+		if path == h || strings.HasPrefix(path, strings.TrimSuffix(h, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// newRanker builds the ranking pipeline from the configured weights. The
+// tools signal is left out when the project declares no tools, the frecency
+// signal when nothing was picked yet, the hosts signal when no host is
+// preferred, and the popularity signal where dependents are not counted.
+func newRanker(weights map[string]float64, tools *projectTools, usage *usageHistory, hosts []string, dependents dependentCounts) (*search.Ranker, error) {
+	scorers := rankingScorers(tools, usage, hosts, dependents)
+
+	names := make([]string, 0, len(weights))
+	for name := range weights {
+		if _, ok := scorers[name]; !ok {
+			return nil, fmt.Errorf("unknown ranking signal '%s'", name)
+		}
+		if (name == "tools" && tools == nil) || (name == "frecency" && usage.empty()) || (name == "hosts" && len(hosts) == 0) || (name == "popularity" && dependents == nil) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	r := &search.Ranker{}
+	for _, name := range names {
+		r.Stages = append(r.Stages, search.Stage{Name: name, Scorer: scorers[name], Weight: weights[name]})
+	}
+	return r, nil
+}
+
+// rankingLabel names the signals that contribute to r, e.g. "match+recency".
+func rankingLabel(r *search.Ranker) string {
+	var names []string
+	for _, stage := range r.Stages {
+		if stage.Weight != 0 {
+			names = append(names, stage.Name)
+		}
+	}
+	return strings.Join(names, "+")
+}
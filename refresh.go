@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+
+	"gosearch/index"
+)
+
+type refreshedMsg struct {
+	packages   []Package
+	syncedAt   time.Time
+	err        error
+	background bool
+}
+
+type autoRefreshMsg struct{}
+
+// scheduleAutoRefresh wakes the model up every interval to check whether the
+// loaded data has outlived it.
+func scheduleAutoRefresh(interval time.Duration) tea.Cmd {
+	if interval <= 0 {
+		return nil
+	}
+	return tea.Tick(interval, func(time.Time) tea.Msg { return autoRefreshMsg{} })
+}
+
+// startRefresh fetches entries newer than the loaded data and merges them in.
+// A dump left incomplete by a failed sync is resumed instead. Background
+// refreshes started by the auto-refresh timer do not show a banner and keep
+// to sync_rate_limit.
+func (m *model) startRefresh(background bool) tea.Cmd {
+	if m.state == stateLoading || m.done() || m.refreshing || m.resuming {
+		return nil
+	}
+	if m.remote != nil {
+		return remoteStatusCmd(m.remote)
+	}
+	if m.partial != nil {
+		if background {
+			return nil
+		}
+		m.resuming = true
+		return resumeDumpCmd(m.dumpPath, *m.partial)
+	}
+
+	m.refreshing = true
+	m.backgroundRefresh = background
+	if !background {
+		m.refreshErr = nil
+		m.notice = ""
+		m.newCount = 0
+	}
+	since, have := index.NewestEntries(m.packages)
+	source := m.source
+	if background {
+		source = throttled(source, m.config.SyncRateLimit)
+	}
+	return refreshCmd(source, since, have, background)
+}
+
+// throttled returns source reading the feed at no more than rate bytes per
+// second, if it reads it over HTTP and rate is set.
+func throttled(source index.Source, rate byteSize) index.Source {
+	if rate <= 0 {
+		return source
+	}
+	switch s := source.(type) {
+	case *index.HTTPSource:
+		feed := newFeed(s.URL, rate)
+		feed.Client = s.Client
+		return feed
+	case index.CachedSource:
+		s.Source = throttled(s.Source, rate).(*index.HTTPSource)
+		return s
+	}
+	return source
+}
+
+// refreshCmd walks source from since. have lists the entries stamped with
+// since that are already loaded.
+func refreshCmd(source index.Source, since time.Time, have map[string]bool, background bool) tea.Cmd {
+	return func() tea.Msg {
+		syncedAt := clock()
+		var packages []Package
+		syncer := index.Syncer{Source: source, Have: have}
+		err := syncer.Walk(appCtx, since, func(page []index.Package) error {
+			packages = append(packages, page...)
+			return nil
+		})
+		if err != nil {
+			err = fmt.Errorf("refresh failed: %w", err)
+		}
+		return refreshedMsg{packages: packages, syncedAt: syncedAt, err: err, background: background}
+	}
+}
+
+// mergeRefreshed adds the entries of a finished refresh to the result set.
+func (m *model) mergeRefreshed(msg refreshedMsg) {
+	m.refreshing = false
+	m.backgroundRefresh = false
+	if !msg.background {
+		m.refreshErr = msg.err
+	}
+	if msg.err == nil {
+		m.syncedAt = msg.syncedAt
+	}
+	if msg.background {
+		m.newCount += len(msg.packages)
+	}
+	if len(msg.packages) > 0 {
+		m.packages = append(m.packages, msg.packages...)
+		m.setCorpus()
+		m.filterPackages()
+	}
+}
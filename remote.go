@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+
+	"gosearch/index"
+	"gosearch/search"
+)
+
+// remoteResults is how many results a remote search asks for; the server
+// does the ranking, so the best ones come first.
+const remoteResults = 500
+
+// remoteClient talks to a `gosearch serve` instance for --remote.
+type remoteClient struct {
+	base  string
+	token string
+}
+
+// newRemoteClient returns a client for the server at addr, a host:port or a
+// URL.
+func newRemoteClient(addr, token string) (*remoteClient, error) {
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	u, err := url.Parse(addr)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid --remote '%s'", addr)
+	}
+	return &remoteClient{base: strings.TrimSuffix(u.String(), "/"), token: token}, nil
+}
+
+func (c *remoteClient) get(path string, v any) error {
+	req, err := http.NewRequestWithContext(appCtx, http.MethodGet, c.base+path, nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", c.base, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s answered %s: %s", c.base, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse the answer of %s: %w", c.base, err)
+	}
+	return nil
+}
+
+// serverStatus is what GET /status of `gosearch serve` returns.
+type serverStatus struct {
+	Entries  int       `json:"entries"`
+	SyncedAt time.Time `json:"synced_at"`
+	Matcher  string    `json:"matcher"`
+}
+
+type remoteStatusMsg struct {
+	status serverStatus
+	err    error
+}
+
+func remoteStatusCmd(c *remoteClient) tea.Cmd {
+	return func() tea.Msg {
+		var status serverStatus
+		err := c.get("/status", &status)
+		return remoteStatusMsg{status: status, err: err}
+	}
+}
+
+type remoteResultsMsg struct {
+	query   string
+	matches []search.Match
+	err     error
+}
+
+func remoteSearchCmd(c *remoteClient, query string) tea.Cmd {
+	return func() tea.Msg {
+		var results []searchResult
+		err := c.get(fmt.Sprintf("/search?limit=%d&q=%s", remoteResults, url.QueryEscape(query)), &results)
+		matches := make([]search.Match, len(results))
+		for i, r := range results {
+			matches[i] = search.Match{
+				Package:        index.Package{Path: r.Path, Version: r.Version, Timestamp: r.Time},
+				Index:          -1,
+				MatchedIndexes: r.Matched,
+			}
+		}
+		return remoteResultsMsg{query: query, matches: matches, err: err}
+	}
+}
+
+// searchRemote sends the query to the server once it changed, or after a
+// refresh, while the results are shown.
+func (m *model) searchRemote() tea.Cmd {
+	if m.remote == nil || m.state != stateBrowsing || (m.remoteSent && m.remoteQuery == m.input.query) {
+		return nil
+	}
+	m.remoteQuery, m.remoteSent = m.input.query, true
+	return remoteSearchCmd(m.remote, m.input.query)
+}
+
+// applyRemoteStatus takes in the index size and sync time of the server,
+// leaving the loading screen on the first answer.
+func (m *model) applyRemoteStatus(msg remoteStatusMsg) tea.Cmd {
+	if msg.err != nil {
+		if m.state == stateLoading {
+			return m.fail(msg.err)
+		}
+		m.refreshErr = fmt.Errorf("refresh failed: %w", msg.err)
+		return nil
+	}
+	m.refreshErr = nil
+	m.remoteTotal = msg.status.Entries
+	// Only for the status line; highlights come from the server.
+	m.engine.SetMatcher(msg.status.Matcher)
+	m.syncedAt = msg.status.SyncedAt
+	m.remoteSent = false
+	if m.state == stateLoading && m.setState(stateBrowsing) {
+		loaded := m.remoteTotal
+		m.events.emit(event{Type: "ready", Results: &loaded})
+	}
+	return nil
+}
+
+// mergeRemoteResults shows the results of a remote search if the query has
+// not changed in the meantime.
+func (m *model) mergeRemoteResults(msg remoteResultsMsg) tea.Cmd {
+	if msg.query != m.input.query {
+		return nil
+	}
+	if msg.err != nil {
+		m.queryErr = msg.err
+		return nil
+	}
+	m.remoteMatches = msg.matches
+	m.filterPackages()
+	return tea.Batch(m.resolveVisibleLatest(), m.followDeepLink())
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/mod/module"
+)
+
+// padWidth pads s with spaces to width terminal columns. Wide characters,
+// like CJK, take two columns, which fmt's %-*s padding counts as one.
+func padWidth(s string, width int) string {
+	return runewidth.FillRight(s, width)
+}
+
+// clipWidth cuts s to at most width terminal columns, ending it with … if
+// it had to be cut, without splitting a character.
+func clipWidth(s string, width int) string {
+	return runewidth.Truncate(s, width, "…")
+}
+
+// styledWidth is the number of terminal columns s takes, leaving out the
+// ANSI escape sequences styles put in it.
+func styledWidth(s string) int {
+	width := 0
+	for i := 0; i < len(s); {
+		if n := escapeLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		width += runewidth.RuneWidth(r)
+		i += size
+	}
+	return width
+}
+
+// escapeLen is the length of the CSI escape sequence s starts with, or 0.
+func escapeLen(s string) int {
+	if !strings.HasPrefix(s, "\x1b[") {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		if s[i] >= 0x40 && s[i] <= 0x7e {
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// clipStyledWidth is clipWidth for styled text: escape sequences take no
+// columns and are kept, and the styles are reset after the ….
+func clipStyledWidth(s string, width int) string {
+	if styledWidth(s) <= width {
+		return s
+	}
+	var b strings.Builder
+	used := 0
+	for i := 0; i < len(s); {
+		if n := escapeLen(s[i:]); n > 0 {
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		w := runewidth.RuneWidth(r)
+		if used+w > width-1 {
+			break
+		}
+		b.WriteRune(r)
+		used += w
+		i += size
+	}
+	return b.String() + "…\x1b[0m"
+}
+
+// padStyledWidth is padWidth for styled text.
+func padStyledWidth(s string, width int) string {
+	return s + strings.Repeat(" ", max(width-styledWidth(s), 0))
+}
+
+var matchStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff00ff"))
+
+// hostColors tints the host prefix of common hosts so mixed result lists are
+// easier to scan. The colors are fixed so a host always looks the same.
+var hostColors = map[string]lipgloss.Color{
+	"github.com":    lipgloss.Color("#a371f7"),
+	"gitlab.com":    lipgloss.Color("#fc6d26"),
+	"bitbucket.org": lipgloss.Color("#2684ff"),
+	"golang.org/x":  lipgloss.Color("#00add8"),
+	"gopkg.in":      lipgloss.Color("#2ea44f"),
+}
+
+// hostPrefix returns the part of path identifying where it is hosted.
+func hostPrefix(path string) string {
+	if strings.HasPrefix(path, "golang.org/x/") {
+		return "golang.org/x"
+	}
+	if i := strings.Index(path, "/"); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
+
+// ansiStyle is a foreground style reduced to the escape sequences around its
+// text, so highlighting a path is plain string concatenation instead of one
+// lipgloss render per span.
+type ansiStyle struct {
+	open, close string
+}
+
+func newANSIStyle(style lipgloss.Style) ansiStyle {
+	rendered := style.Render("x")
+	i := strings.Index(rendered, "x")
+	return ansiStyle{open: rendered[:i], close: rendered[i+1:]}
+}
+
+// The sequences depend on the terminal's color profile, so they are worked
+// out on first use rather than at init.
+var (
+	precomputeOnce sync.Once
+	matchANSI      ansiStyle
+	hostANSI       map[string]ansiStyle
+)
+
+func precomputeStyles() {
+	matchANSI = newANSIStyle(matchStyle)
+	hostANSI = make(map[string]ansiStyle, len(hostColors))
+	for host, color := range hostColors {
+		hostANSI[host] = newANSIStyle(lipgloss.NewStyle().Foreground(color))
+	}
+}
+
+// renderPath styles a package path for the result list: matched characters
+// are highlighted and a known host prefix is tinted.
+func renderPath(path string, matchedIndexes []int) string {
+	precomputeOnce.Do(precomputeStyles)
+
+	host := hostPrefix(path)
+	hostEnd := 0
+	hostStyle, ok := hostANSI[host]
+	if ok {
+		hostEnd = len(host)
+	}
+
+	var b strings.Builder
+	b.Grow(len(path) + 16*(len(matchedIndexes)+1))
+
+	// plain writes an unmatched span, tinting whatever part of it falls
+	// inside the host prefix.
+	plain := func(from, to int) {
+		if from >= to {
+			return
+		}
+		if from < hostEnd {
+			split := min(to, hostEnd)
+			b.WriteString(hostStyle.open + path[from:split] + hostStyle.close)
+			from = split
+		}
+		b.WriteString(path[from:to])
+	}
+
+	lastIndex := 0
+	for i := 0; i < len(matchedIndexes); {
+		from := matchedIndexes[i]
+		if from < lastIndex || from >= len(path) {
+			i++
+			continue
+		}
+		// Extend over the run of consecutive matched characters.
+		to := from + 1
+		for i++; i < len(matchedIndexes) && matchedIndexes[i] == to && to < len(path); i++ {
+			to++
+		}
+		plain(lastIndex, from)
+		b.WriteString(matchANSI.open + path[from:to] + matchANSI.close)
+		lastIndex = to
+	}
+	plain(lastIndex, len(path))
+	return b.String()
+}
+
+// Nerd Font glyphs shown when the icons setting is enabled.
+const (
+	iconGitHub    = "\uf09b"
+	iconGitLab    = "\uf296"
+	iconBitbucket = "\uf171"
+	iconGo        = "\ue627"
+	iconHost      = "\uf0ac"
+	iconLock      = "\uf023"
+	iconWarning   = "\uf071"
+	iconStar      = "\uf005"
+)
+
+var hostIcons = map[string]string{
+	"github.com":    iconGitHub,
+	"gitlab.com":    iconGitLab,
+	"bitbucket.org": iconBitbucket,
+	"golang.org/x":  iconGo,
+	"gopkg.in":      iconGo,
+}
+
+// rowIcons returns the icon column of a result row.
+func (m model) rowIcons(pkg Package) string {
+	icon, ok := hostIcons[hostPrefix(pkg.Path)]
+	if !ok {
+		icon = iconHost
+	}
+	if color, ok := hostColors[hostPrefix(pkg.Path)]; ok {
+		icon = lipgloss.NewStyle().Foreground(color).Render(icon)
+	}
+	return icon + m.statusIcons(pkg)
+}
+
+// statusIcons returns the badges describing a package's status.
+func (m model) statusIcons(pkg Package) string {
+	var b strings.Builder
+	if m.privatePatterns != "" && module.MatchPrefixPatterns(m.privatePatterns, pkg.Path) {
+		b.WriteString(" " + iconLock)
+	}
+	if vulns, ok := osv.cached(pkg.Path, pkg.Version); ok && len(vulns) > 0 {
+		b.WriteString(" " + warningStyle.Render(iconWarning))
+	}
+	if m.favorites.has(pkg.Path) {
+		b.WriteString(" " + iconStar)
+	}
+	return b.String()
+}
+
+// goPrivatePatterns returns the GOPRIVATE setting, consulting `go env` so the
+// go command's own config file is honored.
+func goPrivatePatterns() string {
+	if out, err := command("go", "env", "GOPRIVATE").Output(); err == nil {
+		return strings.TrimSpace(string(out))
+	}
+	return os.Getenv("GOPRIVATE")
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// renderPathLipgloss is renderPath as it was before the escape sequences
+// were precomputed: one lipgloss render per matched character.
+func renderPathLipgloss(path string, matchedIndexes []int) string {
+	host := hostPrefix(path)
+	hostEnd := 0
+	var hostStyle lipgloss.Style
+	if color, ok := hostColors[host]; ok {
+		hostEnd = len(host)
+		hostStyle = lipgloss.NewStyle().Foreground(color)
+	}
+
+	plain := func(from, to int) string {
+		if from >= hostEnd {
+			return path[from:to]
+		}
+		split := min(to, hostEnd)
+		return hostStyle.Render(path[from:split]) + path[split:to]
+	}
+
+	var b strings.Builder
+	lastIndex := 0
+	for _, idx := range matchedIndexes {
+		if idx < lastIndex || idx >= len(path) {
+			continue
+		}
+		b.WriteString(plain(lastIndex, idx))
+		b.WriteString(matchStyle.Render(path[idx : idx+1]))
+		lastIndex = idx + 1
+	}
+	b.WriteString(plain(lastIndex, len(path)))
+	return b.String()
+}
+
+// benchPath is a long path matched in many places, some of them runs.
+const benchPath = "github.com/someorganization/some-long-repository-name/internal/pkg/subsystem/v2/handlers"
+
+var benchMatches = func() []int {
+	var indexes []int
+	for i := 0; i < len(benchPath); i += 3 {
+		indexes = append(indexes, i)
+		if i%9 == 0 && i+1 < len(benchPath) {
+			indexes = append(indexes, i+1)
+		}
+	}
+	return indexes
+}()
+
+// withColors renders in true color, as a terminal would, instead of the
+// plain text lipgloss falls back to without one.
+func withColors(b *testing.B) {
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	precomputeOnce = sync.Once{}
+	b.Cleanup(func() {
+		lipgloss.SetColorProfile(profile)
+		precomputeOnce = sync.Once{}
+	})
+}
+
+func BenchmarkRenderPath(b *testing.B) {
+	withColors(b)
+	b.ReportAllocs()
+	for b.Loop() {
+		renderPath(benchPath, benchMatches)
+	}
+}
+
+func BenchmarkRenderPathLipgloss(b *testing.B) {
+	withColors(b)
+	b.ReportAllocs()
+	for b.Loop() {
+		renderPathLipgloss(benchPath, benchMatches)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// replacePrompt asks where to point a module with a replace directive: a
+// local directory or a fork's module path.
+type replacePrompt struct {
+	path  string
+	value string
+	// current is the replacement go.mod already has for path, if any.
+	current string
+}
+
+// replaceChosenMsg is sent when a replacement is confirmed. An empty target
+// drops the replace directive.
+type replaceChosenMsg struct {
+	path   string
+	target string
+}
+
+// currentReplacement returns the replacement of path in the go.mod of the
+// module at root, as it would be typed in the prompt.
+func currentReplacement(root, path string) string {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	f, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		return ""
+	}
+	for _, r := range f.Replace {
+		if r.Old.Path == path {
+			if r.New.Version == "" {
+				return r.New.Path
+			}
+			return r.New.Path + "@" + r.New.Version
+		}
+	}
+	return ""
+}
+
+// newReplacePrompt starts from the current replacement of path, with a
+// directory made relative to the working directory like typed ones are.
+func newReplacePrompt(path string) replacePrompt {
+	cwd, _ := os.Getwd()
+	root := findModuleRoot(cwd)
+	current := currentReplacement(root, path)
+	if isLocalPath(current) && !filepath.IsAbs(current) {
+		if rel, err := filepath.Rel(cwd, filepath.Join(root, current)); err == nil {
+			if !strings.HasPrefix(rel, "..") {
+				rel = "." + string(filepath.Separator) + rel
+			}
+			current = filepath.ToSlash(rel)
+		}
+	}
+	return replacePrompt{path: path, value: current, current: current}
+}
+
+// isLocalPath reports whether a replacement names a directory, the way the
+// go command tells them from module paths.
+func isLocalPath(target string) bool {
+	return filepath.IsAbs(target) || target == "~" || strings.HasPrefix(target, "~/") ||
+		target == "." || target == ".." || strings.HasPrefix(target, "./") || strings.HasPrefix(target, "../")
+}
+
+// replacementFor turns what was typed into the right-hand side of a replace
+// directive for the module at root. Directories are made relative to root,
+// which go.mod resolves them against, and must hold a go.mod. A fork without
+// a version is pinned to its latest one.
+func replacementFor(root, target string) (string, error) {
+	if isLocalPath(target) {
+		dir := target
+		if dir == "~" || strings.HasPrefix(dir, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", fmt.Errorf("failed to expand ~: %w", err)
+			}
+			dir = filepath.Join(home, strings.TrimPrefix(dir, "~"))
+		}
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", target, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err != nil {
+			return "", fmt.Errorf("%s has no go.mod", dir)
+		}
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			return dir, nil
+		}
+		if rel != "." && !strings.HasPrefix(rel, "..") {
+			rel = "." + string(filepath.Separator) + rel
+		}
+		return filepath.ToSlash(rel), nil
+	}
+
+	path, version, _ := strings.Cut(target, "@")
+	if err := module.CheckPath(path); err != nil {
+		return "", fmt.Errorf("'%s' is neither a directory nor a module path: %w", target, err)
+	}
+	if version == "" || version == "latest" {
+		info, err := fetchLatest(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to look up '%s': %w", path, err)
+		}
+		version = info.Version
+	}
+	return path + "@" + version, nil
+}
+
+// replaceCmd adds a replace directive for path to the current module, or
+// drops it if target is empty.
+func replaceCmd(path, target string) tea.Cmd {
+	return func() tea.Msg {
+		cwd, _ := os.Getwd()
+		root := findModuleRoot(cwd)
+		if root == "" {
+			return errMsg(fmt.Errorf("not inside a Go module"))
+		}
+		arg := "-dropreplace=" + path
+		if target != "" {
+			replacement, err := replacementFor(root, target)
+			if err != nil {
+				return errMsg(err)
+			}
+			arg = "-replace=" + path + "=" + replacement
+		}
+		if err := backupModFiles(root, "go mod edit "+arg); err != nil {
+			return errMsg(err)
+		}
+		if out, err := runMutating(root, "go", "mod", "edit", arg); err != nil {
+			return errMsg(&ActionError{Action: "replace", Command: "go mod edit " + arg, Output: strings.TrimSpace(string(out)), Err: err})
+		}
+		return nil
+	}
+}
+
+func (p replacePrompt) Update(msg tea.Msg) (replacePrompt, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return p, nil
+	}
+
+	switch key.Type {
+	case tea.KeyEsc:
+		return p, closeOverlay
+	case tea.KeyEnter:
+		target := strings.TrimSpace(p.value)
+		if target == "" && p.current == "" {
+			return p, nil
+		}
+		chosen := replaceChosenMsg{path: p.path, target: target}
+		return p, func() tea.Msg { return chosen }
+	case tea.KeyBackspace:
+		if r := []rune(p.value); len(r) > 0 {
+			p.value = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes:
+		p.value += string(key.Runes)
+	}
+	return p, nil
+}
+
+func (p replacePrompt) View() string {
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf("Replace %s with\n\n", inputStyle.Render(p.path)))
+	s.WriteString(fmt.Sprintf("%s%s\n\n", p.value, inputStyle.Render("|")))
+	hint := "A local directory (./fork, ../fork, ~/src/fork) or a fork's module[@version]. Enter to run go mod edit -replace, Esc to cancel."
+	if p.current != "" {
+		hint = "A local directory or a fork's module[@version]; an empty value drops the replace directive. Enter to run go mod edit, Esc to cancel."
+	}
+	s.WriteString(statusMessageStyle.Render(hint))
+	return s.String()
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// goImportMeta matches <meta name="go-import" content="prefix vcs repo">.
+var goImportMeta = regexp.MustCompile(`<meta\s+name="go-import"\s+content="([^"]+)"`)
+
+// repoURL returns the source repository URL for a module or package path.
+// Well-known hosts are mapped directly; anything else goes through the
+// ?go-get=1 discovery protocol used by the go command.
+func repoURL(path string) (string, error) {
+	parts := strings.Split(path, "/")
+	switch parts[0] {
+	case "github.com", "gitlab.com", "bitbucket.org":
+		if len(parts) < 3 {
+			return "", fmt.Errorf("incomplete repository path '%s'", path)
+		}
+		return "https://" + strings.Join(parts[:3], "/"), nil
+	}
+
+	resp, err := httpDo(httpClient, http.MethodGet, "https://"+path+"?go-get=1")
+	if err != nil {
+		return "", fmt.Errorf("failed to discover repository of '%s': %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("received non-OK status discovering repository of '%s': %s", path, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read go-get response of '%s': %w", path, err)
+	}
+
+	for _, match := range goImportMeta.FindAllStringSubmatch(string(body), -1) {
+		fields := strings.Fields(match[1])
+		if len(fields) == 3 && (path == fields[0] || strings.HasPrefix(path, fields[0]+"/")) && fields[1] == "git" {
+			return fields[2], nil
+		}
+	}
+	return "", fmt.Errorf("no git repository advertised for '%s'", path)
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"archive/zip"
+	"go/ast"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+// cgoUsage is how much a module depends on cgo.
+type cgoUsage int
+
+const (
+	cgoNone cgoUsage = iota
+	// cgoOptional means every package importing "C" has a !cgo fallback.
+	cgoOptional
+	cgoRequired
+)
+
+// moduleScan is what was learned from the files of a module version's zip.
+type moduleScan struct {
+	version string
+	cgo     cgoUsage
+	// cgoDirs are the package directories importing "C", relative to the
+	// module root.
+	cgoDirs []string
+	// platforms describes the packages that only build on some systems.
+	platforms []platformHint
+	// api sums up the exported API of the module's main package, if it
+	// has Go files.
+	api *apiSummary
+}
+
+// platformHint lists the systems and architectures a package of a module
+// builds on, when it does not build on all of them.
+type platformHint struct {
+	dir    string
+	goos   []string
+	goarch []string
+}
+
+type moduleScannedMsg struct {
+	path string
+	scan moduleScan
+	err  error
+}
+
+func scanModuleCmd(path, version string) tea.Cmd {
+	return func() tea.Msg {
+		scan, err := scanModule(path, version)
+		return moduleScannedMsg{path: path, scan: scan, err: err}
+	}
+}
+
+// scanModule downloads the zip of path@version if needed and inspects its
+// Go files.
+func scanModule(path, version string) (moduleScan, error) {
+	z, err := openModuleZip(path, version)
+	if err != nil {
+		return moduleScan{}, err
+	}
+	defer z.Close()
+	scan := scanModuleZip(&z.Reader, path+"@"+version+"/")
+	scan.version = version
+	return scan, nil
+}
+
+// goFile is a Go file of a module zip, parsed up to its imports.
+type goFile struct {
+	// dir is the file's directory relative to the module root, "." for the
+	// root itself.
+	dir  string
+	name string
+	file *ast.File
+}
+
+// moduleGoFiles parses the non-test Go files of the module in z whose files
+// are named under prefix, skipping testdata, vendor, and nested modules.
+func moduleGoFiles(z *zip.Reader, prefix string) []goFile {
+	nested := make(map[string]bool)
+	for _, f := range z.File {
+		rel := strings.TrimPrefix(f.Name, prefix)
+		if dir := path.Dir(rel); path.Base(rel) == "go.mod" && dir != "." {
+			nested[dir] = true
+		}
+	}
+	inNested := func(dir string) bool {
+		for d := dir; d != "."; d = path.Dir(d) {
+			if nested[d] {
+				return true
+			}
+		}
+		return false
+	}
+
+	var files []goFile
+	for _, f := range z.File {
+		rel := strings.TrimPrefix(f.Name, prefix)
+		if !strings.HasSuffix(rel, ".go") || strings.HasSuffix(rel, "_test.go") {
+			continue
+		}
+		dir := path.Dir(rel)
+		if skipDir(dir) || inNested(dir) {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			continue
+		}
+		parsed, err := parser.ParseFile(token.NewFileSet(), rel, r, parser.ImportsOnly|parser.ParseComments)
+		r.Close()
+		if err != nil {
+			continue
+		}
+		files = append(files, goFile{dir: dir, name: path.Base(rel), file: parsed})
+	}
+	return files
+}
+
+// skipDir reports whether the go command ignores packages in dir.
+func skipDir(dir string) bool {
+	if dir == "." {
+		return false
+	}
+	for _, elem := range strings.Split(dir, "/") {
+		if elem == "testdata" || elem == "vendor" || strings.HasPrefix(elem, ".") || strings.HasPrefix(elem, "_") {
+			return true
+		}
+	}
+	return false
+}
+
+func scanModuleZip(z *zip.Reader, prefix string) moduleScan {
+	var scan moduleScan
+	fallback := make(map[string]bool)
+	files := moduleGoFiles(z, prefix)
+	scan.platforms = platformHints(files)
+	if len(files) > 0 {
+		scan.api = packageAPI(z, prefix, files, apiPackage(files))
+	}
+	for _, f := range files {
+		if expr := buildConstraint(f.file); expr != nil && strings.Contains(expr.String(), "!cgo") {
+			fallback[f.dir] = true
+		}
+		for _, spec := range f.file.Imports {
+			if p, _ := strconv.Unquote(spec.Path.Value); p == "C" && !slices.Contains(scan.cgoDirs, f.dir) {
+				scan.cgoDirs = append(scan.cgoDirs, f.dir)
+			}
+		}
+	}
+	slices.Sort(scan.cgoDirs)
+	if len(scan.cgoDirs) > 0 {
+		scan.cgo = cgoOptional
+		for _, dir := range scan.cgoDirs {
+			if !fallback[dir] {
+				scan.cgo = cgoRequired
+			}
+		}
+	}
+	return scan
+}
+
+// buildConstraint returns the //go:build constraint of f, if it has one.
+func buildConstraint(f *ast.File) constraint.Expr {
+	for _, group := range f.Comments {
+		if group.Pos() >= f.Package {
+			break
+		}
+		for _, c := range group.List {
+			if constraint.IsGoBuild(c.Text) {
+				expr, err := constraint.Parse(c.Text)
+				if err != nil {
+					return nil
+				}
+				return expr
+			}
+		}
+	}
+	return nil
+}
+
+// knownOS and knownArch are the values of GOOS and GOARCH the go command
+// supports.
+var (
+	knownOS = []string{"aix", "android", "darwin", "dragonfly", "freebsd", "illumos", "ios", "js",
+		"linux", "netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows"}
+	knownArch = []string{"386", "amd64", "arm", "arm64", "loong64", "mips", "mips64", "mips64le",
+		"mipsle", "ppc64", "ppc64le", "riscv64", "s390x", "wasm"}
+)
+
+var unixOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true, "illumos": true,
+	"ios": true, "linux": true, "netbsd": true, "openbsd": true, "solaris": true,
+}
+
+// matchOS reports whether a file constrained to tag builds for goos. As with
+// the go command, linux files also build for android, darwin ones for ios,
+// and solaris ones for illumos.
+func matchOS(tag, goos string) bool {
+	return tag == goos || tag == "unix" && unixOS[goos] ||
+		tag == "linux" && goos == "android" || tag == "darwin" && goos == "ios" || tag == "solaris" && goos == "illumos"
+}
+
+// buildsFor reports whether f is part of its package on goos/goarch, going by
+// its name and build constraint. Tags other than the system, architecture,
+// cgo, gc, and Go versions are taken to be unset.
+func buildsFor(f goFile, goos, goarch string) bool {
+	name := strings.TrimSuffix(f.name, ".go")
+	if i := strings.Index(name, "_"); i >= 0 {
+		elems := strings.Split(name[i:], "_")
+		n := len(elems)
+		switch {
+		case n >= 3 && isKnownOS(elems[n-2]) && isKnownArch(elems[n-1]):
+			if !matchOS(elems[n-2], goos) || elems[n-1] != goarch {
+				return false
+			}
+		case isKnownOS(elems[n-1]):
+			if !matchOS(elems[n-1], goos) {
+				return false
+			}
+		case isKnownArch(elems[n-1]):
+			if elems[n-1] != goarch {
+				return false
+			}
+		}
+	}
+	expr := buildConstraint(f.file)
+	if expr == nil {
+		return true
+	}
+	return expr.Eval(func(tag string) bool {
+		switch {
+		case isKnownOS(tag) || tag == "unix":
+			return matchOS(tag, goos)
+		case isKnownArch(tag):
+			return tag == goarch
+		case tag == "cgo" || tag == "gc" || strings.HasPrefix(tag, "go1."):
+			return true
+		}
+		return false
+	})
+}
+
+func isKnownOS(tag string) bool {
+	return slices.Contains(knownOS, tag)
+}
+
+func isKnownArch(tag string) bool {
+	return slices.Contains(knownArch, tag)
+}
+
+// platformHints works out, for each package in files, the systems and
+// architectures it builds on, keeping the packages that exclude some. Every
+// pairing is tried, valid port or not, so that a package limited to some
+// architectures is not reported as missing the systems lacking them.
+func platformHints(files []goFile) []platformHint {
+	byDir := make(map[string][]goFile)
+	for _, f := range files {
+		byDir[f.dir] = append(byDir[f.dir], f)
+	}
+	var hints []platformHint
+	for _, dir := range slices.Sorted(maps.Keys(byDir)) {
+		hint := platformHint{dir: dir}
+		for _, goos := range knownOS {
+			for _, goarch := range knownArch {
+				builds := slices.ContainsFunc(byDir[dir], func(f goFile) bool { return buildsFor(f, goos, goarch) })
+				if !builds {
+					continue
+				}
+				if !slices.Contains(hint.goos, goos) {
+					hint.goos = append(hint.goos, goos)
+				}
+				if !slices.Contains(hint.goarch, goarch) {
+					hint.goarch = append(hint.goarch, goarch)
+				}
+			}
+		}
+		slices.Sort(hint.goarch)
+		// Packages that never build, such as a lone ignored file, say
+		// nothing about the module.
+		if len(hint.goos) == 0 {
+			continue
+		}
+		if len(hint.goos) == len(knownOS) {
+			hint.goos = nil
+		}
+		if len(hint.goarch) == len(knownArch) {
+			hint.goarch = nil
+		}
+		if hint.goos != nil || hint.goarch != nil {
+			hints = append(hints, hint)
+		}
+	}
+	return hints
+}
+
+// restriction describes a subset of all, naming whichever side is shorter:
+// "linux only" or "not js, plan9".
+func restriction(subset, all []string) string {
+	if len(subset) <= len(all)/2 {
+		return strings.Join(subset, ", ") + " only"
+	}
+	var missing []string
+	for _, v := range all {
+		if !slices.Contains(subset, v) {
+			missing = append(missing, v)
+		}
+	}
+	return "not " + strings.Join(missing, ", ")
+}
+
+// platformLines describes the packages of the module that only build on
+// some systems or architectures, grouped by what they are restricted to.
+func (scan moduleScan) platformLines() (goos, goarch string) {
+	describe := func(restricted func(platformHint) string) string {
+		var order []string
+		dirs := make(map[string][]string)
+		for _, hint := range scan.platforms {
+			r := restricted(hint)
+			if r == "" {
+				continue
+			}
+			if _, ok := dirs[r]; !ok {
+				order = append(order, r)
+			}
+			dirs[r] = append(dirs[r], displayDir(hint.dir))
+		}
+		parts := make([]string, len(order))
+		for i, r := range order {
+			parts[i] = r + " (" + strings.Join(dirs[r], ", ") + ")"
+		}
+		return strings.Join(parts, "; ")
+	}
+	goos = describe(func(h platformHint) string {
+		if h.goos == nil {
+			return ""
+		}
+		return restriction(h.goos, knownOS)
+	})
+	goarch = describe(func(h platformHint) string {
+		if h.goarch == nil {
+			return ""
+		}
+		return restriction(h.goarch, knownArch)
+	})
+	return goos, goarch
+}
+
+// displayDir writes a package directory of a module as a relative path.
+func displayDir(dir string) string {
+	if dir == "." {
+		return dir
+	}
+	return "./" + dir
+}
+
+// cgoLine describes the cgo use found by scan for the detail view.
+func (scan moduleScan) cgoLine() string {
+	dirs := make([]string, len(scan.cgoDirs))
+	for i, dir := range scan.cgoDirs {
+		dirs[i] = displayDir(dir)
+	}
+	switch scan.cgo {
+	case cgoRequired:
+		return "required (" + strings.Join(dirs, ", ") + ")"
+	case cgoOptional:
+		return "optional, with pure Go fallbacks (" + strings.Join(dirs, ", ") + ")"
+	}
+	return "not used"
+}
+
+// cgoBadge marks rows of modules found to require cgo.
+func (m model) cgoBadge(pkg Package) string {
+	if m.scans[pkg.Path].cgo != cgoRequired {
+		return ""
+	}
+	return " " + warningStyle.Render("[cgo]")
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const (
+	// maxLaunches caps the launch times remembered for scheduling syncs.
+	maxLaunches = 500
+	// usageWindow is how far back launches count towards the schedule.
+	usageWindow = 28 * 24 * time.Hour
+	// slotSize divides the day into the slots usage is counted in.
+	slotSize = 30 * time.Minute
+	// syncLead is how long before a typical launch a sync starts.
+	syncLead = 10 * time.Minute
+)
+
+func launchesPath() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "launches.json"), nil
+}
+
+// readLaunches returns the recorded launch times of the TUI, oldest first.
+// A missing file is no history.
+func readLaunches(path string) ([]time.Time, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read launch history: %w", err)
+	}
+	var launches []time.Time
+	if err := json.Unmarshal(data, &launches); err != nil {
+		return nil, &ParseError{What: "launch history", Path: path, Err: err}
+	}
+	return launches, nil
+}
+
+// recordLaunch adds t to the launch history at path, keeping the most
+// recent maxLaunches.
+func recordLaunch(path string, t time.Time) error {
+	launches, err := readLaunches(path)
+	if err != nil {
+		return err
+	}
+	launches = append(launches, t)
+	if len(launches) > maxLaunches {
+		launches = launches[len(launches)-maxLaunches:]
+	}
+	data, err := json.Marshal(launches)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write launch history: %w", err)
+	}
+	return nil
+}
+
+// nextSync picks when to sync next so that the index is fresh at the next
+// time of day gosearch is typically launched: syncLead before the next slot
+// in which launches happened on at least two days of the last usageWindow.
+// It returns the zero time if no slot is typical yet.
+func nextSync(launches []time.Time, now time.Time) time.Time {
+	days := make(map[int]map[string]bool)
+	for _, t := range launches {
+		if now.Sub(t) > usageWindow || t.After(now) {
+			continue
+		}
+		t = t.In(now.Location())
+		slot := slotOf(t)
+		if days[slot] == nil {
+			days[slot] = make(map[string]bool)
+		}
+		days[slot][t.Format("2006-01-02")] = true
+	}
+	var typical []int
+	for slot, seen := range days {
+		if len(seen) >= 2 {
+			typical = append(typical, slot)
+		}
+	}
+	if len(typical) == 0 {
+		return time.Time{}
+	}
+	slices.Sort(typical)
+
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for day := 0; day <= 1; day++ {
+		for _, slot := range typical {
+			at := midnight.AddDate(0, 0, day).Add(time.Duration(slot)*slotSize - syncLead)
+			if at.After(now) {
+				return at
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func slotOf(t time.Time) int {
+	return (t.Hour()*60 + t.Minute()) / int(slotSize/time.Minute)
+}
@@ -0,0 +1,353 @@
+// Package search implements ranked package search independently of any user
+// interface, so the TUI and the non-interactive modes share one engine.
+package search
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"gosearch/index"
+)
+
+// Match is a single ranked search result.
+type Match struct {
+	Package index.Package
+	// Index is the position of Package in the engine's corpus, or -1 for
+	// matches found by SearchCorpus.
+	Index int
+	Score int
+	// MatchedIndexes are the byte offsets of the query characters in the path.
+	// They may be empty for matchers that highlight lazily; see Highlight.
+	MatchedIndexes []int
+}
+
+// Engine searches a corpus of packages. It is safe for concurrent use.
+type Engine struct {
+	mu       sync.RWMutex
+	packages []index.Package
+	paths    []string
+	matcher  Matcher
+	ranker   *Ranker
+	filters  map[string]Filter
+
+	highlightMu sync.Mutex
+	highlights  map[highlightKey][]int
+
+	// shards are the bloom filters over shardPaths used to prefilter exact
+	// terms, built on the first query that has any. A search that read the
+	// paths before SetPackages replaced them may still ask for the old ones.
+	shardMu    sync.Mutex
+	shards     []shard
+	shardPaths []string
+}
+
+type highlightKey struct {
+	query string
+	index int
+}
+
+// maxHighlights bounds the highlight memo; it is simply dropped when full.
+const maxHighlights = 10000
+
+// NewEngine returns an engine searching packages with the fuzzy matcher.
+// It understands the pkg:name filter, which matches the conventional package
+// name of a path (see PackageName).
+func NewEngine(packages []index.Package) *Engine {
+	e := &Engine{matcher: fuzzyMatcher{}, filters: map[string]Filter{"pkg": packageNameFilter}}
+	e.SetPackages(packages)
+	return e
+}
+
+// SetFilter makes name:value tokens in later queries keep only the packages
+// accepted by f.
+func (e *Engine) SetFilter(name string, f Filter) {
+	e.mu.Lock()
+	e.filters[name] = f
+	e.mu.Unlock()
+}
+
+// SetMatcher selects the registered matcher used by later searches.
+func (e *Engine) SetMatcher(name string) error {
+	m, err := Lookup(name)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.matcher = m
+	e.mu.Unlock()
+	e.resetHighlights()
+	return nil
+}
+
+// SetRanker makes later searches order matches with r instead of the
+// matcher's own order. A nil ranker restores the matcher's order.
+func (e *Engine) SetRanker(r *Ranker) {
+	e.mu.Lock()
+	e.ranker = r
+	e.mu.Unlock()
+}
+
+// Matcher returns the name of the matcher in use.
+func (e *Engine) Matcher() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.matcher.Name()
+}
+
+// SetPackages replaces the corpus.
+func (e *Engine) SetPackages(packages []index.Package) {
+	paths := make([]string, len(packages))
+	for i, p := range packages {
+		paths[i] = p.Path
+	}
+
+	e.mu.Lock()
+	e.packages = packages
+	e.paths = paths
+	e.mu.Unlock()
+	e.resetHighlights()
+
+	e.shardMu.Lock()
+	e.shards, e.shardPaths = nil, nil
+	e.shardMu.Unlock()
+}
+
+// Packages returns the corpus.
+func (e *Engine) Packages() []index.Package {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.packages
+}
+
+// Search returns every match for q, best first. An empty query matches all
+// packages in corpus order. Exact terms and filters in q (see ParseQuery)
+// narrow the corpus down before the matcher runs.
+func (e *Engine) Search(q string) ([]Match, error) {
+	e.mu.RLock()
+	packages, paths := e.packages, e.paths
+	e.mu.RUnlock()
+	return e.search(q, packages, paths, e.shardsFor)
+}
+
+// shardsFor returns the bloom filters over paths, building them if needed.
+func (e *Engine) shardsFor(paths []string) []shard {
+	e.shardMu.Lock()
+	defer e.shardMu.Unlock()
+	if e.shards == nil || !samePaths(e.shardPaths, paths) {
+		e.shards, e.shardPaths = buildShards(paths), paths
+	}
+	return e.shards
+}
+
+// samePaths reports whether a and b are the same slice, as SetPackages
+// makes a new one for every corpus.
+func samePaths(a, b []string) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// SearchCorpus searches packages that are not part of the engine's corpus,
+// such as entries kept on disk, with the engine's matcher and ranker. The
+// returned matches have an Index of -1 and their MatchedIndexes filled in.
+func (e *Engine) SearchCorpus(q string, packages []index.Package) ([]Match, error) {
+	paths := make([]string, len(packages))
+	for i, p := range packages {
+		paths[i] = p.Path
+	}
+	matches, err := e.search(q, packages, paths, nil)
+	for i := range matches {
+		matches[i].Index = -1
+		matches[i].MatchedIndexes = e.highlight(q, matches[i].Package.Path, matches[i].MatchedIndexes)
+	}
+	return matches, err
+}
+
+// search runs q over packages. shards, if set, provides bloom filters over
+// paths for prefiltering exact terms; without it every path is checked.
+func (e *Engine) search(q string, packages []index.Package, paths []string, shards func([]string) []shard) ([]Match, error) {
+	e.mu.RLock()
+	matcher, ranker, filters := e.matcher, e.ranker, e.filters
+	e.mu.RUnlock()
+
+	query := e.parseQuery(matcher, q)
+	if len(query.Terms) > 0 || len(query.Filters) > 0 {
+		candidates := e.candidates(query, packages, paths, shards, filters)
+		return e.searchSubset(query.Text, candidates, packages, paths, matcher, ranker)
+	}
+
+	// A filter still being typed is left out of Text as well.
+	if query.Text == "" {
+		matches := make([]Match, len(packages))
+		for i, p := range packages {
+			matches[i] = Match{Package: p, Index: i}
+		}
+		if ranker != nil {
+			ranker.Rank(matches)
+		}
+		return matches, nil
+	}
+
+	found, err := matcher.Find(query.Text, paths)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]Match, len(found))
+	for i, f := range found {
+		matches[i] = Match{Package: packages[f.Index], Index: f.Index, Score: f.Score, MatchedIndexes: f.MatchedIndexes}
+	}
+	if ranker != nil {
+		ranker.Rank(matches)
+	}
+	return matches, nil
+}
+
+// candidates returns the indexes of the packages containing every term of
+// query and accepted by every filter.
+func (e *Engine) candidates(query Query, packages []index.Package, paths []string,
+	shards func([]string) []shard, filters map[string]Filter) []int {
+	var candidates []int
+	switch {
+	case len(query.Terms) > 0 && shards != nil:
+		candidates = prefilter(shards(paths), paths, query.Terms)
+	default:
+		for i, path := range paths {
+			if containsAll(path, query.Terms) {
+				candidates = append(candidates, i)
+			}
+		}
+	}
+
+	for _, f := range query.Filters {
+		keep := filters[f.Name]
+		kept := candidates[:0]
+		for _, c := range candidates {
+			if keep(packages[c], f.Value) {
+				kept = append(kept, c)
+			}
+		}
+		candidates = kept
+	}
+	return candidates
+}
+
+// searchSubset runs the matcher over the candidate packages alone. Without
+// any text left to match, every candidate is a match.
+func (e *Engine) searchSubset(text string, candidates []int, packages []index.Package, paths []string,
+	matcher Matcher, ranker *Ranker) ([]Match, error) {
+	var matches []Match
+	if text == "" {
+		matches = make([]Match, len(candidates))
+		for i, c := range candidates {
+			matches[i] = Match{Package: packages[c], Index: c}
+		}
+	} else {
+		subset := make([]string, len(candidates))
+		for i, c := range candidates {
+			subset[i] = paths[c]
+		}
+		found, err := matcher.Find(text, subset)
+		if err != nil {
+			return nil, err
+		}
+		matches = make([]Match, len(found))
+		for i, f := range found {
+			c := candidates[f.Index]
+			matches[i] = Match{Package: packages[c], Index: c, Score: f.Score, MatchedIndexes: f.MatchedIndexes}
+		}
+	}
+	if ranker != nil {
+		ranker.Rank(matches)
+	}
+	return matches, nil
+}
+
+// parseQuery splits out the exact terms and filters of q. Regular
+// expressions are taken as written since quotes, + and : mean something
+// there.
+func (e *Engine) parseQuery(matcher Matcher, q string) Query {
+	if _, ok := matcher.(regexMatcher); ok {
+		return Query{Text: q}
+	}
+	e.mu.RLock()
+	names := make([]string, 0, len(e.filters))
+	for name := range e.filters {
+		names = append(names, name)
+	}
+	e.mu.RUnlock()
+	return ParseQuery(q, names...)
+}
+
+// Highlight returns the offsets to highlight in the path of m, a match for q.
+// Matchers that highlight lazily are asked only here, and the answer is
+// memoized per query and package.
+func (e *Engine) Highlight(q string, m Match) []int {
+	if q == "" || m.Index < 0 {
+		return m.MatchedIndexes
+	}
+	if len(m.MatchedIndexes) > 0 && !strings.ContainsAny(q, `"+:`) {
+		return m.MatchedIndexes
+	}
+	key := highlightKey{query: q, index: m.Index}
+	e.highlightMu.Lock()
+	indexes, ok := e.highlights[key]
+	e.highlightMu.Unlock()
+	if ok {
+		return indexes
+	}
+
+	indexes = e.highlight(q, m.Package.Path, m.MatchedIndexes)
+	e.highlightMu.Lock()
+	if e.highlights == nil || len(e.highlights) >= maxHighlights {
+		e.highlights = make(map[highlightKey][]int)
+	}
+	e.highlights[key] = indexes
+	e.highlightMu.Unlock()
+	return indexes
+}
+
+// highlight adds the offsets of the exact terms of q to the matcher's
+// offsets in path, asking a lazily highlighting matcher for those if the
+// search left them empty.
+func (e *Engine) highlight(q, path string, indexes []int) []int {
+	e.mu.RLock()
+	matcher := e.matcher
+	e.mu.RUnlock()
+
+	query := e.parseQuery(matcher, q)
+	if h, ok := matcher.(Highlighter); ok && len(indexes) == 0 && query.Text != "" {
+		indexes = h.Highlight(query.Text, path)
+	}
+	return termIndexes(path, query.Terms, indexes)
+}
+
+func (e *Engine) resetHighlights() {
+	e.highlightMu.Lock()
+	e.highlights = nil
+	e.highlightMu.Unlock()
+}
+
+// Query streams the matches for q, best first. The channel is closed once all
+// matches have been sent or ctx is cancelled.
+func (e *Engine) Query(ctx context.Context, q string) (<-chan Match, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	matches, err := e.Search(q)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan Match)
+	go func() {
+		defer close(out)
+		for _, match := range matches {
+			select {
+			case out <- match:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
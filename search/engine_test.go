@@ -0,0 +1,54 @@
+package search
+
+import (
+	"testing"
+
+	"gosearch/index"
+)
+
+func packages(paths ...string) []index.Package {
+	packages := make([]index.Package, len(paths))
+	for i, p := range paths {
+		packages[i] = index.Package{Path: p, Version: "v1.0.0"}
+	}
+	return packages
+}
+
+func matchedPaths(matches []Match) []string {
+	paths := make([]string, len(matches))
+	for i, m := range matches {
+		paths[i] = m.Package.Path
+	}
+	return paths
+}
+
+func TestSearchAfterStaleShards(t *testing.T) {
+	e := NewEngine(packages("github.com/gorilla/mux"))
+	e.mu.RLock()
+	old := e.paths
+	e.mu.RUnlock()
+	e.SetPackages(packages("gopkg.in/yaml.v3", "github.com/gorilla/mux"))
+	// A search that read the corpus before SetPackages builds its shards last.
+	e.shardsFor(old)
+
+	matches, err := e.Search(`"yaml"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := matchedPaths(matches); len(got) != 1 || got[0] != "gopkg.in/yaml.v3" {
+		t.Errorf("Search found %q, want [gopkg.in/yaml.v3]", got)
+	}
+}
+
+func TestSearchWithFilterBeingTyped(t *testing.T) {
+	e := NewEngine(packages("github.com/gorilla/mux", "gopkg.in/yaml.v3"))
+	for _, q := range []string{"mux pkg:", "pkg: mux"} {
+		matches, err := e.Search(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := matchedPaths(matches); len(got) != 1 || got[0] != "github.com/gorilla/mux" {
+			t.Errorf("Search(%q) found %q, want [github.com/gorilla/mux]", q, got)
+		}
+	}
+}
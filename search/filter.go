@@ -0,0 +1,37 @@
+package search
+
+import (
+	"strings"
+
+	"golang.org/x/mod/module"
+
+	"gosearch/index"
+)
+
+// Filter decides whether a package is kept by a name:value query token; it
+// is given the value.
+type Filter func(p index.Package, value string) bool
+
+// PackageName returns the name a package at path is imported as by
+// convention: the last path element, skipping a major version suffix such as
+// /v2 and the .vN of gopkg.in paths.
+func PackageName(path string) string {
+	prefix, _, ok := module.SplitPathVersion(path)
+	if !ok {
+		prefix = path
+	}
+	return prefix[strings.LastIndex(prefix, "/")+1:]
+}
+
+// packageNameFilter implements pkg:name. Besides the exact last element it
+// accepts the usual go- and -go decorations of repository names, so
+// pkg:redis finds go-redis and pkg:yaml finds yaml-go.
+func packageNameFilter(p index.Package, value string) bool {
+	name := strings.ToLower(PackageName(p.Path))
+	value = strings.ToLower(value)
+	if name == value {
+		return true
+	}
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "go-"), "-go")
+	return name == value || strings.ReplaceAll(name, "-", "") == value
+}
@@ -0,0 +1,89 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Result is a matcher's verdict for one candidate string.
+type Result struct {
+	// Index is the position of the candidate in the slice passed to Find.
+	Index int
+	Score int
+	// MatchedIndexes are the byte offsets in the candidate to highlight.
+	// Matchers implementing Highlighter may leave them empty.
+	MatchedIndexes []int
+}
+
+// Matcher is a search algorithm. Find returns the candidates matching query,
+// best first.
+type Matcher interface {
+	Name() string
+	Find(query string, candidates []string) ([]Result, error)
+}
+
+// Highlighter is implemented by matchers that can work out the highlighted
+// offsets of a single candidate on demand. Such matchers skip them in Find so
+// that only the rows actually displayed pay for highlighting.
+type Highlighter interface {
+	Highlight(query, candidate string) []int
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Matcher{}
+)
+
+// Register makes a matcher available by name. Registering the same name twice
+// replaces the earlier matcher.
+func Register(m Matcher) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[m.Name()] = m
+}
+
+// Lookup returns the matcher registered under name.
+func Lookup(name string) (Matcher, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	m, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown matcher '%s'", name)
+	}
+	return m, nil
+}
+
+// Matchers lists the names of all registered matchers in alphabetical order.
+func Matchers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register(fuzzyMatcher{})
+	Register(exactMatcher{})
+	Register(regexMatcher{})
+	Register(trigramMatcher{})
+}
+
+// sortResults orders results by descending score, breaking ties by the
+// shorter candidate and then by corpus order.
+func sortResults(results []Result, candidates []string) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		li, lj := len(candidates[results[i].Index]), len(candidates[results[j].Index])
+		if li != lj {
+			return li < lj
+		}
+		return results[i].Index < results[j].Index
+	})
+}
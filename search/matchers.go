@@ -0,0 +1,171 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sahilm/fuzzy"
+)
+
+// fuzzyMatcher matches the query characters in order, allowing gaps.
+type fuzzyMatcher struct{}
+
+func (fuzzyMatcher) Name() string { return "fuzzy" }
+
+func (fuzzyMatcher) Find(query string, candidates []string) ([]Result, error) {
+	found := fuzzy.Find(query, candidates)
+	results := make([]Result, len(found))
+	for i, f := range found {
+		results[i] = Result{Index: f.Index, Score: f.Score, MatchedIndexes: f.MatchedIndexes}
+	}
+	return results, nil
+}
+
+// exactMatcher matches the query as a case-insensitive substring.
+type exactMatcher struct{}
+
+func (exactMatcher) Name() string { return "exact" }
+
+func (exactMatcher) Find(query string, candidates []string) ([]Result, error) {
+	needle := strings.ToLower(query)
+	var results []Result
+	for i, c := range candidates {
+		pos := strings.Index(strings.ToLower(c), needle)
+		if pos < 0 {
+			continue
+		}
+		results = append(results, Result{Index: i, Score: positionScore(c, pos)})
+	}
+	sortResults(results, candidates)
+	return results, nil
+}
+
+func (exactMatcher) Highlight(query, candidate string) []int {
+	needle := strings.ToLower(query)
+	pos := strings.Index(strings.ToLower(candidate), needle)
+	if pos < 0 {
+		return nil
+	}
+	return span(pos, len(needle))
+}
+
+// regexMatcher matches the query as a regular expression.
+type regexMatcher struct{}
+
+func (regexMatcher) Name() string { return "regex" }
+
+func (regexMatcher) Find(query string, candidates []string) ([]Result, error) {
+	re, err := regexp.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %w", err)
+	}
+
+	var results []Result
+	for i, c := range candidates {
+		loc := re.FindStringIndex(c)
+		if loc == nil {
+			continue
+		}
+		results = append(results, Result{Index: i, Score: positionScore(c, loc[0])})
+	}
+	sortResults(results, candidates)
+	return results, nil
+}
+
+func (regexMatcher) Highlight(query, candidate string) []int {
+	re, err := regexp.Compile(query)
+	if err != nil {
+		return nil
+	}
+	loc := re.FindStringIndex(candidate)
+	if loc == nil {
+		return nil
+	}
+	return span(loc[0], loc[1]-loc[0])
+}
+
+// trigramMatcher ranks candidates by how many of the query's three-character
+// substrings they contain, which tolerates typos and transpositions.
+type trigramMatcher struct{}
+
+func (trigramMatcher) Name() string { return "trigram" }
+
+func (trigramMatcher) Find(query string, candidates []string) ([]Result, error) {
+	query = strings.ToLower(query)
+	if len(query) < 3 {
+		return exactMatcher{}.Find(query, candidates)
+	}
+
+	grams := queryTrigrams(query)
+	// Require at least half of the query trigrams to be present.
+	threshold := (len(grams) + 1) / 2
+
+	var results []Result
+	for i, c := range candidates {
+		lower := strings.ToLower(c)
+		seen := make(map[string]bool)
+		for j := 0; j+3 <= len(lower); j++ {
+			if g := lower[j : j+3]; grams[g] {
+				seen[g] = true
+			}
+		}
+		if len(seen) >= threshold {
+			results = append(results, Result{Index: i, Score: len(seen)})
+		}
+	}
+	sortResults(results, candidates)
+	return results, nil
+}
+
+// Highlight marks every character covered by one of the query's trigrams.
+func (trigramMatcher) Highlight(query, candidate string) []int {
+	query = strings.ToLower(query)
+	if len(query) < 3 {
+		return exactMatcher{}.Highlight(query, candidate)
+	}
+
+	grams := queryTrigrams(query)
+	lower := strings.ToLower(candidate)
+	var matched []int
+	for j := 0; j+3 <= len(lower); j++ {
+		if !grams[lower[j:j+3]] {
+			continue
+		}
+		for k := j; k < j+3; k++ {
+			if len(matched) == 0 || matched[len(matched)-1] < k {
+				matched = append(matched, k)
+			}
+		}
+	}
+	return matched
+}
+
+func queryTrigrams(query string) map[string]bool {
+	grams := make(map[string]bool)
+	for i := 0; i+3 <= len(query); i++ {
+		grams[query[i:i+3]] = true
+	}
+	return grams
+}
+
+// positionScore rewards matches in the final path element and matches that
+// start a path element.
+func positionScore(candidate string, pos int) int {
+	score := 0
+	if pos > strings.LastIndex(candidate, "/") {
+		score += 2
+	}
+	if pos == 0 || candidate[pos-1] == '/' {
+		score++
+	}
+	return score
+}
+
+func span(start, n int) []int {
+	indexes := make([]int, n)
+	for i := range indexes {
+		indexes[i] = start + i
+	}
+	return indexes
+}
@@ -0,0 +1,136 @@
+package search
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// shardSize is the number of paths covered by one bloom filter. Smaller
+// shards skip more precisely at the cost of more filters.
+const shardSize = 1024
+
+// bloomBits is the size of each shard's filter. A shard holds around ten
+// thousand distinct trigrams, which keeps false positives near 5%.
+const bloomBits = 1 << 16
+
+// shard summarizes the trigrams of the lower-cased paths in
+// [start, start+shardSize) with a bloom filter, so a search for an exact term
+// can skip shards that cannot contain it.
+type shard struct {
+	start int
+	bloom [bloomBits / 64]uint64
+}
+
+func bloomHashes(gram string) (uint32, uint32) {
+	h := fnv.New64a()
+	h.Write([]byte(gram))
+	sum := h.Sum64()
+	return uint32(sum), uint32(sum>>32) | 1
+}
+
+func (s *shard) add(gram string) {
+	h1, h2 := bloomHashes(gram)
+	for i := uint32(0); i < 3; i++ {
+		bit := (h1 + i*h2) % bloomBits
+		s.bloom[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func (s *shard) mayContain(gram string) bool {
+	h1, h2 := bloomHashes(gram)
+	for i := uint32(0); i < 3; i++ {
+		bit := (h1 + i*h2) % bloomBits
+		if s.bloom[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func buildShards(paths []string) []shard {
+	shards := make([]shard, (len(paths)+shardSize-1)/shardSize)
+	for i := range shards {
+		s := &shards[i]
+		s.start = i * shardSize
+		for _, path := range paths[s.start:min(s.start+shardSize, len(paths))] {
+			lower := strings.ToLower(path)
+			for j := 0; j+3 <= len(lower); j++ {
+				s.add(lower[j : j+3])
+			}
+		}
+	}
+	return shards
+}
+
+// prefilter returns the indexes of the paths containing every term, checking
+// only the shards whose filters admit all of the terms' trigrams.
+func prefilter(shards []shard, paths []string, terms []string) []int {
+	var candidates []int
+	for i := range shards {
+		s := &shards[i]
+		if !shardMayContain(s, terms) {
+			continue
+		}
+		for j := s.start; j < min(s.start+shardSize, len(paths)); j++ {
+			if containsAll(paths[j], terms) {
+				candidates = append(candidates, j)
+			}
+		}
+	}
+	return candidates
+}
+
+func shardMayContain(s *shard, terms []string) bool {
+	for _, term := range terms {
+		for j := 0; j+3 <= len(term); j++ {
+			if !s.mayContain(term[j : j+3]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func containsAll(path string, terms []string) bool {
+	lower := path
+	if strings.IndexFunc(path, unicode.IsUpper) >= 0 {
+		lower = strings.ToLower(path)
+	}
+	for _, term := range terms {
+		if !strings.Contains(lower, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// termIndexes returns the offsets of the first occurrence of each term in
+// path merged into indexes, sorted and without repeats.
+func termIndexes(path string, terms []string, indexes []int) []int {
+	if len(terms) == 0 {
+		return indexes
+	}
+	lower := strings.ToLower(path)
+	seen := make(map[int]bool, len(indexes))
+	merged := make([]int, 0, len(indexes))
+	add := func(i int) {
+		if !seen[i] {
+			seen[i] = true
+			merged = append(merged, i)
+		}
+	}
+	for _, i := range indexes {
+		add(i)
+	}
+	for _, term := range terms {
+		if pos := strings.Index(lower, term); pos >= 0 {
+			for i := pos; i < pos+len(term); i++ {
+				add(i)
+			}
+		}
+	}
+	sort.Ints(merged)
+	return merged
+}
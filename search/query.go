@@ -0,0 +1,93 @@
+package search
+
+import (
+	"strings"
+)
+
+// Query is a parsed search query.
+type Query struct {
+	// Text is what the matcher runs on.
+	Text string
+	// Terms must appear as case-insensitive substrings of the path.
+	Terms []string
+	// Filters are the name:value tokens of the query.
+	Filters []FieldFilter
+}
+
+// FieldFilter is a name:value token of a query, such as pkg:router.
+type FieldFilter struct {
+	Name  string
+	Value string
+}
+
+// ParseQuery splits the exact terms and field filters out of a query. A term
+// is written in double quotes or prefixed with +, as in `"sql" +driver pg`.
+// A filter is a word of the form name:value where name is one of fields.
+// A filter without a value, as while it is being typed, is dropped. The rest
+// of the query is left to the matcher; a query without terms or filters is
+// returned unchanged as Text.
+func ParseQuery(q string, fields ...string) Query {
+	if !strings.ContainsAny(q, `"+:`) {
+		return Query{Text: q}
+	}
+
+	var query Query
+	var b strings.Builder
+	stripped := false
+	for i := 0; i < len(q); {
+		wordStart := i == 0 || q[i-1] == ' '
+		switch {
+		case q[i] == '"':
+			end := strings.IndexByte(q[i+1:], '"')
+			if end < 0 {
+				end = len(q) - i - 1
+			}
+			query.Terms = appendTerm(query.Terms, q[i+1:i+1+end])
+			i += end + 2
+		case q[i] == '+' && wordStart:
+			end := wordEnd(q, i)
+			query.Terms = appendTerm(query.Terms, q[i+1:end])
+			i = end
+		case wordStart && fieldAt(q[i:], fields) != "":
+			name := fieldAt(q[i:], fields)
+			end := wordEnd(q, i)
+			if value := q[i+len(name)+1 : end]; value != "" {
+				query.Filters = append(query.Filters, FieldFilter{Name: name, Value: value})
+			}
+			i, stripped = end, true
+		default:
+			b.WriteByte(q[i])
+			i++
+		}
+	}
+	if len(query.Terms) == 0 && len(query.Filters) == 0 && !stripped {
+		return Query{Text: q}
+	}
+	query.Text = strings.Join(strings.Fields(b.String()), " ")
+	return query
+}
+
+func appendTerm(terms []string, term string) []string {
+	if term == "" {
+		return terms
+	}
+	return append(terms, strings.ToLower(term))
+}
+
+// wordEnd returns the index of the space ending the word at i, or len(q).
+func wordEnd(q string, i int) int {
+	if end := strings.IndexByte(q[i:], ' '); end >= 0 {
+		return i + end
+	}
+	return len(q)
+}
+
+// fieldAt returns the field of fields that s starts with as "name:".
+func fieldAt(s string, fields []string) string {
+	for _, name := range fields {
+		if strings.HasPrefix(s, name+":") {
+			return name
+		}
+	}
+	return ""
+}
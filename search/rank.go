@@ -0,0 +1,96 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// RankContext carries what scorers may need to know about the whole result
+// set when scoring one match.
+type RankContext struct {
+	// MaxScore is the highest matcher score among the matches being ranked.
+	MaxScore int
+	Now      time.Time
+}
+
+// Scorer produces one ranking signal for a match, ideally in [0, 1].
+type Scorer interface {
+	Score(m Match, rc RankContext) float64
+}
+
+// ScorerFunc adapts a function to the Scorer interface.
+type ScorerFunc func(m Match, rc RankContext) float64
+
+// Score implements Scorer.
+func (f ScorerFunc) Score(m Match, rc RankContext) float64 { return f(m, rc) }
+
+// Stage is a scorer with the weight of its signal in the final rank.
+type Stage struct {
+	Name   string
+	Scorer Scorer
+	Weight float64
+}
+
+// Ranker orders matches by the weighted sum of its stages' signals.
+type Ranker struct {
+	Stages []Stage
+}
+
+// Rank sorts matches best first. Matches with equal rank keep their order.
+func (r *Ranker) Rank(matches []Match) {
+	if len(r.Stages) == 0 || len(matches) == 0 {
+		return
+	}
+
+	rc := RankContext{Now: time.Now()}
+	for _, m := range matches {
+		rc.MaxScore = max(rc.MaxScore, m.Score)
+	}
+
+	ranks := make([]float64, len(matches))
+	for i, m := range matches {
+		for _, stage := range r.Stages {
+			if stage.Weight != 0 {
+				ranks[i] += stage.Weight * stage.Scorer.Score(m, rc)
+			}
+		}
+	}
+
+	order := make([]int, len(matches))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return ranks[order[i]] > ranks[order[j]]
+	})
+
+	sorted := make([]Match, len(matches))
+	for i, idx := range order {
+		sorted[i] = matches[idx]
+	}
+	copy(matches, sorted)
+}
+
+// MatchScorer is the matcher's own score, normalized to the best match.
+var MatchScorer = ScorerFunc(func(m Match, rc RankContext) float64 {
+	if rc.MaxScore <= 0 {
+		return 0
+	}
+	return math.Max(0, float64(m.Score)/float64(rc.MaxScore))
+})
+
+// RecencyScorer favors recently published versions, halving the signal every
+// halfLife.
+func RecencyScorer(halfLife time.Duration) Scorer {
+	return ScorerFunc(func(m Match, rc RankContext) float64 {
+		if m.Package.Timestamp.IsZero() {
+			return 0
+		}
+		age := rc.Now.Sub(m.Package.Timestamp)
+		if age <= 0 {
+			return 1
+		}
+		return math.Exp2(-float64(age) / float64(halfLife))
+	})
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gosearch/index"
+	"gosearch/search"
+)
+
+// webPage is the search page served by --web. It only talks to the API.
+//
+//go:embed web/index.html
+var webPage string
+
+// searchBuckets are the upper bounds, in seconds, of the search latency
+// histogram.
+var searchBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// searchServer answers searches over HTTP for a shared gosearch instance.
+type searchServer struct {
+	mu       sync.RWMutex
+	engine   *search.Engine
+	source   index.Source
+	syncedAt time.Time
+	// users, if set, are the users whose searches, favorites, and
+	// watchlists are kept apart.
+	users *userStore
+	// cacheBytes is the size of the cache directory as of the last sync,
+	// so that scrapes of /metrics do not walk it.
+	cacheBytes atomic.Int64
+
+	metrics serveMetrics
+}
+
+// serveMetrics are the counters behind /metrics.
+type serveMetrics struct {
+	mu           sync.Mutex
+	requests     map[[2]string]int
+	searchCounts []int
+	searchSum    float64
+	searchTotal  int
+	syncFailures int
+}
+
+func (m *serveMetrics) countRequest(handler string, code int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[[2]string{handler, strconv.Itoa(code)}]++
+}
+
+func (m *serveMetrics) observeSearch(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	seconds := d.Seconds()
+	for i, bound := range searchBuckets {
+		if seconds <= bound {
+			m.searchCounts[i]++
+		}
+	}
+	m.searchSum += seconds
+	m.searchTotal++
+}
+
+func (m *serveMetrics) countSyncFailure() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.syncFailures++
+}
+
+// runServe implements `gosearch serve`: it loads the index once and answers
+// GET /search?q=<query>&limit=<n> with the matching modules as JSON, keeping
+// the index fresh every --refresh. GET /status reports the size and age of
+// the index, GET /module/<module> looks a module up through the proxy, and
+// GET /metrics exposes Prometheus metrics. --web also serves a search page
+// at /. With --users, requests carrying a user's token as a bearer token are
+// personalized: GET /me returns the user's favorites, watchlist, and search
+// history, and PUT or DELETE /me/favorites/<module> and
+// /me/watchlist/<module> edit them.
+func runServe(args []string, w io.Writer) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", "localhost:8080", "listen on `address`")
+	indexFile := flags.String("index-file", "", "serve a newline-delimited JSON `file`, such as one written by gosearch sync, instead of the network")
+	refresh := flags.Duration("refresh", time.Hour, "how often to fetch new index entries (0 to never)")
+	usersFile := flags.String("users", "", "serve the users in a YAML `file` mapping user names to tokens")
+	web := flags.Bool("web", false, "serve a search page for browsers at /")
+	usersDir := flags.String("users-dir", "", "keep the data of each user in `dir` (default users in the gosearch config directory)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	var source index.Source = feedSource(cfg)
+	if *indexFile != "" {
+		dir, _ := cacheDir()
+		source = index.FileSource{Path: *indexFile, CacheDir: dir}
+	}
+	syncedAt := time.Now()
+	packages, err := source.Fetch(appCtx, time.Time{})
+	if err != nil {
+		return err
+	}
+	if *indexFile != "" {
+		if fi, err := os.Stat(*indexFile); err == nil {
+			syncedAt = fi.ModTime()
+		}
+	}
+
+	engine := search.NewEngine(packages)
+	if err := engine.SetMatcher(cfg.Matcher); err != nil {
+		return err
+	}
+	dir, _ := os.Getwd()
+	ranker, err := newRanker(cfg.Ranking, findProjectTools(dir), nil, cfg.PreferredHosts, nil)
+	if err != nil {
+		return err
+	}
+	engine.SetRanker(ranker)
+
+	s := &searchServer{
+		engine:   engine,
+		source:   throttled(source, cfg.SyncRateLimit),
+		syncedAt: syncedAt,
+		metrics: serveMetrics{
+			requests:     make(map[[2]string]int),
+			searchCounts: make([]int, len(searchBuckets)),
+		},
+	}
+	if *usersFile != "" {
+		dir := *usersDir
+		if dir == "" {
+			if dir, err = defaultUsersDir(); err != nil {
+				return err
+			}
+		}
+		if s.users, err = loadUsers(*usersFile, dir); err != nil {
+			return err
+		}
+	}
+	s.measureCache()
+	// A dump only grows when gosearch sync appends to it.
+	if *refresh > 0 && *indexFile == "" {
+		go s.refreshEvery(*refresh)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /search", s.handleSearch)
+	mux.HandleFunc("GET /module/{path...}", s.handleModule)
+	mux.HandleFunc("GET /status", s.handleStatus)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	if *web {
+		mux.HandleFunc("GET /{$}", s.handleWeb)
+	}
+	if s.users != nil {
+		mux.HandleFunc("GET /me", s.handleUser)
+		for _, list := range []string{"favorites", "watchlist"} {
+			mux.HandleFunc("PUT /me/"+list+"/{path...}", s.handleUserList(list))
+			mux.HandleFunc("DELETE /me/"+list+"/{path...}", s.handleUserList(list))
+		}
+	}
+	fmt.Fprintf(w, "Serving %d index entries on http://%s\n", len(packages), *addr)
+	return http.ListenAndServe(*addr, mux)
+}
+
+// refreshEvery merges the entries published since the newest loaded one into
+// the index every interval.
+func (s *searchServer) refreshEvery(interval time.Duration) {
+	for range time.Tick(interval) {
+		syncedAt := time.Now()
+		s.mu.RLock()
+		since, have := index.NewestEntries(s.engine.Packages())
+		s.mu.RUnlock()
+
+		var fresh []Package
+		syncer := index.Syncer{Source: s.source, Have: have}
+		err := syncer.Walk(appCtx, since, func(page []index.Package) error {
+			fresh = append(fresh, page...)
+			return nil
+		})
+		if err != nil {
+			s.metrics.countSyncFailure()
+		}
+
+		s.mu.Lock()
+		if len(fresh) > 0 {
+			s.engine.SetPackages(append(s.engine.Packages(), fresh...))
+		}
+		if err == nil {
+			s.syncedAt = syncedAt
+		}
+		s.mu.Unlock()
+		s.measureCache()
+	}
+}
+
+// measureCache records the size of the cache directory for /metrics.
+func (s *searchServer) measureCache() {
+	if dir, err := cacheDir(); err == nil {
+		s.cacheBytes.Store(dirSize(dir))
+	}
+}
+
+type searchResult struct {
+	Path    string    `json:"path"`
+	Version string    `json:"version"`
+	Time    time.Time `json:"time"`
+	// Matched are the byte offsets of the path that the query matched.
+	Matched []int `json:"matched,omitempty"`
+}
+
+func (s *searchServer) handleSearch(w http.ResponseWriter, r *http.Request) {
+	var user string
+	if s.users != nil {
+		var ok bool
+		if user, ok = s.users.authenticate(r); !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			s.fail(w, "search", http.StatusUnauthorized, "unknown token")
+			return
+		}
+	}
+	limit := editorDefaultResults
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			s.fail(w, "search", http.StatusBadRequest, fmt.Sprintf("invalid limit '%s'", v))
+			return
+		}
+		limit = n
+	}
+
+	start := time.Now()
+	s.mu.RLock()
+	q := r.URL.Query().Get("q")
+	matches, err := queryMatches(r.Context(), s.engine, q)
+	s.mu.RUnlock()
+	s.metrics.observeSearch(time.Since(start))
+	if err != nil {
+		s.fail(w, "search", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if query := strings.TrimSpace(q); user != "" && query != "" {
+		if err := s.users.recordSearch(user, query); err != nil {
+			s.fail(w, "search", http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	results := []searchResult{}
+	for _, m := range newestPerPath(matches, matchPackage) {
+		if limit > 0 && len(results) == limit {
+			break
+		}
+		results = append(results, searchResult{
+			Path:    m.Package.Path,
+			Version: m.Package.Version,
+			Time:    m.Package.Timestamp,
+			Matched: s.engine.Highlight(q, m),
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+	s.metrics.countRequest("search", http.StatusOK)
+}
+
+// handleStatus reports the size and age of the index being served.
+func (s *searchServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	status := serverStatus{Entries: len(s.engine.Packages()), SyncedAt: s.syncedAt, Matcher: s.engine.Matcher()}
+	s.mu.RUnlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+	s.metrics.countRequest("status", http.StatusOK)
+}
+
+// moduleDetails is what GET /module returns about a module.
+type moduleDetails struct {
+	Path     string    `json:"path"`
+	Version  string    `json:"version"`
+	Time     time.Time `json:"time,omitzero"`
+	Licenses []string  `json:"licenses"`
+	Versions []string  `json:"versions"`
+}
+
+// handleModule looks up a module through the proxy. The license comes from
+// deps.dev and is left out if it cannot be reached.
+func (s *searchServer) handleModule(w http.ResponseWriter, r *http.Request) {
+	modPath, latest, err := resolveModule(r.PathValue("path"))
+	if err != nil {
+		s.fail(w, "module", http.StatusNotFound, err.Error())
+		return
+	}
+	versions, err := fetchVersions(modPath)
+	if err != nil {
+		s.fail(w, "module", http.StatusBadGateway, err.Error())
+		return
+	}
+	licenses, _ := fetchLicenses(modPath, latest.Version)
+	details := moduleDetails{
+		Path:     modPath,
+		Version:  latest.Version,
+		Time:     latest.Time,
+		Licenses: append([]string{}, licenses...),
+		Versions: append([]string{}, versions...),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(details)
+	s.metrics.countRequest("module", http.StatusOK)
+}
+
+func (s *searchServer) handleWeb(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	io.WriteString(w, webPage)
+	s.metrics.countRequest("web", http.StatusOK)
+}
+
+func (s *searchServer) fail(w http.ResponseWriter, handler string, code int, message string) {
+	http.Error(w, message, code)
+	s.metrics.countRequest(handler, code)
+}
+
+// handleMetrics writes the metrics in the Prometheus text format.
+func (s *searchServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.metrics.countRequest("metrics", http.StatusOK)
+
+	s.mu.RLock()
+	entries := len(s.engine.Packages())
+	syncAge := time.Since(s.syncedAt).Seconds()
+	s.mu.RUnlock()
+	cacheBytes := s.cacheBytes.Load()
+
+	var b strings.Builder
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	metric("gosearch_index_entries", "gauge", "Index entries being searched.")
+	fmt.Fprintf(&b, "gosearch_index_entries %d\n", entries)
+	metric("gosearch_cache_bytes", "gauge", "Size of the gosearch cache directory as of the last sync.")
+	fmt.Fprintf(&b, "gosearch_cache_bytes %d\n", cacheBytes)
+	metric("gosearch_sync_age_seconds", "gauge", "Time since the index was last brought up to date.")
+	fmt.Fprintf(&b, "gosearch_sync_age_seconds %g\n", syncAge)
+
+	s.metrics.mu.Lock()
+	metric("gosearch_sync_failures_total", "counter", "Refreshes of the index that failed.")
+	fmt.Fprintf(&b, "gosearch_sync_failures_total %d\n", s.metrics.syncFailures)
+	metric("gosearch_search_duration_seconds", "histogram", "Time taken to run a search.")
+	for i, bound := range searchBuckets {
+		fmt.Fprintf(&b, "gosearch_search_duration_seconds_bucket{le=\"%g\"} %d\n", bound, s.metrics.searchCounts[i])
+	}
+	fmt.Fprintf(&b, "gosearch_search_duration_seconds_bucket{le=\"+Inf\"} %d\n", s.metrics.searchTotal)
+	fmt.Fprintf(&b, "gosearch_search_duration_seconds_sum %g\n", s.metrics.searchSum)
+	fmt.Fprintf(&b, "gosearch_search_duration_seconds_count %d\n", s.metrics.searchTotal)
+	metric("gosearch_http_requests_total", "counter", "HTTP requests answered, by handler and status code.")
+	keys := make([][2]string, 0, len(s.metrics.requests))
+	for key := range s.metrics.requests {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b [2]string) int {
+		return strings.Compare(a[0]+" "+a[1], b[0]+" "+b[1])
+	})
+	for _, key := range keys {
+		fmt.Fprintf(&b, "gosearch_http_requests_total{handler=%q,code=%q} %d\n", key[0], key[1], s.metrics.requests[key])
+	}
+	s.metrics.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	io.WriteString(w, b.String())
+}
+
+// dirSize adds up the sizes of the files under dir.
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxUserHistory caps the queries remembered for each user of the server.
+const maxUserHistory = 100
+
+var userNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+// userData is what the server keeps for each of its users.
+type userData struct {
+	Favorites []string `json:"favorites"`
+	Watchlist []string `json:"watchlist"`
+	// History lists the user's searches, most recent last.
+	History []string `json:"history"`
+}
+
+// clone copies d, so that it may be read once the store is unlocked.
+func (d *userData) clone() userData {
+	return userData{Favorites: slices.Clone(d.Favorites), Watchlist: slices.Clone(d.Watchlist), History: slices.Clone(d.History)}
+}
+
+// userStore keeps the users of a shared server, identified by token, and
+// their data, one JSON file per user in dir.
+type userStore struct {
+	// tokens maps each user name to its token.
+	tokens map[string]string
+	dir    string
+
+	mu   sync.Mutex
+	data map[string]*userData
+}
+
+// loadUsers reads the users file at path, a YAML mapping of user names to
+// their tokens, and keeps their data in dir.
+func loadUsers(path, dir string) (*userStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read users file: %w", err)
+	}
+	var tokens map[string]string
+	if err := yaml.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse users file %s: %w", path, err)
+	}
+	seen := make(map[string]string)
+	for name, token := range tokens {
+		if !userNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid user name '%s' in %s", name, path)
+		}
+		if token == "" {
+			return nil, fmt.Errorf("no token for user '%s' in %s", name, path)
+		}
+		if other, ok := seen[token]; ok {
+			return nil, fmt.Errorf("users '%s' and '%s' share a token in %s", other, name, path)
+		}
+		seen[token] = name
+	}
+	return &userStore{tokens: tokens, dir: dir, data: make(map[string]*userData)}, nil
+}
+
+func defaultUsersDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "gosearch", "users"), nil
+}
+
+// authenticate returns the user whose token r carries as a bearer token. ok
+// is false if r carries a token that no user has; a request without one is
+// anonymous.
+func (s *userStore) authenticate(r *http.Request) (user string, ok bool) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", true
+	}
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found {
+		return "", false
+	}
+	for name, t := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			user = name
+		}
+	}
+	return user, user != ""
+}
+
+// update runs fn on the data of user and saves it.
+func (s *userStore) update(user string, fn func(*userData)) (userData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, err := s.load(user)
+	if err != nil {
+		return userData{}, err
+	}
+	fn(d)
+	if err := s.save(user, d); err != nil {
+		return userData{}, err
+	}
+	return d.clone(), nil
+}
+
+func (s *userStore) get(user string) (userData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, err := s.load(user)
+	if err != nil {
+		return userData{}, err
+	}
+	return d.clone(), nil
+}
+
+// load returns the data of user, reading it on first use. A missing file is
+// a user without any data yet.
+func (s *userStore) load(user string) (*userData, error) {
+	if d, ok := s.data[user]; ok {
+		return d, nil
+	}
+	d := &userData{Favorites: []string{}, Watchlist: []string{}, History: []string{}}
+	data, err := os.ReadFile(filepath.Join(s.dir, user+".json"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read the data of user '%s': %w", user, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, d); err != nil {
+			return nil, fmt.Errorf("failed to parse the data of user '%s': %w", user, err)
+		}
+	}
+	s.data[user] = d
+	return d, nil
+}
+
+func (s *userStore) save(user string, d *userData) error {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create users directory: %w", err)
+	}
+	f, err := os.CreateTemp(s.dir, "."+user+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to save the data of user '%s': %w", user, err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to save the data of user '%s': %w", user, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to save the data of user '%s': %w", user, err)
+	}
+	if err := os.Rename(f.Name(), filepath.Join(s.dir, user+".json")); err != nil {
+		return fmt.Errorf("failed to save the data of user '%s': %w", user, err)
+	}
+	return nil
+}
+
+// recordSearch adds query to the history of user, moving it to the end if
+// it was searched before.
+func (s *userStore) recordSearch(user, query string) error {
+	_, err := s.update(user, func(d *userData) {
+		d.History = slices.DeleteFunc(d.History, func(q string) bool { return q == query })
+		d.History = append(d.History, query)
+		if len(d.History) > maxUserHistory {
+			d.History = d.History[len(d.History)-maxUserHistory:]
+		}
+	})
+	return err
+}
+
+// handleUser answers GET /me with the data of the requesting user.
+func (s *searchServer) handleUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.requireUser(w, r, "me")
+	if !ok {
+		return
+	}
+	d, err := s.users.get(user)
+	if err != nil {
+		s.fail(w, "me", http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeUserData(w, "me", user, d)
+}
+
+// handleUserList adds the module path in the request to, or with DELETE
+// removes it from, the list named by the route: favorites or watchlist.
+func (s *searchServer) handleUserList(list string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := s.requireUser(w, r, list)
+		if !ok {
+			return
+		}
+		path := r.PathValue("path")
+		if path == "" {
+			s.fail(w, list, http.StatusBadRequest, "no module path given")
+			return
+		}
+		d, err := s.users.update(user, func(d *userData) {
+			entries := &d.Favorites
+			if list == "watchlist" {
+				entries = &d.Watchlist
+			}
+			*entries = slices.DeleteFunc(*entries, func(p string) bool { return p == path })
+			if r.Method == http.MethodPut {
+				*entries = append(*entries, path)
+			}
+		})
+		if err != nil {
+			s.fail(w, list, http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.writeUserData(w, list, user, d)
+	}
+}
+
+func (s *searchServer) requireUser(w http.ResponseWriter, r *http.Request, handler string) (string, bool) {
+	user, ok := s.users.authenticate(r)
+	if !ok || user == "" {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		s.fail(w, handler, http.StatusUnauthorized, "a valid token is required")
+		return "", false
+	}
+	return user, true
+}
+
+func (s *searchServer) writeUserData(w http.ResponseWriter, handler, user string, d userData) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		User string `json:"user"`
+		userData
+	}{user, d})
+	s.metrics.countRequest(handler, http.StatusOK)
+}
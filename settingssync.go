@@ -0,0 +1,404 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SettingsSyncConfig points at where `gosearch settings` keeps the encrypted
+// settings bundle: a file of a git repository, or an S3 object.
+type SettingsSyncConfig struct {
+	Git  string `yaml:"git"`
+	File string `yaml:"file"`
+	// S3 is an s3://bucket/key URL. Endpoint overrides the AWS endpoint for
+	// S3-compatible stores, which are then addressed path-style.
+	S3       string `yaml:"s3"`
+	Region   string `yaml:"region"`
+	Endpoint string `yaml:"endpoint"`
+	// PassphraseEnv names the environment variable holding the passphrase
+	// the bundle is encrypted with. It is never written anywhere.
+	PassphraseEnv string `yaml:"passphrase_env"`
+}
+
+// settingsMagic starts every bundle, followed by the version of its format.
+const settingsMagic = "gosearch-settings\x01"
+
+// pbkdf2Iterations makes guessing the passphrase of a stolen bundle slow.
+const pbkdf2Iterations = 600_000
+
+// syncedSettings are the files a bundle carries: the config, the
+// annotations, the usage and launch histories, and the favorites.
+var syncedSettings = []struct {
+	name string
+	path func() (string, error)
+}{
+	{"config.yaml", configPath},
+	{"annotations.yaml", annotationsPath},
+	{"usage.json", usagePath},
+	{"launches.json", launchesPath},
+	{"favorites.json", favoritesPath},
+	{"watchlist.json", watchlistPath},
+}
+
+// settingsStore keeps the encrypted bundle somewhere off the machine.
+type settingsStore interface {
+	// get returns the bundle, or nil if none was pushed yet.
+	get(ctx context.Context) ([]byte, error)
+	put(ctx context.Context, data []byte) error
+	String() string
+}
+
+func newSettingsStore(cfg SettingsSyncConfig) (settingsStore, error) {
+	switch {
+	case cfg.Git != "" && cfg.S3 != "":
+		return nil, fmt.Errorf("settings_sync: set either git or s3, not both")
+	case cfg.Git != "":
+		dir, err := cacheDir()
+		if err != nil {
+			return nil, err
+		}
+		file := cfg.File
+		if file == "" {
+			file = "gosearch-settings.enc"
+		}
+		sum := sha256.Sum256([]byte(cfg.Git))
+		return gitSettingsStore{gitTeamBackend{repo: cfg.Git, file: file, dir: filepath.Join(dir, "settings", hex.EncodeToString(sum[:8]))}}, nil
+	case cfg.S3 != "":
+		u, err := url.Parse(cfg.S3)
+		if err != nil || u.Scheme != "s3" || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+			return nil, fmt.Errorf("settings_sync: s3 must look like s3://bucket/key")
+		}
+		region := cfg.Region
+		if region == "" {
+			region = os.Getenv("AWS_REGION")
+		}
+		if region == "" {
+			region = "us-east-1"
+		}
+		return s3SettingsStore{bucket: u.Host, key: strings.TrimPrefix(u.Path, "/"), region: region, endpoint: cfg.Endpoint}, nil
+	}
+	return nil, fmt.Errorf("no settings store configured; set settings_sync.git or settings_sync.s3 in the config file")
+}
+
+// gitSettingsStore keeps the bundle in a file of a git repository, through
+// a clone in the cache directory like the team annotations.
+type gitSettingsStore struct {
+	gitTeamBackend
+}
+
+func (s gitSettingsStore) get(ctx context.Context) ([]byte, error) {
+	if err := s.update(ctx); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, s.file))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (s gitSettingsStore) put(ctx context.Context, data []byte) error {
+	if err := s.update(ctx); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, s.file), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write the settings bundle: %w", err)
+	}
+	if err := s.git(ctx, "add", s.file); err != nil {
+		return err
+	}
+	if err := s.git(ctx, "commit", "--quiet", "-m", "Update gosearch settings"); err != nil {
+		return err
+	}
+	return s.git(ctx, "push", "--quiet")
+}
+
+// s3SettingsStore keeps the bundle in an S3 object, signing requests with
+// the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN
+// credentials of the environment.
+type s3SettingsStore struct {
+	bucket, key, region, endpoint string
+}
+
+func (s s3SettingsStore) String() string { return "s3://" + s.bucket + "/" + s.key }
+
+func (s s3SettingsStore) objectURL() string {
+	if s.endpoint != "" {
+		return strings.TrimSuffix(s.endpoint, "/") + "/" + s.bucket + "/" + s.key
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.region, s.key)
+}
+
+func (s s3SettingsStore) do(ctx context.Context, method string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := signS3(req, body, s.region, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", s, err)
+	}
+	return resp, nil
+}
+
+func (s s3SettingsStore) get(ctx context.Context) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("received non-OK status from %s: %s", s, resp.Status)
+}
+
+func (s s3SettingsStore) put(ctx context.Context, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("received non-OK status from %s: %s", s, resp.Status)
+	}
+	return nil
+}
+
+// signS3 signs req for S3 with AWS Signature Version 4.
+func signS3(req *http.Request, body []byte, region string, now time.Time) error {
+	keyID, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if keyID == "" || secret == "" {
+		return fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set to use S3")
+	}
+	payload := sha256.Sum256(body)
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payload[:]))
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+
+	names := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	values := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": req.Header.Get("X-Amz-Content-Sha256"),
+		"x-amz-date":           amzDate,
+	}
+	if token := req.Header.Get("X-Amz-Security-Token"); token != "" {
+		names = append(names, "x-amz-security-token")
+		values["x-amz-security-token"] = token
+	}
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + values[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	canonical := strings.Join([]string{
+		req.Method, req.URL.EscapedPath(), req.URL.RawQuery,
+		canonicalHeaders.String(), signedHeaders, hex.EncodeToString(payload[:]),
+	}, "\n")
+
+	scope := day + "/" + region + "/s3/aws4_request"
+	digest := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(digest[:])
+	key := []byte("AWS4" + secret)
+	for _, part := range []string{day, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", keyID, scope, signedHeaders, signature))
+	return nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// sealSettings encrypts files, keyed by name, with AES-256-GCM under a key
+// derived from passphrase with PBKDF2. The salt and nonce travel with it.
+func sealSettings(files map[string][]byte, passphrase string) ([]byte, error) {
+	plain, err := json.Marshal(files)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	rand.Read(salt)
+	gcm, err := settingsCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	rand.Read(nonce)
+
+	out := append([]byte(settingsMagic), salt...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plain, []byte(settingsMagic)), nil
+}
+
+// openSettings decrypts a bundle written by sealSettings.
+func openSettings(data []byte, passphrase string) (map[string][]byte, error) {
+	rest, ok := bytes.CutPrefix(data, []byte(settingsMagic))
+	if !ok || len(rest) < 16 {
+		return nil, fmt.Errorf("not a gosearch settings bundle")
+	}
+	salt, rest := rest[:16], rest[16:]
+	gcm, err := settingsCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) < gcm.NonceSize() {
+		return nil, fmt.Errorf("not a gosearch settings bundle")
+	}
+	nonce, sealed := rest[:gcm.NonceSize()], rest[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, sealed, []byte(settingsMagic))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the settings: wrong passphrase or damaged bundle")
+	}
+	var files map[string][]byte
+	if err := json.Unmarshal(plain, &files); err != nil {
+		return nil, fmt.Errorf("failed to parse the settings bundle: %w", err)
+	}
+	return files, nil
+}
+
+func settingsCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// runSettings implements `gosearch settings push|pull`: push encrypts the
+// synced files and uploads them, pull downloads and decrypts them, replacing
+// the local copies.
+func runSettings(args []string, w io.Writer) error {
+	if len(args) != 1 || (args[0] != "pull" && args[0] != "push") {
+		return fmt.Errorf("usage: gosearch settings pull|push")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	store, err := newSettingsStore(cfg.SettingsSync)
+	if err != nil {
+		return err
+	}
+	env := cmp.Or(cfg.SettingsSync.PassphraseEnv, "GOSEARCH_SYNC_PASSPHRASE")
+	passphrase := os.Getenv(env)
+	if passphrase == "" {
+		return fmt.Errorf("set %s to the passphrase the settings are encrypted with", env)
+	}
+	ctx, cancel := context.WithTimeout(appCtx, 2*time.Minute)
+	defer cancel()
+
+	if args[0] == "push" {
+		files := make(map[string][]byte)
+		for _, f := range syncedSettings {
+			path, err := f.path()
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", f.name, err)
+			}
+			files[f.name] = data
+		}
+		bundle, err := sealSettings(files, passphrase)
+		if err != nil {
+			return err
+		}
+		if err := store.put(ctx, bundle); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Pushed %d encrypted files to %s\n", len(files), store)
+		return nil
+	}
+
+	bundle, err := store.get(ctx)
+	if err != nil {
+		return err
+	}
+	if bundle == nil {
+		return fmt.Errorf("no settings pushed to %s yet", store)
+	}
+	files, err := openSettings(bundle, passphrase)
+	if err != nil {
+		return err
+	}
+	pulled := 0
+	for _, f := range syncedSettings {
+		data, ok := files[f.name]
+		if !ok {
+			continue
+		}
+		path, err := f.path()
+		if err != nil {
+			return err
+		}
+		if err := writeSetting(path, data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.name, err)
+		}
+		pulled++
+	}
+	fmt.Fprintf(w, "Pulled %d files from %s\n", pulled, store)
+	return nil
+}
+
+// writeSetting replaces the file at path in one step, so a failed pull
+// leaves the old copy intact.
+func writeSetting(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".settings-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+// state is the screen the TUI is in. Every key press and async message is
+// interpreted according to it, and moving between screens goes through
+// setState so that late messages cannot put the model in an impossible mix
+// of screens.
+type state int
+
+const (
+	stateLoading state = iota
+	stateBrowsing
+	statePicking
+	stateMenu
+	stateHelp
+	stateCategories
+	stateHistory
+	stateAnnotating
+	stateDetail
+	stateLicenses
+	stateUpgrading
+	stateOutdated
+	stateGraph
+	stateReplacing
+	stateFiles
+	stateGrepping
+	stateConfirming
+	stateQuitting
+	stateError
+)
+
+var stateNames = map[state]string{
+	stateLoading:    "loading",
+	stateBrowsing:   "browsing",
+	statePicking:    "picking",
+	stateMenu:       "menu",
+	stateHelp:       "help",
+	stateCategories: "categories",
+	stateHistory:    "history",
+	stateAnnotating: "annotating",
+	stateDetail:     "detail",
+	stateLicenses:   "licenses",
+	stateUpgrading:  "upgrading",
+	stateOutdated:   "outdated",
+	stateGraph:      "graph",
+	stateReplacing:  "replacing",
+	stateFiles:      "files",
+	stateGrepping:   "grepping",
+	stateConfirming: "confirming",
+	stateQuitting:   "quitting",
+	stateError:      "error",
+}
+
+func (s state) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("state(%d)", int(s))
+}
+
+// transitions lists the states reachable from each state. Quitting may still
+// turn into an error because actions report failures after deciding to quit.
+var transitions = map[state][]state{
+	stateLoading:    {stateBrowsing, stateQuitting, stateError},
+	stateBrowsing:   {statePicking, stateMenu, stateHelp, stateCategories, stateHistory, stateAnnotating, stateDetail, stateLicenses, stateUpgrading, stateOutdated, stateGraph, stateReplacing, stateFiles, stateGrepping, stateConfirming, stateQuitting, stateError},
+	statePicking:    {stateBrowsing, stateQuitting, stateError},
+	stateMenu:       {stateBrowsing, statePicking, stateLicenses, stateUpgrading, stateReplacing, stateFiles, stateGrepping, stateQuitting, stateError},
+	stateHelp:       {stateBrowsing, stateQuitting, stateError},
+	stateCategories: {stateBrowsing, stateQuitting, stateError},
+	stateHistory:    {stateBrowsing, stateQuitting, stateError},
+	stateAnnotating: {stateBrowsing, stateQuitting, stateError},
+	stateDetail:     {stateBrowsing, stateMenu, stateQuitting, stateError},
+	stateLicenses:   {stateBrowsing, stateQuitting, stateError},
+	stateUpgrading:  {stateBrowsing, stateQuitting, stateError},
+	stateOutdated:   {stateBrowsing, stateConfirming, stateQuitting, stateError},
+	stateGraph:      {stateBrowsing, stateQuitting, stateError},
+	stateReplacing:  {stateBrowsing, stateQuitting, stateError},
+	stateFiles:      {stateBrowsing, stateQuitting, stateError},
+	stateGrepping:   {stateBrowsing, stateQuitting, stateError},
+	stateConfirming: {stateBrowsing, stateOutdated, stateQuitting, stateError},
+	stateQuitting:   {stateError},
+	stateError:      {},
+}
+
+// setState moves the model to next if the transition is allowed and reports
+// whether it happened.
+func (m *model) setState(next state) bool {
+	if m.state == next {
+		return true
+	}
+	for _, allowed := range transitions[m.state] {
+		if allowed == next {
+			m.state = next
+			return true
+		}
+	}
+	return false
+}
+
+// done reports whether the model has reached a terminal state.
+func (m model) done() bool {
+	return m.state == stateQuitting || m.state == stateError
+}
+
+// quit moves the model to the quitting state with a final message.
+func (m *model) quit(message string) tea.Cmd {
+	if !m.setState(stateQuitting) {
+		return nil
+	}
+	m.finalMessage = message
+	return tea.Quit
+}
+
+// fail moves the model to the error state and quits.
+func (m *model) fail(err error) tea.Cmd {
+	if !m.setState(stateError) {
+		return nil
+	}
+	m.err = err
+	m.finalMessage = fmt.Sprintf("Error: %v", err)
+	return tea.Quit
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultStatusLine is the status line template used unless the user
+// configures their own.
+const defaultStatusLine = "Found {{.Filtered}} packages (filtered from {{.Total}}, {{.Mode}}), synced {{.Age}}{{if .New}}, +{{.New}} new{{end}}. {{.Keys}}"
+
+// statusData is what the status line template can refer to.
+type statusData struct {
+	Filtered int
+	Total    int
+	Query    string
+	// Mode is the active matcher and Sort the weighted ranking signals.
+	Mode string
+	Sort string
+	// Synced is when the loaded index data was fetched and Age how long ago
+	// that was.
+	Synced string
+	Age    string
+	// New counts the entries merged in by background refreshes.
+	New int
+	// Enter is the action Enter runs.
+	Enter string
+	// Keys hints at the keys that do something with the results now.
+	Keys string
+}
+
+func parseStatusLine(text string) (*template.Template, error) {
+	tmpl, err := template.New("status_line").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid status_line template: %w", err)
+	}
+	return tmpl, nil
+}
+
+func (m model) statusLine() string {
+	data := statusData{
+		Filtered: len(m.list.matches) - m.expansion.rows(),
+		Total:    cmp.Or(m.remoteTotal, len(m.engine.Packages())),
+		Query:    m.input.query,
+		Mode:     m.engine.Matcher(),
+		Sort:     m.sortLabel,
+		New:      m.newCount,
+		Enter:    m.config.EnterAction,
+		Keys:     m.listHints(),
+	}
+	if !m.syncedAt.IsZero() {
+		data.Synced = m.syncedAt.Format("2006-01-02 15:04")
+		data.Age = formatAge(clock().Sub(m.syncedAt))
+	}
+
+	var b strings.Builder
+	if err := m.statusTemplate.Execute(&b, data); err != nil {
+		return fmt.Sprintf("status_line: %v", err)
+	}
+	return b.String()
+}
+
+// listHints are the footer hints of the results screen: moving, selecting
+// and the actions while there is a result, the keys that undo what is on
+// screen, like expanded versions, and the keys that always work. While the
+// query has the focus, keys that are letters are left out.
+func (m model) listHints() string {
+	hint, focus := m.keys.typingHint, "Esc for single-key commands"
+	if m.input.blurred {
+		hint, focus = m.keys.hint, "/ to type the query"
+	}
+	var hints []string
+	pkg, selected := m.selectedPackage()
+	if selected {
+		hints = append(hints,
+			hint("to navigate", "up", "down"),
+			hint("to select", "enter"),
+			hint("for actions", "actions"))
+	}
+	if m.expansion.shown {
+		hints = append(hints, hint("to hide the versions", "expand"))
+	}
+	if selected && m.migrations[pkg.Path] != nil {
+		hints = append(hints, hint("to search for the new path", "moved"))
+	}
+	if m.preview {
+		hints = append(hints, hint("to hide the preview", "preview"))
+	}
+	if slices.Contains(strings.Fields(m.input.query), favoritesQuery) {
+		hints = append(hints, hint("to show all modules", "favorites"))
+	}
+	quit := cmp.Or(hint("or Ctrl+C to quit", "quit"), "Ctrl+C to quit")
+	hints = append(hints,
+		hint("to change mode", "matcher"),
+		hint("to refresh", "refresh"),
+		hint("for help", "help"),
+		focus,
+		quit)
+	return joinHints(hints...)
+}
+
+// clock tells the time that syncs are stamped with and their age measured
+// against. Tests pin it so that frames do not show how long they ran.
+var clock = time.Now
+
+// formatAge renders a duration in the largest sensible unit, e.g. "3h ago".
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"gosearch/index"
+)
+
+// stdActions are the actions that make sense for a standard library
+// package; the rest need a module on the proxy.
+var stdActions = []string{"copy", "print", "open", "note", "tag", "favorite", "hook"}
+
+// isStdPath reports whether path is a standard library import path: its
+// first element has no dot, which module paths need.
+func isStdPath(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
+
+// listStdPackages asks the go command for the standard library packages,
+// leaving out internal and vendored ones, at the version of the installed
+// toolchain. Without a go command it returns nil.
+func listStdPackages() []Package {
+	out, err := command("go", "list", "std").Output()
+	if err != nil {
+		return nil
+	}
+	version := "std"
+	if v, err := command("go", "env", "GOVERSION").Output(); err == nil {
+		version = strings.TrimSpace(string(v))
+	}
+	var packages []Package
+	for _, path := range strings.Fields(string(out)) {
+		elems := strings.Split(path, "/")
+		if slices.Contains(elems, "internal") || slices.Contains(elems, "vendor") || !isStdPath(path) {
+			continue
+		}
+		// No timestamp: they are never the newest entries a refresh
+		// starts from.
+		packages = append(packages, Package{Path: path, Version: version})
+	}
+	return packages
+}
+
+// stdlibSource adds the standard library to a full fetch of Source. Later
+// fetches, as refreshes make, only return what Source has.
+type stdlibSource struct {
+	index.Source
+}
+
+func (s stdlibSource) Fetch(ctx context.Context, since time.Time) ([]Package, error) {
+	packages, err := s.Source.Fetch(ctx, since)
+	if err != nil || !since.IsZero() {
+		return packages, err
+	}
+	return append(packages, listStdPackages()...), nil
+}
+
+// stdBadge marks standard library rows.
+func (m model) stdBadge(pkg Package) string {
+	if !isStdPath(pkg.Path) {
+		return ""
+	}
+	return " " + versionStyle.Render("[std]")
+}
@@ -0,0 +1,155 @@
+package sumdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/mod/sumdb"
+)
+
+// ops implements sumdb.ClientOps over HTTP, with configuration and cache
+// files kept in dir when it is set and in memory otherwise.
+type ops struct {
+	name   string
+	key    string
+	direct string
+	proxy  string
+	http   *http.Client
+	dir    string
+
+	baseOnce sync.Once
+	base     string
+
+	mu      sync.Mutex
+	configs map[string][]byte
+	cache   map[string][]byte
+}
+
+// remoteBase picks where to read the database from, preferring a proxy that
+// says it serves it, like the go command does.
+func (o *ops) remoteBase() string {
+	o.baseOnce.Do(func() {
+		o.base = o.direct
+		if o.proxy == "" {
+			return
+		}
+		resp, err := o.http.Get(o.proxy + "/sumdb/" + o.name + "/supported")
+		if err != nil {
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			o.base = o.proxy + "/sumdb/" + o.name
+		}
+	})
+	return o.base
+}
+
+func (o *ops) ReadRemote(path string) ([]byte, error) {
+	resp, err := o.http.Get(o.remoteBase() + path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received non-OK status from %s: %s", o.name, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (o *ops) ReadConfig(file string) ([]byte, error) {
+	if file == "key" {
+		return []byte(o.key), nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.readConfig(file)
+}
+
+// readConfig returns the stored file, or nothing if there is none yet, which
+// the client takes as an empty tree. It must be called with mu held.
+func (o *ops) readConfig(file string) ([]byte, error) {
+	if o.dir == "" {
+		return o.configs[file], nil
+	}
+	data, err := os.ReadFile(filepath.Join(o.dir, "config", filepath.FromSlash(file)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (o *ops) WriteConfig(file string, old, new []byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	current, err := o.readConfig(file)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(current, old) {
+		return sumdb.ErrWriteConflict
+	}
+	if o.dir == "" {
+		o.configs[file] = new
+		return nil
+	}
+	return writeFile(filepath.Join(o.dir, "config", filepath.FromSlash(file)), new)
+}
+
+func (o *ops) ReadCache(file string) ([]byte, error) {
+	if o.dir == "" {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		if data, ok := o.cache[file]; ok {
+			return data, nil
+		}
+		return nil, os.ErrNotExist
+	}
+	return os.ReadFile(filepath.Join(o.dir, "cache", filepath.FromSlash(file)))
+}
+
+func (o *ops) WriteCache(file string, data []byte) {
+	if o.dir == "" {
+		o.mu.Lock()
+		o.cache[file] = data
+		o.mu.Unlock()
+		return
+	}
+	// A cache that cannot be written only costs another download.
+	_ = writeFile(filepath.Join(o.dir, "cache", filepath.FromSlash(file)), data)
+}
+
+func (o *ops) Log(msg string) {}
+
+// SecurityError is logged; the failing lookup itself returns an error
+// wrapping sumdb.ErrSecurity.
+func (o *ops) SecurityError(msg string) {
+	log.Printf("checksum database %s: %s", o.name, msg)
+}
+
+// writeFile replaces path atomically.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
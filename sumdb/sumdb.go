@@ -0,0 +1,202 @@
+// Package sumdb is a small client for the Go checksum database
+// (sum.golang.org or a GOSUMDB replacement). It looks up the go.sum lines of
+// module versions and verifies hashes against them, checking the database's
+// signed tree along the way.
+package sumdb
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb"
+)
+
+const (
+	defaultName  = "sum.golang.org"
+	defaultKey   = "sum.golang.org+033de0ae+Ac4zctda0e5eza+HJyk9SxEdh+s3Ux18htTTAD8OuAn8"
+	defaultProxy = "https://proxy.golang.org"
+)
+
+var (
+	// ErrDisabled is returned by lookups when GOSUMDB is off.
+	ErrDisabled = errors.New("checksum database disabled by GOSUMDB=off")
+	// ErrExcluded is returned for modules matched by GONOSUMDB.
+	ErrExcluded = sumdb.ErrGONOSUMDB
+)
+
+// Config selects the checksum database the way the go command does.
+type Config struct {
+	// GOSUMDB names the database: empty for sum.golang.org, "off", or
+	// "name[+key] [url]".
+	GOSUMDB string
+	// GONOSUMDB lists module path patterns not to look up. It defaults to
+	// GOPRIVATE.
+	GONOSUMDB string
+	GOPRIVATE string
+	// GOPROXY is consulted for a proxy that serves the database, as
+	// proxy.golang.org does for sum.golang.org.
+	GOPROXY string
+	// CacheDir, if set, keeps verified tiles and the latest signed tree
+	// between runs; otherwise they only live as long as the Client.
+	CacheDir string
+	HTTP     *http.Client
+}
+
+// FromEnv reads the configuration from `go env`, falling back to the
+// process environment when the go command is not available.
+func FromEnv() Config {
+	names := []string{"GOSUMDB", "GONOSUMDB", "GOPRIVATE", "GOPROXY"}
+	values := make([]string, len(names))
+	if out, err := exec.Command("go", append([]string{"env"}, names...)...).Output(); err == nil {
+		copy(values, strings.Split(strings.TrimRight(string(out), "\n"), "\n"))
+	} else {
+		for i, name := range names {
+			values[i] = os.Getenv(name)
+		}
+	}
+	return Config{GOSUMDB: values[0], GONOSUMDB: values[1], GOPRIVATE: values[2], GOPROXY: values[3]}
+}
+
+// Client looks up module hashes in a checksum database.
+type Client struct {
+	name     string
+	disabled bool
+	client   *sumdb.Client
+}
+
+// New returns a client for the database selected by cfg.
+func New(cfg Config) (*Client, error) {
+	name, key, url, err := parseGOSUMDB(cfg.GOSUMDB)
+	if err != nil {
+		return nil, err
+	}
+	if name == "off" {
+		return &Client{name: name, disabled: true}, nil
+	}
+
+	httpClient := cfg.HTTP
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	o := &ops{
+		name:    name,
+		key:     key,
+		direct:  url,
+		proxy:   firstProxy(cfg.GOPROXY),
+		http:    httpClient,
+		dir:     cfg.CacheDir,
+		configs: make(map[string][]byte),
+		cache:   make(map[string][]byte),
+	}
+
+	c := sumdb.NewClient(o)
+	noSumDB := cfg.GONOSUMDB
+	if noSumDB == "" {
+		noSumDB = cfg.GOPRIVATE
+	}
+	c.SetGONOSUMDB(noSumDB)
+	return &Client{name: name, client: c}, nil
+}
+
+// Name returns the name of the database, e.g. sum.golang.org.
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Lookup returns the go.sum lines the database holds for path@version. A
+// version ending in /go.mod looks up the hash of the go.mod file instead of
+// the module zip.
+func (c *Client) Lookup(path, version string) ([]string, error) {
+	if c.disabled {
+		return nil, ErrDisabled
+	}
+	if err := module.Check(path, strings.TrimSuffix(version, "/go.mod")); err != nil {
+		return nil, err
+	}
+	lines, err := c.client.Lookup(path, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %s@%s in %s: %w", path, version, c.name, err)
+	}
+	return lines, nil
+}
+
+// Hash returns the database's hash of path@version, such as "h1:...". As
+// with Lookup, version may end in /go.mod.
+func (c *Client) Hash(path, version string) (string, error) {
+	lines, err := c.Lookup(path, version)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range lines {
+		if f := strings.Fields(line); len(f) == 3 && f[0] == path && f[1] == version {
+			return f[2], nil
+		}
+	}
+	return "", fmt.Errorf("%s has no hash for %s %s", c.name, path, version)
+}
+
+// Verify checks hash, as written in go.sum, against the database's record of
+// path@version.
+func (c *Client) Verify(path, version, hash string) error {
+	want, err := c.Hash(path, version)
+	if err != nil {
+		return err
+	}
+	if hash != want {
+		return fmt.Errorf("checksum mismatch for %s %s: have %s, %s has %s", path, version, hash, c.name, want)
+	}
+	return nil
+}
+
+// parseGOSUMDB splits a GOSUMDB value into the database name, its verifier
+// key, and the URL to reach it directly.
+func parseGOSUMDB(value string) (name, key, url string, err error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		fields = []string{defaultName}
+	}
+	if fields[0] == "off" {
+		return "off", "", "", nil
+	}
+	if len(fields) > 2 {
+		return "", "", "", fmt.Errorf("invalid GOSUMDB: too many fields")
+	}
+
+	key = fields[0]
+	switch key {
+	case defaultName:
+		key = defaultKey
+	case "sum.golang.google.cn":
+		key, url = defaultKey, "https://sum.golang.google.cn"
+	}
+	name, _, _ = strings.Cut(key, "+")
+	if !strings.Contains(key, "+") {
+		return "", "", "", fmt.Errorf("invalid GOSUMDB: unknown database %q needs a key", name)
+	}
+	if len(fields) == 2 {
+		url = fields[1]
+	}
+	if url == "" {
+		url = "https://" + name
+	}
+	return name, key, strings.TrimSuffix(url, "/"), nil
+}
+
+// firstProxy returns the first proxy URL in a GOPROXY list.
+func firstProxy(goproxy string) string {
+	if goproxy == "" {
+		return defaultProxy
+	}
+	first, _, _ := strings.Cut(goproxy, ",")
+	first, _, _ = strings.Cut(first, "|")
+	if first == "direct" || first == "off" {
+		return ""
+	}
+	return strings.TrimSuffix(first, "/")
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"gosearch/index"
+)
+
+// runSync implements `gosearch sync`: it pages through the whole index feed
+// and writes every entry to a newline-delimited JSON file. An output file
+// left incomplete by an earlier failed sync is resumed instead. With
+// --daemon it keeps running and appends new entries to the file shortly
+// before the times of day gosearch is usually launched.
+func runSync(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	output := fs.String("output", "", "write the index entries to `file`")
+	indexURL := fs.String("index-url", "", "index feed `url` to sync from (default index_url, or index.golang.org)")
+	sinceFlag := fs.String("since", "", "only fetch entries published at or after `time` (RFC 3339, YYYY-MM-DD, or an age such as 90d)")
+	untilFlag := fs.String("until", "", "only fetch entries published at or before `time`")
+	daemon := fs.Bool("daemon", false, "keep running, syncing new entries before the times gosearch is usually launched")
+	interval := fs.Duration("interval", 6*time.Hour, "with --daemon, how often to sync until a usage pattern emerges")
+	rateFlag := fs.String("rate-limit", "", "read at most `size` per second from the feed, e.g. 500KiB (default sync_rate_limit)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *output == "" {
+		return fmt.Errorf("usage: gosearch sync --output <file> [--since <time>] [--until <time>]")
+	}
+
+	now := time.Now()
+	since, err := parseTimeFlag(*sinceFlag, now)
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	until, err := parseTimeFlag(*untilFlag, now)
+	if err != nil {
+		return fmt.Errorf("invalid --until: %w", err)
+	}
+	if !since.IsZero() && !until.IsZero() && until.Before(since) {
+		return fmt.Errorf("--until must not be before --since")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if *indexURL == "" {
+		*indexURL = cfg.IndexURL
+	}
+	rate := cfg.SyncRateLimit
+	if *rateFlag != "" {
+		if rate, err = parseByteSize(*rateFlag); err != nil {
+			return fmt.Errorf("invalid --rate-limit: %w", err)
+		}
+	}
+	if *daemon {
+		if !until.IsZero() {
+			return fmt.Errorf("--until cannot be used with --daemon")
+		}
+		return runSyncDaemon(*indexURL, *output, since, *interval, rate, w)
+	}
+
+	marker, err := readPartialMarker(*output)
+	if err != nil {
+		return err
+	}
+
+	progress := func(p index.Progress) {
+		fmt.Fprintln(w, formatProgress(p))
+	}
+
+	var total int
+	if marker != nil {
+		fmt.Fprintf(w, "Resuming incomplete sync of %s from %s\n", *output, marker.Through.Format(time.RFC3339))
+		total, err = syncDump(newFeed(marker.URL, rate), *output, marker.Through, marker.Until, true, progress, nil)
+	} else {
+		total, err = syncDump(newFeed(*indexURL, rate), *output, since, until, false, progress, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Wrote %d entries to %s\n", total, *output)
+	return nil
+}
+
+// runSyncDaemon syncs the dump at path, then keeps adding the entries
+// published since its newest one, each time syncLead before the next slot
+// of the day in which gosearch is typically launched, or every interval
+// while there is no such slot. Failures are reported and retried at the
+// next sync.
+func runSyncDaemon(url, path string, since time.Time, interval time.Duration, rate byteSize, w io.Writer) error {
+	history, err := launchesPath()
+	if err != nil {
+		return err
+	}
+	progress := func(p index.Progress) {
+		fmt.Fprintln(w, formatProgress(p))
+	}
+	for {
+		total, err := syncLatest(url, path, since, rate, progress)
+		if err != nil {
+			fmt.Fprintf(w, "Error: %v\n", err)
+		} else {
+			fmt.Fprintf(w, "Added %d entries to %s\n", total, path)
+		}
+
+		now := time.Now()
+		launches, err := readLaunches(history)
+		if err != nil {
+			fmt.Fprintf(w, "Error: %v\n", err)
+		}
+		next := nextSync(launches, now)
+		if next.IsZero() {
+			next = now.Add(interval)
+			fmt.Fprintf(w, "Next sync at %s; no usual launch time yet\n", next.Format("2006-01-02 15:04"))
+		} else {
+			fmt.Fprintf(w, "Next sync at %s, before a usual launch\n", next.Format("2006-01-02 15:04"))
+		}
+		time.Sleep(time.Until(next))
+	}
+}
+
+// syncLatest brings the dump at path up to date: it resumes a sync left
+// incomplete, appends the entries published since its newest one, or, if
+// there is no dump yet, syncs one from since.
+func syncLatest(url, path string, since time.Time, rate byteSize, progress func(index.Progress)) (int, error) {
+	marker, err := readPartialMarker(path)
+	if err != nil {
+		return 0, err
+	}
+	if marker != nil {
+		return syncDump(newFeed(marker.URL, rate), path, marker.Through, marker.Until, true, progress, nil)
+	}
+	newest, err := newestEntry(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return syncDump(newFeed(url, rate), path, since, time.Time{}, false, progress, nil)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return syncDump(newFeed(url, rate), path, newest, time.Time{}, true, progress, nil)
+}
+
+// newestEntry returns the latest timestamp of the entries in dump.
+func newestEntry(dump string) (time.Time, error) {
+	f, err := os.Open(dump)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	packages, err := index.Decode(f)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error reading index file %s: %w", dump, err)
+	}
+	var newest time.Time
+	for _, p := range packages {
+		if p.Timestamp.After(newest) {
+			newest = p.Timestamp
+		}
+	}
+	return newest, nil
+}
+
+// newFeed returns a source for the feed at url read at no more than rate
+// bytes per second, or as fast as possible if rate is zero.
+func newFeed(url string, rate byteSize) *index.HTTPSource {
+	feed := index.NewHTTPSource(url)
+	feed.RateLimit = int64(rate)
+	return feed
+}
+
+// partialMarker records that a dump file stops short of the end of the feed
+// because its sync failed. It is stored next to the dump as <file>.incomplete.
+type partialMarker struct {
+	Through time.Time `json:"through"`
+	URL     string    `json:"url"`
+	Error   string    `json:"error"`
+	// Until is the end of the requested sync window, if the sync was bounded.
+	Until time.Time `json:"until,omitzero"`
+}
+
+func partialMarkerPath(dump string) string {
+	return dump + ".incomplete"
+}
+
+// readPartialMarker returns the marker of dump, or nil if it is complete.
+func readPartialMarker(dump string) (*partialMarker, error) {
+	data, err := os.ReadFile(partialMarkerPath(dump))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync marker: %w", err)
+	}
+
+	var marker partialMarker
+	if err := json.Unmarshal(data, &marker); err != nil {
+		return nil, &ParseError{What: "sync marker", Path: partialMarkerPath(dump), Err: err}
+	}
+	return &marker, nil
+}
+
+func writePartialMarker(dump string, marker partialMarker) error {
+	data, err := json.Marshal(marker)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(partialMarkerPath(dump), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write sync marker: %w", err)
+	}
+	return nil
+}
+
+// syncDump pages through feed from since and writes the entries
+// to path. When resuming, since is the newest timestamp already in the file
+// and new entries are appended after it. onPage, if set, sees every page
+// after it has been written.
+//
+// If the walk fails after some entries were written, the file is kept and
+// marked incomplete so it can still be searched and resumed later.
+func syncDump(feed *index.HTTPSource, path string, since, until time.Time, resume bool, progress func(index.Progress), onPage func([]index.Package)) (int, error) {
+	syncer := index.Syncer{Source: feed, Progress: progress, Until: until}
+
+	var f *os.File
+	var err error
+	if resume {
+		if syncer.Have, err = entriesAt(path, since); err != nil {
+			return 0, err
+		}
+		f, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	} else {
+		f, err = os.CreateTemp(filepath.Dir(path), ".gosearch-sync-*")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open output file: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	total := 0
+	through := since
+	walkErr := syncer.Walk(appCtx, since, func(page []index.Package) error {
+		for _, p := range page {
+			if err := enc.Encode(p); err != nil {
+				return fmt.Errorf("failed to write index entry: %w", err)
+			}
+		}
+		total += len(page)
+		through = page[len(page)-1].Timestamp
+		if onPage != nil {
+			onPage(page)
+		}
+		return nil
+	})
+
+	if err := f.Close(); err != nil && walkErr == nil {
+		walkErr = fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	if walkErr != nil && total == 0 && !resume {
+		os.Remove(f.Name())
+		return 0, walkErr
+	}
+
+	if !resume {
+		if err := os.Rename(f.Name(), path); err != nil {
+			os.Remove(f.Name())
+			return total, fmt.Errorf("failed to move output file into place: %w", err)
+		}
+	}
+
+	if walkErr != nil {
+		if err := writePartialMarker(path, partialMarker{Through: through, URL: feed.URL, Error: walkErr.Error(), Until: until}); err != nil {
+			return total, err
+		}
+		return total, fmt.Errorf("sync failed after %d entries; partial data kept in %s (incomplete through %s): %w",
+			total, path, through.Format(time.RFC3339), walkErr)
+	}
+
+	if err := os.Remove(partialMarkerPath(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return total, fmt.Errorf("failed to clear sync marker: %w", err)
+	}
+	return total, nil
+}
+
+// parseTimeFlag accepts an RFC 3339 timestamp, a YYYY-MM-DD date, or an age
+// such as 90d, 2w, or 36h measured back from now. An empty value is the zero
+// time.
+func parseTimeFlag(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+
+	units := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	if unit, ok := units[value[len(value)-1]]; ok {
+		if n, err := strconv.Atoi(value[:len(value)-1]); err == nil && n >= 0 {
+			return now.Add(-time.Duration(n) * unit), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("cannot parse '%s' as a time, date, or age", value)
+}
+
+// entriesAt returns the keys of the entries in dump stamped exactly with ts.
+func entriesAt(dump string, ts time.Time) (map[string]bool, error) {
+	f, err := os.Open(dump)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index file: %w", err)
+	}
+	defer f.Close()
+
+	packages, err := index.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading index file %s: %w", dump, err)
+	}
+
+	keys := make(map[string]bool)
+	for _, p := range packages {
+		if p.Timestamp.Equal(ts) {
+			keys[index.Key(p)] = true
+		}
+	}
+	return keys, nil
+}
+
+// formatProgress renders a sync progress event as a single line.
+func formatProgress(p index.Progress) string {
+	line := fmt.Sprintf("Fetched %d pages, %d entries, %s (through %s)",
+		p.Pages, p.Entries, formatBytes(p.Bytes), p.Through.Format(time.RFC3339))
+	if p.ETA >= time.Second {
+		line += fmt.Sprintf(", about %s left", p.ETA.Round(time.Second))
+	}
+	return line
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
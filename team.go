@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+	"gopkg.in/yaml.v3"
+)
+
+// TeamConfig points at annotations shared by a team. Exactly one of URL and
+// Git is set: URL is fetched with GET and updated with PUT, Git is a
+// repository holding File.
+type TeamConfig struct {
+	URL  string `yaml:"url"`
+	Git  string `yaml:"git"`
+	File string `yaml:"file"`
+}
+
+// Tags with a meaning for the team: they badge results in the list.
+const (
+	tagApproved  = "approved"
+	tagPreferred = "preferred"
+	tagBlocked   = "blocked"
+)
+
+// teamBackend stores the shared annotations, in the same format as the
+// personal annotations file.
+type teamBackend interface {
+	pull(ctx context.Context) (map[string]annotation, error)
+	push(ctx context.Context, entries map[string]annotation) error
+	String() string
+}
+
+// newTeamBackend returns the backend configured by cfg, or nil if there is
+// none.
+func newTeamBackend(cfg TeamConfig) (teamBackend, error) {
+	switch {
+	case cfg.URL != "" && cfg.Git != "":
+		return nil, fmt.Errorf("team: set either url or git, not both")
+	case cfg.URL != "":
+		return httpTeamBackend{url: cfg.URL}, nil
+	case cfg.Git != "":
+		dir, err := cacheDir()
+		if err != nil {
+			return nil, err
+		}
+		file := cfg.File
+		if file == "" {
+			file = "annotations.yaml"
+		}
+		sum := sha256.Sum256([]byte(cfg.Git))
+		return gitTeamBackend{repo: cfg.Git, file: file, dir: filepath.Join(dir, "team", hex.EncodeToString(sum[:8]))}, nil
+	}
+	return nil, nil
+}
+
+// httpTeamBackend keeps the shared annotations at a URL.
+type httpTeamBackend struct {
+	url string
+}
+
+func (b httpTeamBackend) String() string { return b.url }
+
+func (b httpTeamBackend) pull(ctx context.Context) (map[string]annotation, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch team annotations: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return map[string]annotation{}, nil
+	default:
+		return nil, fmt.Errorf("received non-OK status from %s: %s", b.url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read team annotations: %w", err)
+	}
+	return parseSharedAnnotations(data)
+}
+
+func (b httpTeamBackend) push(ctx context.Context, entries map[string]annotation) error {
+	data, err := yaml.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode annotations: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, b.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/yaml")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload team annotations: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("received non-OK status from %s: %s", b.url, resp.Status)
+	}
+	return nil
+}
+
+// gitTeamBackend keeps the shared annotations in a file of a git repository,
+// worked on through a clone in the cache directory. The clone doubles as the
+// offline copy when the remote cannot be reached.
+type gitTeamBackend struct {
+	repo string
+	file string
+	dir  string
+}
+
+func (b gitTeamBackend) String() string { return b.repo }
+
+func (b gitTeamBackend) git(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", b.dir}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s failed: %w\n%s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// update clones the repository or fast-forwards the existing clone.
+func (b gitTeamBackend) update(ctx context.Context) error {
+	if _, err := os.Stat(filepath.Join(b.dir, ".git")); err == nil {
+		return b.git(ctx, "pull", "--quiet", "--ff-only")
+	}
+	if err := os.MkdirAll(b.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create team directory: %w", err)
+	}
+	return b.git(ctx, "clone", "--quiet", b.repo, ".")
+}
+
+func (b gitTeamBackend) pull(ctx context.Context) (map[string]annotation, error) {
+	updateErr := b.update(ctx)
+	data, err := os.ReadFile(filepath.Join(b.dir, b.file))
+	if errors.Is(err, os.ErrNotExist) {
+		if updateErr != nil {
+			return nil, updateErr
+		}
+		return map[string]annotation{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read team annotations: %w", err)
+	}
+	entries, err := parseSharedAnnotations(data)
+	if err != nil {
+		return nil, err
+	}
+	// A stale clone is still worth showing; the caller hears why it is stale.
+	return entries, updateErr
+}
+
+func (b gitTeamBackend) push(ctx context.Context, entries map[string]annotation) error {
+	data, err := yaml.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode annotations: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(b.dir, b.file), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write team annotations: %w", err)
+	}
+	if err := b.git(ctx, "add", b.file); err != nil {
+		return err
+	}
+	if exec.CommandContext(ctx, "git", "-C", b.dir, "diff", "--cached", "--quiet").Run() == nil {
+		return nil
+	}
+	if err := b.git(ctx, "commit", "--quiet", "-m", "Update gosearch annotations"); err != nil {
+		return err
+	}
+	return b.git(ctx, "push", "--quiet")
+}
+
+func parseSharedAnnotations(data []byte) (map[string]annotation, error) {
+	entries := make(map[string]annotation)
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse team annotations: %w", err)
+	}
+	return entries, nil
+}
+
+// mergeAnnotations adds the personal annotations to the shared ones. Tags
+// are combined and a personal note replaces the shared note of the package.
+func mergeAnnotations(shared, personal map[string]annotation) map[string]annotation {
+	merged := make(map[string]annotation, len(shared)+len(personal))
+	for path, a := range shared {
+		merged[path] = a
+	}
+	for path, a := range personal {
+		m := merged[path]
+		if a.Note != "" {
+			m.Note = a.Note
+		}
+		for _, tag := range a.Tags {
+			if !slices.ContainsFunc(m.Tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+				m.Tags = append(slices.Clip(m.Tags), tag)
+			}
+		}
+		merged[path] = m
+	}
+	return merged
+}
+
+// teamPulledMsg carries the shared annotations fetched at startup.
+type teamPulledMsg struct {
+	entries map[string]annotation
+	err     error
+}
+
+func pullTeamCmd(backend teamBackend) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(appCtx, time.Minute)
+		defer cancel()
+		entries, err := backend.pull(ctx)
+		if err != nil {
+			err = fmt.Errorf("failed to update team annotations from %s: %w", backend, err)
+		}
+		return teamPulledMsg{entries: entries, err: err}
+	}
+}
+
+// teamBadge marks results the team approved, prefers, or blocked.
+func (m model) teamBadge(pkg Package) string {
+	shared := m.annotations.getShared(pkg.Path)
+	has := func(tag string) bool {
+		return slices.ContainsFunc(shared.Tags, func(t string) bool { return strings.EqualFold(t, tag) })
+	}
+	switch {
+	case has(tagBlocked):
+		return errorStyle.Render("✗") + " "
+	case has(tagPreferred), has(tagApproved):
+		return successMessageStyle.Render("✓") + " "
+	}
+	return ""
+}
+
+// runTeam implements `gosearch team pull|push`.
+func runTeam(args []string, w io.Writer) error {
+	if len(args) != 1 || (args[0] != "pull" && args[0] != "push") {
+		return fmt.Errorf("usage: gosearch team pull|push")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	backend, err := newTeamBackend(cfg.Team)
+	if err != nil {
+		return err
+	}
+	if backend == nil {
+		return fmt.Errorf("no team backend configured; set team.url or team.git in the config file")
+	}
+
+	ctx := appCtx
+	shared, err := backend.pull(ctx)
+	if err != nil {
+		return err
+	}
+	if args[0] == "pull" {
+		fmt.Fprintf(w, "%d packages annotated by the team at %s\n", len(shared), backend)
+		return nil
+	}
+
+	path, err := annotationsPath()
+	if err != nil {
+		return err
+	}
+	personal, err := loadAnnotations(path)
+	if err != nil {
+		return err
+	}
+	merged := mergeAnnotations(shared, personal.entries)
+	if err := backend.push(ctx, merged); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Pushed annotations of %d packages to %s\n", len(merged), backend)
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// telemetry backs the debug overlay toggled with F2. It is shared by pointer
+// so that View, which works on a copy of the model, can record how long the
+// frame took; the overlay therefore shows the previous frame's render time.
+type telemetry struct {
+	enabled bool
+	filter  time.Duration
+	render  time.Duration
+	rows    int
+}
+
+func (t *telemetry) toggle() {
+	if t != nil {
+		t.enabled = !t.enabled
+	}
+}
+
+// timeFilter records a search that started at start and matched rows rows.
+func (t *telemetry) timeFilter(start time.Time, rows int) {
+	if t != nil {
+		t.filter = time.Since(start)
+		t.rows = rows
+	}
+}
+
+func (t *telemetry) timeRender(start time.Time) {
+	if t != nil {
+		t.render = time.Since(start)
+	}
+}
+
+// View renders the overlay line, or nothing while it is off.
+func (t *telemetry) View() string {
+	if t == nil || !t.enabled {
+		return ""
+	}
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return fmt.Sprintf("filter %s, render %s, %d rows matched, heap %s (%s from the OS)",
+		t.filter.Round(time.Microsecond), t.render.Round(time.Microsecond), t.rows,
+		formatBytes(int64(mem.HeapAlloc)), formatBytes(int64(mem.Sys)))
+}
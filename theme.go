@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// colorNames are the colors of the palette the colors option can override.
+var colorNames = []string{"accent", "text", "selection", "error", "warning", "success", "version", "match"}
+
+// applyColors overrides the palette with the colors given by name, as hex
+// codes like "#ff8800" or ANSI numbers like "205". It must run before the
+// first render, which precomputes the match highlight.
+func applyColors(colors map[string]string) {
+	for name, value := range colors {
+		c := lipgloss.Color(value)
+		switch name {
+		case "accent":
+			inputStyle = inputStyle.Foreground(c)
+			selectedItemStyle = selectedItemStyle.Foreground(c)
+			statusMessageStyle = statusMessageStyle.Foreground(c)
+		case "text":
+			itemStyle = itemStyle.Foreground(c)
+		case "selection":
+			selectedItemStyle = selectedItemStyle.Background(c)
+		case "error":
+			errorStyle = errorStyle.Foreground(c)
+		case "warning":
+			warningStyle = warningStyle.Foreground(c)
+		case "success":
+			successMessageStyle = successMessageStyle.Foreground(c)
+		case "version":
+			versionStyle = versionStyle.Foreground(c)
+		case "match":
+			matchStyle = matchStyle.Foreground(c)
+		}
+	}
+}
+
+func checkColors(colors map[string]string) error {
+	for name, value := range colors {
+		if !slices.Contains(colorNames, name) {
+			return fmt.Errorf("unknown color '%s' in colors", name)
+		}
+		if value == "" {
+			return fmt.Errorf("no value for color '%s' in colors", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"gosearch/search"
+)
+
+// tutorialSource serves a small fixed index, so the tutorial behaves the
+// same everywhere and starts instantly.
+type tutorialSource struct{}
+
+var tutorialModules = []struct{ path, version string }{
+	{"github.com/gorilla/mux", "v1.8.1"},
+	{"github.com/go-chi/chi/v5", "v5.2.1"},
+	{"github.com/julienschmidt/httprouter", "v1.3.0"},
+	{"github.com/gin-gonic/gin", "v1.10.0"},
+	{"github.com/labstack/echo/v4", "v4.13.3"},
+	{"github.com/spf13/cobra", "v1.9.1"},
+	{"github.com/urfave/cli/v2", "v2.27.6"},
+	{"github.com/jmoiron/sqlx", "v1.4.0"},
+	{"github.com/lib/pq", "v1.10.9"},
+	{"github.com/mattn/go-sqlite3", "v1.14.24"},
+	{"github.com/jackc/pgx/v5", "v5.7.2"},
+	{"go.uber.org/zap", "v1.27.0"},
+	{"github.com/rs/zerolog", "v1.33.0"},
+	{"github.com/sirupsen/logrus", "v1.9.3"},
+	{"github.com/stretchr/testify", "v1.10.0"},
+	{"golang.org/x/sync", "v0.11.0"},
+	{"golang.org/x/mod", "v0.23.0"},
+	{"gopkg.in/yaml.v3", "v3.0.1"},
+}
+
+func (tutorialSource) Fetch(ctx context.Context, since time.Time) ([]Package, error) {
+	packages := make([]Package, len(tutorialModules))
+	published := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for i, mod := range tutorialModules {
+		packages[i] = Package{Path: mod.path, Version: mod.version, Timestamp: published.Add(time.Duration(i) * time.Hour)}
+	}
+	return packages, nil
+}
+
+// tutorialStep is one prompt of `gosearch tutorial` and what the user has
+// to do in the real interface to move past it.
+type tutorialStep struct {
+	prompt func(m model, t *tutorial) string
+	done   func(m model, t *tutorial) bool
+}
+
+var tutorialSteps = []tutorialStep{
+	{
+		prompt: func(m model, t *tutorial) string {
+			return "Type http to search. Letters only have to appear in order, so htrtr finds httprouter too."
+		},
+		done: func(m model, t *tutorial) bool { return strings.Contains(m.input.query, "http") },
+	},
+	{
+		prompt: func(m model, t *tutorial) string {
+			return fmt.Sprintf("Clear the query with Backspace and type chi. Move the cursor with %s.", m.keys.label("up", "down"))
+		},
+		done: func(m model, t *tutorial) bool {
+			return strings.Contains(m.input.query, "chi") && m.list.selectedIndex != t.selected
+		},
+	},
+	{
+		prompt: func(m model, t *tutorial) string {
+			return `Now search for "log" with the quotes: quoted words, or words prefixed with +, must appear as written.`
+		},
+		done: func(m model, t *tutorial) bool {
+			return strings.Contains(m.input.query, `"log"`) || strings.Contains(m.input.query, "+log")
+		},
+	},
+	{
+		prompt: func(m model, t *tutorial) string {
+			return fmt.Sprintf("Press %s to cycle the search mode through %s, as shown in the status line, and on to %s again.",
+				m.keys.label("matcher"), strings.Join(search.Matchers(), ", "), t.matcher)
+		},
+		done: func(m model, t *tutorial) bool {
+			if m.engine.Matcher() != t.matcher {
+				t.cycled = true
+			}
+			return t.cycled && m.engine.Matcher() == t.matcher
+		},
+	},
+	{
+		prompt: func(m model, t *tutorial) string {
+			return fmt.Sprintf("Press %s to show the preview pane of the selected module.", m.keys.label("preview"))
+		},
+		done: func(m model, t *tutorial) bool { return m.preview },
+	},
+	{
+		prompt: func(m model, t *tutorial) string {
+			return fmt.Sprintf("Press Esc to leave the query, so that letters run commands, and %s on a result to open the actions menu: copying, go get, notes and tags, licenses, and more.", m.keys.label("actions"))
+		},
+		done: func(m model, t *tutorial) bool { return m.state == stateMenu },
+	},
+	{
+		prompt: func(m model, t *tutorial) string { return "Press Esc to close the menu again." },
+		done:   func(m model, t *tutorial) bool { return m.state == stateBrowsing },
+	},
+	{
+		prompt: func(m model, t *tutorial) string {
+			return fmt.Sprintf("Press %s to list every key binding; any key closes the list.", m.keys.label("help"))
+		},
+		done: func(m model, t *tutorial) bool { return m.state == stateHelp },
+	},
+	{
+		prompt: func(m model, t *tutorial) string {
+			path, err := configPath()
+			if err != nil {
+				path = "config.yaml in the gosearch config directory"
+			}
+			return fmt.Sprintf("Keys, colors, the Enter action, and more are set in %s. Press %s to run the Enter action (%s) and finish.",
+				path, m.keys.label("enter"), m.config.EnterAction)
+		},
+		done: func(m model, t *tutorial) bool { return false },
+	},
+}
+
+// tutorial walks through tutorialSteps as the user follows them.
+type tutorial struct {
+	step int
+	// What was shown when the step began, for steps asking for a change.
+	selected int
+	matcher  string
+	// cycled is set once the matcher changed during the step.
+	cycled bool
+}
+
+// advance moves past every step m already satisfies.
+func (t *tutorial) advance(m model) {
+	if t == nil || m.state == stateLoading {
+		return
+	}
+	for t.step < len(tutorialSteps) && tutorialSteps[t.step].done(m, t) {
+		t.step++
+		t.selected, t.matcher = m.list.selectedIndex, m.engine.Matcher()
+	}
+}
+
+// tutorialView renders the prompt of the current step.
+func (m model) tutorialView() string {
+	t := m.tutorial
+	if t == nil || t.step >= len(tutorialSteps) {
+		return ""
+	}
+	label := successMessageStyle.Render(fmt.Sprintf("Tutorial %d/%d:", t.step+1, len(tutorialSteps)))
+	prompt := inputStyle.Render(tutorialSteps[t.step].prompt(m, t))
+	if m.width > 0 {
+		// The renderer cuts lines at the terminal width instead of wrapping.
+		return lipgloss.NewStyle().Width(m.width).Render(label + prompt)
+	}
+	return label + prompt
+}
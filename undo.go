@@ -0,0 +1,205 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+// modBackup is what go.mod and go.sum of a module held before gosearch last
+// changed them. Only the latest change of each module can be undone.
+type modBackup struct {
+	Root   string    `json:"root"`
+	Change string    `json:"change"`
+	Time   time.Time `json:"time"`
+	GoMod  []byte    `json:"go_mod"`
+	// GoSum is nil if the module had no go.sum yet.
+	GoSum []byte `json:"go_sum,omitempty"`
+}
+
+// modBackupPath returns where the backup of the module at root is kept, in
+// the cache directory, named after a hash of root.
+func modBackupPath(root string) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Join(dir, "undo", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// backupModFiles saves go.mod and go.sum of the module containing dir, or
+// the working directory if dir is empty, before change is made to them.
+// Outside a module, and under --dry-run, it does nothing.
+func backupModFiles(dir, change string) error {
+	if dryRun != nil {
+		return nil
+	}
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	root := findModuleRoot(dir)
+	if root == "" {
+		return nil
+	}
+	b := modBackup{Root: root, Change: change, Time: time.Now()}
+	var err error
+	if b.GoMod, err = os.ReadFile(filepath.Join(root, "go.mod")); err != nil {
+		return fmt.Errorf("failed to back up go.mod: %w", err)
+	}
+	if b.GoSum, err = os.ReadFile(filepath.Join(root, "go.sum")); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to back up go.sum: %w", err)
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("failed to encode go.mod backup: %w", err)
+	}
+	path, err := modBackupPath(root)
+	if err != nil {
+		return err
+	}
+	if err := writeSetting(path, data); err != nil {
+		return fmt.Errorf("failed to back up go.mod: %w", err)
+	}
+	return nil
+}
+
+// loadModBackup returns the backup of the module at root, or nil if there
+// is nothing to undo.
+func loadModBackup(root string) (*modBackup, error) {
+	path, err := modBackupPath(root)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod backup: %w", err)
+	}
+	var b modBackup
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, &ParseError{What: "go.mod backup", Path: path, Err: err}
+	}
+	return &b, nil
+}
+
+// restore writes the backed up go.mod and go.sum back, removing a go.sum
+// the module did not have, and drops the backup.
+func (b *modBackup) restore() error {
+	if err := os.WriteFile(filepath.Join(b.Root, "go.mod"), b.GoMod, 0o644); err != nil {
+		return fmt.Errorf("failed to restore go.mod: %w", err)
+	}
+	sum := filepath.Join(b.Root, "go.sum")
+	if b.GoSum == nil {
+		if err := os.Remove(sum); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove go.sum: %w", err)
+		}
+	} else if err := os.WriteFile(sum, b.GoSum, 0o644); err != nil {
+		return fmt.Errorf("failed to restore go.sum: %w", err)
+	}
+	path, err := modBackupPath(b.Root)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove go.mod backup: %w", err)
+	}
+	return nil
+}
+
+// describe tells what undoing b restores.
+func (b *modBackup) describe() string {
+	return fmt.Sprintf("go.mod and go.sum of %s as they were before %s, on %s", b.Root, b.Change, b.Time.Format("2006-01-02 15:04"))
+}
+
+// undoLastChange finds the backup of the module containing the working
+// directory and restores it.
+func undoLastChange() (*modBackup, error) {
+	cwd, _ := os.Getwd()
+	root := findModuleRoot(cwd)
+	if root == "" {
+		return nil, fmt.Errorf("not inside a Go module")
+	}
+	b, err := loadModBackup(root)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, fmt.Errorf("nothing to undo: gosearch has not changed go.mod of %s", root)
+	}
+	if dryRun != nil {
+		return b, nil
+	}
+	return b, b.restore()
+}
+
+// runUndo implements `gosearch undo`, restoring go.mod and go.sum of the
+// current module to before gosearch last changed them.
+func runUndo(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("undo", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	b, err := undoLastChange()
+	if err != nil {
+		return err
+	}
+	if dryRun != nil {
+		fmt.Fprintf(w, "Dry run: would restore %s.\n", b.describe())
+		return nil
+	}
+	fmt.Fprintf(w, "Restored %s.\n", b.describe())
+	return nil
+}
+
+type undoneMsg struct {
+	backup *modBackup
+	err    error
+}
+
+// undo asks to restore the last change gosearch made to the current
+// module, then flashes how it went.
+func (m *model) undo() tea.Cmd {
+	cwd, _ := os.Getwd()
+	root := findModuleRoot(cwd)
+	if root == "" {
+		return m.flashMessage("Not inside a Go module; nothing to undo.", true)
+	}
+	b, err := loadModBackup(root)
+	if err != nil {
+		return m.flashMessage(err.Error(), true)
+	}
+	if b == nil {
+		return m.flashMessage("Nothing to undo: gosearch has not changed this go.mod.", true)
+	}
+	details := []string{"Restores " + b.describe() + ".", "A vendor/ directory is not restored; run go mod vendor after."}
+	return m.confirm("undo", fmt.Sprintf("Undo %s?", b.Change), details, func(m *model) tea.Cmd {
+		return func() tea.Msg {
+			b, err := undoLastChange()
+			return undoneMsg{backup: b, err: err}
+		}
+	})
+}
+
+// finishUndo flashes the outcome of the undo key.
+func (m *model) finishUndo(msg undoneMsg) tea.Cmd {
+	if msg.err != nil {
+		return m.flashMessage(msg.err.Error(), true)
+	}
+	if dryRun != nil {
+		return m.flashMessage("Dry run: would undo "+msg.backup.Change+".", false)
+	}
+	return m.flashMessage("Undid "+msg.backup.Change+".", false)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// upgradePreview describes moving a dependency of the current module from
+// one version to another.
+type upgradePreview struct {
+	path, from, to string
+	// added and removed compare the dependency closures of the two
+	// versions.
+	added, removed []module.Version
+	// goFrom and goTo are the go directives of the two versions, and
+	// goProject the one of the current module.
+	goFrom, goTo, goProject string
+	// fromRetracted and toRetracted give why the versions were retracted,
+	// if they were.
+	fromRetracted, toRetracted string
+	// breaking lists the API changes between the versions that may break
+	// code using the module; apiErr is why they could not be compared.
+	breaking []apiChange
+	apiErr   error
+}
+
+type upgradePreviewedMsg struct {
+	path    string
+	preview upgradePreview
+	err     error
+}
+
+// upgradeConfirmedMsg asks for the previewed upgrade to be run.
+type upgradeConfirmedMsg struct {
+	path, version string
+}
+
+// previewUpgrade compares the version of path the module in dir uses with
+// the latest one.
+func previewUpgrade(dir, path string) (upgradePreview, error) {
+	p := upgradePreview{path: path}
+	graph, err := projectModuleGraph(dir)
+	if err != nil {
+		return p, err
+	}
+	from, ok := graph[path]
+	if !ok || from == "" {
+		return p, fmt.Errorf("'%s' is not a dependency of the current module", path)
+	}
+	latest, err := fetchLatest(path)
+	if err != nil {
+		return p, fmt.Errorf("failed to look up '%s': %w", path, err)
+	}
+	p.from, p.to = from, latest.Version
+	if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+		if f, err := modfile.ParseLax("go.mod", data, nil); err == nil && f.Go != nil {
+			p.goProject = f.Go.Version
+		}
+	}
+	if semver.Compare(p.to, p.from) <= 0 {
+		return p, nil
+	}
+
+	fromMod, err := fetchGoMod(path, p.from)
+	if err != nil {
+		return p, err
+	}
+	toMod, err := fetchGoMod(path, p.to)
+	if err != nil {
+		return p, err
+	}
+	if fromMod.Go != nil {
+		p.goFrom = fromMod.Go.Version
+	}
+	if toMod.Go != nil {
+		p.goTo = toMod.Go.Version
+	}
+	// Retractions are read from the latest version's go.mod.
+	for _, r := range toMod.Retract {
+		if semver.Compare(p.from, r.Low) >= 0 && semver.Compare(p.from, r.High) <= 0 {
+			p.fromRetracted = retractionReason(r)
+		}
+		if semver.Compare(p.to, r.Low) >= 0 && semver.Compare(p.to, r.High) <= 0 {
+			p.toRetracted = retractionReason(r)
+		}
+	}
+
+	fromDeps, err := buildList(module.Version{Path: path, Version: p.from})
+	if err != nil {
+		return p, err
+	}
+	toDeps, err := buildList(module.Version{Path: path, Version: p.to})
+	if err != nil {
+		return p, err
+	}
+	p.added = missingFrom(toDeps, fromDeps)
+	p.removed = missingFrom(fromDeps, toDeps)
+
+	fromAPI, err := moduleAPI(path, p.from)
+	if err != nil {
+		p.apiErr = err
+		return p, nil
+	}
+	toAPI, err := moduleAPI(path, p.to)
+	if err != nil {
+		p.apiErr = err
+		return p, nil
+	}
+	p.breaking = breakingChanges(fromAPI, toAPI)
+	return p, nil
+}
+
+func retractionReason(r *modfile.Retract) string {
+	if r.Rationale != "" {
+		return r.Rationale
+	}
+	return "no reason given"
+}
+
+// missingFrom returns the modules of list whose path is not in other.
+func missingFrom(list, other []module.Version) []module.Version {
+	paths := make(map[string]bool, len(other))
+	for _, mv := range other {
+		paths[mv.Path] = true
+	}
+	var missing []module.Version
+	for _, mv := range list {
+		if !paths[mv.Path] {
+			missing = append(missing, mv)
+		}
+	}
+	return missing
+}
+
+func previewUpgradeCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		cwd, _ := os.Getwd()
+		dir := findModuleRoot(cwd)
+		if dir == "" {
+			return upgradePreviewedMsg{path: path, err: fmt.Errorf("not inside a Go module")}
+		}
+		p, err := previewUpgrade(dir, path)
+		return upgradePreviewedMsg{path: path, preview: p, err: err}
+	}
+}
+
+// upgradeView is the sub-screen previewing an upgrade before running it.
+type upgradeView struct {
+	path    string
+	loading bool
+	preview upgradePreview
+	err     error
+}
+
+func (u upgradeView) Update(msg tea.Msg) (upgradeView, tea.Cmd) {
+	switch msg := msg.(type) {
+	case upgradePreviewedMsg:
+		if msg.path == u.path {
+			u.loading = false
+			u.preview, u.err = msg.preview, msg.err
+		}
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			return u, closeOverlay
+		case "enter":
+			if !u.loading && u.err == nil && u.preview.to != u.preview.from {
+				confirmed := upgradeConfirmedMsg{path: u.path, version: u.preview.to}
+				return u, func() tea.Msg { return confirmed }
+			}
+		}
+	}
+	return u, nil
+}
+
+func (u upgradeView) View() string {
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf("Upgrade %s\n\n", inputStyle.Render(u.path)))
+
+	p := u.preview
+	switch {
+	case u.loading:
+		s.WriteString(statusMessageStyle.Render("Comparing the dependencies and APIs of the current and latest versions...") + "\n")
+	case u.err != nil:
+		s.WriteString(errorStyle.Render(u.err.Error()) + "\n")
+	case semver.Compare(p.to, p.from) <= 0:
+		s.WriteString(successMessageStyle.Render(fmt.Sprintf("%s is the latest version.", p.from)) + "\n")
+	default:
+		s.WriteString(itemStyle.Render(fmt.Sprintf("Version:   %s → %s", p.from, p.to)) + "\n")
+		if p.fromRetracted != "" {
+			s.WriteString(warningStyle.Render(fmt.Sprintf("           %s is retracted: %s", p.from, p.fromRetracted)) + "\n")
+		}
+		if p.toRetracted != "" {
+			s.WriteString(errorStyle.Render(fmt.Sprintf("           %s is retracted: %s", p.to, p.toRetracted)) + "\n")
+		}
+		goLine := fmt.Sprintf("Go:        %s → %s", orNone(p.goFrom), orNone(p.goTo))
+		if p.goTo != "" && p.goProject != "" && semver.Compare("v"+p.goTo, "v"+p.goProject) > 0 {
+			s.WriteString(warningStyle.Render(goLine+fmt.Sprintf(" (raises this module's go %s)", p.goProject)) + "\n")
+		} else {
+			s.WriteString(itemStyle.Render(goLine) + "\n")
+		}
+		s.WriteString(itemStyle.Render(fmt.Sprintf("Deps:      %d added, %d removed", len(p.added), len(p.removed))) + "\n")
+		for _, mv := range p.added {
+			s.WriteString(successMessageStyle.Render("  + "+mv.String()) + "\n")
+		}
+		for _, mv := range p.removed {
+			s.WriteString(errorStyle.Render("  - "+mv.String()) + "\n")
+		}
+		switch {
+		case p.apiErr != nil:
+			s.WriteString(warningStyle.Render("API:       could not compare: "+p.apiErr.Error()) + "\n")
+		case len(p.breaking) == 0:
+			s.WriteString(successMessageStyle.Render("API:       no breaking changes found") + "\n")
+		default:
+			s.WriteString(errorStyle.Render(fmt.Sprintf("API:       %d breaking %s", len(p.breaking), plural(len(p.breaking), "change", "changes"))) + "\n")
+			for i, c := range p.breaking {
+				if i == maxAPILines {
+					s.WriteString(errorStyle.Render(fmt.Sprintf("  … and %d more", len(p.breaking)-i)) + "\n")
+					break
+				}
+				s.WriteString(errorStyle.Render("  ! "+c.String()) + "\n")
+			}
+		}
+	}
+
+	s.WriteString("\n")
+	if !u.loading && u.err == nil && semver.Compare(p.to, p.from) > 0 {
+		s.WriteString(statusMessageStyle.Render(fmt.Sprintf("Press Enter to run go get -u %s@%s, Esc to go back.", u.path, p.to)))
+	} else {
+		s.WriteString(statusMessageStyle.Render("Press Esc to go back."))
+	}
+	return s.String()
+}
+
+func orNone(v string) string {
+	if v == "" {
+		return "none"
+	}
+	return v
+}
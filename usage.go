@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+// usageEntry is how often and how recently a module was picked.
+type usageEntry struct {
+	Count int `json:"count"`
+	// Last is zero for picks imported without a time.
+	Last time.Time `json:"last,omitzero"`
+}
+
+// usageFile is the on-disk form of the usage history.
+type usageFile struct {
+	// Imported is when `gosearch import-history` last ran.
+	Imported time.Time             `json:"imported,omitzero"`
+	Packages map[string]usageEntry `json:"packages"`
+}
+
+// usageHistory backs the frecency ranking signal: what the user picked in
+// the TUI, plus what `gosearch import-history` found in the shell history.
+type usageHistory struct {
+	path string
+	file usageFile
+	// scores are the frecency of each module path, crediting a package
+	// path to the modules above it too, scaled so the top one is 1.
+	scores map[string]float64
+}
+
+func usagePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "gosearch", "usage.json"), nil
+}
+
+// loadUsage reads the usage history at path. A missing file is an empty
+// history.
+func loadUsage(path string) (*usageHistory, error) {
+	u := &usageHistory{path: path, file: usageFile{Packages: make(map[string]usageEntry)}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return u, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage history: %w", err)
+	}
+	if err := json.Unmarshal(data, &u.file); err != nil {
+		return nil, &ParseError{What: "usage history", Path: path, Err: err}
+	}
+	if u.file.Packages == nil {
+		u.file.Packages = make(map[string]usageEntry)
+	}
+	u.rescore(time.Now())
+	return u, nil
+}
+
+// record counts a pick of path at t; rescore brings the signal up to date.
+func (u *usageHistory) record(path string, t time.Time) {
+	e := u.file.Packages[path]
+	e.Count++
+	if t.After(e.Last) {
+		e.Last = t
+	}
+	u.file.Packages[path] = e
+}
+
+// recencyWeight favors picks of the last days over old ones, like the
+// frecency of browsers and zoxide.
+func recencyWeight(last, now time.Time) float64 {
+	switch age := now.Sub(last); {
+	case age < 24*time.Hour:
+		return 4
+	case age < 7*24*time.Hour:
+		return 2
+	case age < 30*24*time.Hour:
+		return 1
+	}
+	return 0.5
+}
+
+func (u *usageHistory) rescore(now time.Time) {
+	u.scores = make(map[string]float64)
+	top := 0.0
+	for path, e := range u.file.Packages {
+		score := float64(e.Count) * recencyWeight(e.Last, now)
+		// Hosts alone are not modules and would outscore them all.
+		for p := path; strings.Contains(p, "/"); p = p[:strings.LastIndexByte(p, '/')] {
+			u.scores[p] += score
+			top = max(top, u.scores[p])
+		}
+	}
+	for p := range u.scores {
+		u.scores[p] /= top
+	}
+}
+
+// frecency returns the frecency signal of the module at path, in [0, 1].
+func (u *usageHistory) frecency(path string) float64 {
+	if u == nil {
+		return 0
+	}
+	return u.scores[path]
+}
+
+// empty reports whether nothing was picked yet.
+func (u *usageHistory) empty() bool {
+	return u == nil || len(u.file.Packages) == 0
+}
+
+// save writes the history back atomically.
+func (u *usageHistory) save() error {
+	data, err := json.MarshalIndent(u.file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode usage history: %w", err)
+	}
+	return writeUsage(u.path, data)
+}
+
+func writeUsage(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	f, err := os.CreateTemp(dir, ".usage-*")
+	if err != nil {
+		return fmt.Errorf("failed to save usage history: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to save usage history: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to save usage history: %w", err)
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// recordUsage counts a pick of path and saves the history in the
+// background. Without a usage file, nothing is recorded.
+func (m *model) recordUsage(path string) tea.Cmd {
+	if m.usage == nil || m.usage.path == "" {
+		return nil
+	}
+	m.usage.record(path, time.Now())
+	m.usage.rescore(time.Now())
+	data, err := json.MarshalIndent(m.usage.file, "", "  ")
+	if err != nil {
+		return func() tea.Msg { return errMsg(fmt.Errorf("failed to encode usage history: %w", err)) }
+	}
+	file := m.usage.path
+	return func() tea.Msg {
+		if err := writeUsage(file, data); err != nil {
+			return errMsg(err)
+		}
+		return nil
+	}
+}
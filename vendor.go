@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+// vendoredModules are the modules copied into the vendor directory of the
+// current project, by path, with their versions.
+type vendoredModules struct {
+	versions map[string]string
+}
+
+// findVendoredModules reads vendor/modules.txt of the module containing dir.
+// It returns nil if the module is not vendored.
+func findVendoredModules(dir string) *vendoredModules {
+	root := findModuleRoot(dir)
+	if root == "" {
+		return nil
+	}
+	f, err := os.Open(filepath.Join(root, "vendor", "modules.txt"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	v := &vendoredModules{versions: make(map[string]string)}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Modules are listed as "# path version", optionally followed by
+		// "=> replacement"; "## " lines annotate them and other lines are
+		// their packages.
+		line, ok := strings.CutPrefix(scanner.Text(), "# ")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) >= 2 && fields[1] != "=>":
+			v.versions[fields[0]] = fields[1]
+		case len(fields) >= 1:
+			v.versions[fields[0]] = ""
+		}
+	}
+	return v
+}
+
+// version returns the vendored version of the module at path.
+func (v *vendoredModules) version(path string) (string, bool) {
+	if v == nil {
+		return "", false
+	}
+	version, ok := v.versions[path]
+	return version, ok
+}
+
+// vendorBadge marks rows of modules already in the vendor directory.
+func (m model) vendorBadge(pkg Package) string {
+	if _, ok := m.vendored.version(pkg.Path); !ok {
+		return ""
+	}
+	return " " + versionStyle.Render("[vendored]")
+}
+
+// vendorLine tells which version of the selected module is vendored, when
+// it is not the one shown.
+func (m model) vendorLine() string {
+	pkg, ok := m.selectedPackage()
+	if !ok {
+		return ""
+	}
+	version, ok := m.vendored.version(pkg.Path)
+	if !ok || version == "" || version == m.packageVersion(pkg) {
+		return ""
+	}
+	return fmt.Sprintf("Vendored at %s. The vendor action updates it and vendor/.", version)
+}
+
+func goModVendorCmd() tea.Cmd {
+	return func() tea.Msg {
+		out, err := runMutating("", "go", "mod", "vendor")
+		if err != nil {
+			return errMsg(&ActionError{Action: "vendor", Command: "go mod vendor", Output: strings.TrimSpace(string(out)), Err: err})
+		}
+		return nil
+	}
+}
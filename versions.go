@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/bubbletea"
+	"golang.org/x/mod/semver"
+
+	"gosearch/search"
+)
+
+// newestPerPath keeps one of items per module path, the one of the newest
+// version by semver, where the path was first listed. The feed has an entry
+// for every version published, so popular modules would otherwise fill the
+// results.
+func newestPerPath[T any](items []T, pkg func(T) Package) []T {
+	unique := make([]T, 0, len(items))
+	pos := make(map[string]int, len(items))
+	for _, item := range items {
+		p := pkg(item)
+		i, ok := pos[p.Path]
+		if !ok {
+			pos[p.Path] = len(unique)
+			unique = append(unique, item)
+			continue
+		}
+		if semver.Compare(p.Version, pkg(unique[i]).Version) > 0 {
+			unique[i] = item
+		}
+	}
+	return unique
+}
+
+// packageOf and matchPackage tell newestPerPath the package of an entry or
+// a match.
+func packageOf(p Package) Package { return p }
+
+func matchPackage(m search.Match) Package { return m.Package }
+
+// setCorpus searches the newest version of each loaded module. m.packages
+// keeps every entry, as refreshes go on from the newest of those.
+func (m *model) setCorpus() {
+	m.engine.SetPackages(newestPerPath(m.packages, packageOf))
+}
+
+// versionExpansion lists the other loaded versions of one module under its
+// result.
+type versionExpansion struct {
+	path     string
+	versions []Package
+	// shown is whether the versions are among the results, which they are
+	// not when the query leaves out their module.
+	shown bool
+}
+
+// contains reports whether pkg is one of the listed versions rather than the
+// result they are listed under.
+func (x versionExpansion) contains(pkg Package) bool {
+	return x.path == pkg.Path && slices.Contains(x.versions, pkg)
+}
+
+// toggleVersions expands the other loaded versions of the selected module
+// under it, or collapses them again.
+func (m *model) toggleVersions() tea.Cmd {
+	pkg, ok := m.selectedPackage()
+	if !ok {
+		return nil
+	}
+	if m.expansion.path == pkg.Path {
+		m.expansion = versionExpansion{}
+		m.filterPackages()
+		m.selectPath(pkg.Path)
+		return nil
+	}
+	x := versionExpansion{path: pkg.Path}
+	for _, p := range m.packages {
+		if p.Path == pkg.Path && p.Version != pkg.Version && !slices.ContainsFunc(x.versions, func(v Package) bool { return v.Version == p.Version }) {
+			x.versions = append(x.versions, p)
+		}
+	}
+	if len(x.versions) == 0 {
+		return m.flashMessage(fmt.Sprintf("The index lists no other version of %s.", pkg.Path), false)
+	}
+	slices.SortFunc(x.versions, func(a, b Package) int { return semver.Compare(b.Version, a.Version) })
+	m.expansion = x
+	m.filterPackages()
+	return nil
+}
+
+// expandVersions inserts the expanded versions after their module's match.
+func (m *model) expandVersions(matches []search.Match) []search.Match {
+	m.expansion.shown = false
+	if m.expansion.path == "" {
+		return matches
+	}
+	i := slices.IndexFunc(matches, func(match search.Match) bool {
+		return match.Package.Path == m.expansion.path && !m.expansion.contains(match.Package)
+	})
+	if i < 0 {
+		return matches
+	}
+	m.expansion.shown = true
+	rows := make([]search.Match, len(m.expansion.versions))
+	for j, p := range m.expansion.versions {
+		rows[j] = search.Match{Package: p, Index: -1}
+	}
+	return slices.Insert(slices.Clone(matches), i+1, rows...)
+}
+
+// rows is how many results are expanded versions.
+func (x versionExpansion) rows() int {
+	if !x.shown {
+		return 0
+	}
+	return len(x.versions)
+}
+
+// selectPath moves the cursor to the result for path, if listed.
+func (m *model) selectPath(path string) {
+	if i := slices.IndexFunc(m.list.matches, func(match search.Match) bool { return match.Package.Path == path }); i >= 0 {
+		m.list.Select(i)
+	}
+}
+
+// renderVersionRow renders an expanded version, indented under its module.
+func (m model) renderVersionRow(pkg Package) string {
+	line := "  ↳" + versionStyle.Render(pkg.Version)
+	if !pkg.Timestamp.IsZero() {
+		line += versionStyle.Render("published " + pkg.Timestamp.Format("2006-01-02"))
+	}
+	return line
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gosearch/search"
+)
+
+// missingImportPatterns match the go command's errors for an import that no
+// module in the build list provides; the first group is the import path.
+var missingImportPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`no required module provides package ([^\s;:]+)`),
+	regexp.MustCompile(`cannot find module providing package ([^\s;:]+)`),
+	regexp.MustCompile(`package ([^\s;:]+) is not in std`),
+	regexp.MustCompile(`missing go.sum entry for module providing package ([^\s;:]+)`),
+}
+
+// missingImport is an import of the project that no module provides.
+type missingImport struct {
+	path string
+	// pos is where it is imported, as file:line:column.
+	pos string
+}
+
+// missingImportPath returns the import path an error of the go command says
+// is missing, if it is such an error.
+func missingImportPath(text string) (string, bool) {
+	for _, re := range missingImportPatterns {
+		if m := re.FindStringSubmatch(text); m != nil {
+			return strings.Trim(m[1], `"`), true
+		}
+	}
+	return "", false
+}
+
+// findMissingImports loads the packages of the module in dir without
+// changing go.mod or looking anything up, and lists the imports no module
+// provides, sorted by path.
+func findMissingImports(dir string) ([]missingImport, error) {
+	defer track()()
+	cmd := command("go", "list", "-e", "-mod=readonly", "-json=ImportPath,Error,DepsErrors", "./...")
+	cmd.Dir = dir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list failed: %w\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	type packageError struct {
+		Pos string
+		Err string
+	}
+	seen := make(map[missingImport]bool)
+	var missing []missingImport
+	dec := json.NewDecoder(strings.NewReader(string(out)))
+	for {
+		var p struct {
+			Error      *packageError
+			DepsErrors []*packageError
+		}
+		if err := dec.Decode(&p); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse go list output: %w", err)
+		}
+		for _, e := range append(p.DepsErrors, p.Error) {
+			if e == nil {
+				continue
+			}
+			path, ok := missingImportPath(e.Err)
+			if mi := (missingImport{path: path, pos: e.Pos}); ok && !seen[mi] {
+				seen[mi] = true
+				missing = append(missing, mi)
+			}
+		}
+	}
+	slices.SortFunc(missing, func(a, b missingImport) int {
+		return strings.Compare(a.path+" "+a.pos, b.path+" "+b.pos)
+	})
+	return missing, nil
+}
+
+// candidateQuery is a query finding the modules likely to provide the
+// import path: the module the proxy resolves it to, or else modules whose
+// packages are imported under its name.
+func candidateQuery(importPath string) string {
+	if first, _, _ := strings.Cut(importPath, "/"); strings.Contains(first, ".") {
+		if modPath, _, err := resolveModule(importPath); err == nil {
+			return "+" + modPath
+		}
+	}
+	return "pkg:" + search.PackageName(importPath)
+}
+
+// sourceFingerprint sums up the Go files and go.mod of the module in dir by
+// name, size, and modification time, skipping the directories the go
+// command ignores, so that any edit changes it.
+func sourceFingerprint(dir string) string {
+	var b strings.Builder
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != dir && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "vendor" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") && name != "go.mod" {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			fmt.Fprintf(&b, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+		}
+		return nil
+	})
+	return b.String()
+}
+
+// runWatch implements `gosearch watch`: it checks the current module for
+// imports no module provides whenever its Go files change, and opens
+// gosearch searching for candidates of each new one. An import is suggested
+// once until it is resolved.
+func runWatch(args []string, w io.Writer) error {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	interval := flags.Duration("interval", 2*time.Second, "how often to look for changed files")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return fmt.Errorf("usage: gosearch watch [--interval duration]")
+	}
+	cwd, _ := os.Getwd()
+	dir := findModuleRoot(cwd)
+	if dir == "" {
+		return fmt.Errorf("not inside a Go module")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate gosearch: %w", err)
+	}
+
+	fmt.Fprintf(w, "Watching %s for imports no module provides. Press Ctrl+C to stop.\n", dir)
+	suggested := make(map[string]bool)
+	last := ""
+	for ; ; time.Sleep(*interval) {
+		fingerprint := sourceFingerprint(dir)
+		if fingerprint == last {
+			continue
+		}
+		last = fingerprint
+
+		missing, err := findMissingImports(dir)
+		if err != nil {
+			fmt.Fprintf(w, "Error: %v\n", err)
+			continue
+		}
+		current := make(map[string]bool)
+		for _, mi := range missing {
+			current[mi.path] = true
+			if suggested[mi.path] {
+				continue
+			}
+			suggested[mi.path] = true
+			fmt.Fprintf(w, "%s: no module provides %s\n", mi.pos, mi.path)
+
+			tui := exec.Command(exe, "--query", candidateQuery(mi.path))
+			tui.Dir = cwd
+			tui.Stdin, tui.Stdout, tui.Stderr = os.Stdin, os.Stdout, os.Stderr
+			if err := tui.Run(); err != nil {
+				fmt.Fprintf(w, "Error: gosearch failed: %v\n", err)
+			}
+		}
+		// Once resolved, an import may be suggested again if it goes
+		// missing later.
+		for path := range suggested {
+			if !current[path] {
+				delete(suggested, path)
+			}
+		}
+	}
+}
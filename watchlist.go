@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+func watchlistPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "gosearch", "watchlist.json"), nil
+}
+
+// loadWatchlist reads the watchlist file at path, a JSON list of module
+// paths. A missing file is an empty list.
+func loadWatchlist(path string) (*moduleList, error) {
+	return loadModuleList(path, "watchlist")
+}
+
+// toggleWatch adds pkg to the watchlist, or takes it off, and saves the
+// watchlist in the background.
+func (m *model) toggleWatch(pkg Package) tea.Cmd {
+	msg := fmt.Sprintf("Watching %s; refreshes will tell of its new versions.", pkg.Path)
+	if !m.watchlist.toggle(pkg.Path) {
+		msg = fmt.Sprintf("Stopped watching %s.", pkg.Path)
+	}
+	return tea.Batch(saveModuleListCmd(m.watchlist), m.flashMessage(msg, false))
+}
+
+// announceWatched flashes the new versions of watched modules among the
+// entries a refresh brought.
+func (m *model) announceWatched(packages []Package) tea.Cmd {
+	var released []string
+	for _, p := range packages {
+		if m.watchlist.has(p.Path) && !slices.Contains(released, p.Path+"@"+p.Version) {
+			released = append(released, p.Path+"@"+p.Version)
+		}
+	}
+	if len(released) == 0 {
+		return nil
+	}
+	return m.flashMessage("New on your watchlist: "+strings.Join(released, ", ")+".", false)
+}
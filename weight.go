@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// dependencyWeight is what adopting a module would change in the module
+// graph of the current project.
+type dependencyWeight struct {
+	// added are the modules that would join the graph, the adopted one
+	// included, and upgraded the ones it would move to a newer version.
+	added    []module.Version
+	upgraded []module.Version
+}
+
+type weightEstimatedMsg struct {
+	path   string
+	weight *dependencyWeight
+	err    error
+}
+
+// projectModuleGraph lists the modules of the build list of the module in
+// dir, by path, as `go list -m all` reports them.
+func projectModuleGraph(dir string) (map[string]string, error) {
+	defer track()()
+	cmd := command("go", "list", "-m", "all")
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list -m all failed: %w\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	graph := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		// Lines are "path version", or just "path" for the main module, and
+		// may go on with "=> replacement".
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case len(fields) == 1:
+			graph[fields[0]] = ""
+		case len(fields) >= 2:
+			graph[fields[0]] = fields[1]
+		}
+	}
+	return graph, nil
+}
+
+// estimateWeight compares the dependency closure of path@version with the
+// module graph of the project in dir.
+func estimateWeight(dir, path, version string) (*dependencyWeight, error) {
+	graph, err := projectModuleGraph(dir)
+	if err != nil {
+		return nil, err
+	}
+	if have, ok := graph[path]; ok && (have == "" || semver.Compare(version, have) <= 0) {
+		return &dependencyWeight{}, nil
+	}
+	closure, err := buildList(module.Version{Path: path, Version: version})
+	if err != nil {
+		return nil, err
+	}
+
+	w := &dependencyWeight{}
+	for _, mv := range append([]module.Version{{Path: path, Version: version}}, closure...) {
+		have, ok := graph[mv.Path]
+		switch {
+		case !ok:
+			w.added = append(w.added, mv)
+		case have != "" && semver.Compare(mv.Version, have) > 0:
+			w.upgraded = append(w.upgraded, mv)
+		}
+	}
+	return w, nil
+}
+
+// estimateWeightCmd estimates the weight of path@version for the module
+// containing the working directory. Outside a module there is nothing to
+// compare with, and the message carries no weight.
+func estimateWeightCmd(path, version string) tea.Cmd {
+	return func() tea.Msg {
+		cwd, _ := os.Getwd()
+		dir := findModuleRoot(cwd)
+		if dir == "" {
+			return weightEstimatedMsg{path: path}
+		}
+		w, err := estimateWeight(dir, path, version)
+		return weightEstimatedMsg{path: path, weight: w, err: err}
+	}
+}
+
+// String summarizes w for the detail view.
+func (w dependencyWeight) String() string {
+	var parts []string
+	if len(w.added) > 0 {
+		parts = append(parts, fmt.Sprintf("%d new %s", len(w.added), plural(len(w.added), "module", "modules")))
+	}
+	if len(w.upgraded) > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s upgraded", len(w.upgraded), plural(len(w.upgraded), "module", "modules")))
+	}
+	if len(parts) == 0 {
+		return "nothing, already in the module graph"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}